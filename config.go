@@ -5,11 +5,14 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration
 type Config struct {
 	// MikroTik settings
+	MikroTikRouterID string
 	MikroTikHost     string
 	MikroTikPort     string
 	MikroTikUsername string
@@ -20,8 +23,21 @@ type Config struct {
 	RedisPassword string
 	RedisDB       int
 
+	// Replica settings
+	InstanceID      string        // names this replica's consumer group and monitor leases
+	MonitorLeaseTTL time.Duration // how long a customer monitor lease outlives its owner
+
+	// Topic stream retention (per topic family)
+	TopicTrafficRetention time.Duration // customer:<id>:traffic
+	TopicEventsRetention  time.Duration // router:<id>:events
+	TopicAlertsRetention  time.Duration // alerts
+
 	// WebSocket settings
-	WSPort string
+	WSPort             string
+	WSSendQueueSize    int           // messages buffered per /ws client
+	WSSlowClientPolicy string        // drop | disconnect when a client's queue is full
+	WSWriteTimeout     time.Duration // per frame
+	WSPongTimeout      time.Duration // idle clients are closed after this
 
 	// Database settings
 	DBHost         string
@@ -35,14 +51,59 @@ type Config struct {
 
 	// Traffic Monitor settings
 	EnableTrafficMonitor  bool
-	MaxConcurrentMonitors int
+	MaxConcurrentMonitors int // global cap on monitored customers
+	MaxMonitorsPerUser    int // streams per client IP, 0 = unlimited
+	MonitorQueueTimeout   time.Duration
 	AutoStartMonitoring   bool // NEW
+
+	// Traffic overview settings
+	TrafficUplinkInterfaces  []string // summed separately as uplinks, e.g. ether1,sfp1
+	TrafficAggregateInterval time.Duration
+	TrafficTopN              int
+
+	// Uplink interface monitoring settings
+	InterfaceHistoryRetention time.Duration
+
+	// Alerting settings
+	AlertEvalInterval time.Duration
+	AlertSeriesStale  time.Duration // sampled series without a new sample this long are dropped
+
+	// Reachability probe settings
+	EnableReachabilityProbes bool
+	ProbeInterval            time.Duration
+	ProbeCount               int // pings per customer per round
+	ProbeConcurrency         int // probes running at once on the router
+	ProbeDegradedLoss        float64
+	ProbeDegradedRtt         time.Duration
+	ProbeHistoryRetention    time.Duration
+
+	// Router health poller settings
+	RouterHealthInterval  time.Duration
+	RouterHealthRetention time.Duration
+
+	// Health check settings
+	HealthCheckInterval   time.Duration
+	HealthCheckTimeout    time.Duration
+	ReadinessRequireRedis bool // false = Redis down only degrades /readyz
+
+	// Prometheus metrics settings
+	MetricsCustomerTraffic   bool // export per-customer traffic gauges
+	MetricsMaxCustomerSeries int  // busiest sessions exported when enabled
+
+	// Bandwidth test defaults (btest server on the customer CPE)
+	BtestUsername string
+	BtestPassword string
+
+	// Hotspot voucher settings
+	HotspotLoginURL     string // encoded into voucher QR codes, e.g. http://hotspot.lan/login
+	VoucherSyncInterval time.Duration
 }
 
 // LoadConfig loads configuration from environment variables with defaults
 func LoadConfig() *Config {
 	return &Config{
 		// MikroTik
+		MikroTikRouterID: getEnv("MIKROTIK_ROUTER_ID", "mikrotik-001"),
 		MikroTikHost:     getEnv("MIKROTIK_HOST", "192.168.100.1"),
 		MikroTikPort:     getEnv("MIKROTIK_PORT", "8728"),
 		MikroTikUsername: getEnv("MIKROTIK_USER", "admin"),
@@ -53,8 +114,21 @@ func LoadConfig() *Config {
 		RedisPassword: getEnv("REDIS_PASS", ""),
 		RedisDB:       getEnvInt("REDIS_DB", 0),
 
+		// Replicas
+		InstanceID:      getEnv("INSTANCE_ID", defaultInstanceID()),
+		MonitorLeaseTTL: time.Duration(getEnvInt("MONITOR_LEASE_TTL_SECONDS", 15)) * time.Second,
+
+		// Topic streams
+		TopicTrafficRetention: time.Duration(getEnvInt("TOPIC_TRAFFIC_RETENTION_MINUTES", 60)) * time.Minute,
+		TopicEventsRetention:  time.Duration(getEnvInt("TOPIC_EVENTS_RETENTION_HOURS", 24)) * time.Hour,
+		TopicAlertsRetention:  time.Duration(getEnvInt("TOPIC_ALERTS_RETENTION_DAYS", 7)) * 24 * time.Hour,
+
 		// WebSocket
-		WSPort: getEnv("WS_PORT", "8080"),
+		WSPort:             getEnv("WS_PORT", "8080"),
+		WSSendQueueSize:    getEnvInt("WS_SEND_QUEUE_SIZE", 256),
+		WSSlowClientPolicy: getEnv("WS_SLOW_CLIENT_POLICY", "drop"),
+		WSWriteTimeout:     time.Duration(getEnvInt("WS_WRITE_TIMEOUT_SECONDS", 10)) * time.Second,
+		WSPongTimeout:      time.Duration(getEnvInt("WS_PONG_TIMEOUT_SECONDS", 60)) * time.Second,
 
 		// Database
 		DBHost:         getEnv("DB_HOST", "localhost"),
@@ -69,8 +143,52 @@ func LoadConfig() *Config {
 		// Traffic Monitor
 		EnableTrafficMonitor:  getEnvBool("ENABLE_TRAFFIC_MONITOR", true),
 		MaxConcurrentMonitors: getEnvInt("MAX_CONCURRENT_MONITORS", 50),
+		MaxMonitorsPerUser:    getEnvInt("MAX_MONITORS_PER_USER", 10),
+		MonitorQueueTimeout:   time.Duration(getEnvInt("MONITOR_QUEUE_TIMEOUT_SECONDS", 60)) * time.Second,
 		AutoStartMonitoring:   getEnvBool("AUTO_START_MONITORING", false), // NEW
 
+		// Traffic overview
+		TrafficUplinkInterfaces:  getEnvList("TRAFFIC_UPLINK_INTERFACES"),
+		TrafficAggregateInterval: time.Duration(getEnvInt("TRAFFIC_AGGREGATE_INTERVAL_SECONDS", 10)) * time.Second,
+		TrafficTopN:              getEnvInt("TRAFFIC_TOP_N", 10),
+
+		// Uplink interface monitoring
+		InterfaceHistoryRetention: time.Duration(getEnvInt("INTERFACE_HISTORY_RETENTION_DAYS", 30)) * 24 * time.Hour,
+
+		// Alerting
+		AlertEvalInterval: time.Duration(getEnvInt("ALERT_EVAL_INTERVAL_SECONDS", 15)) * time.Second,
+		AlertSeriesStale:  time.Duration(getEnvInt("ALERT_SERIES_STALE_MINUTES", 10)) * time.Minute,
+
+		// Reachability probes
+		EnableReachabilityProbes: getEnvBool("ENABLE_REACHABILITY_PROBES", true),
+		ProbeInterval:            time.Duration(getEnvInt("PROBE_INTERVAL_SECONDS", 60)) * time.Second,
+		ProbeCount:               getEnvInt("PROBE_COUNT", 5),
+		ProbeConcurrency:         getEnvInt("PROBE_CONCURRENCY", 8),
+		ProbeDegradedLoss:        float64(getEnvInt("PROBE_DEGRADED_LOSS_PERCENT", 10)),
+		ProbeDegradedRtt:         time.Duration(getEnvInt("PROBE_DEGRADED_RTT_MS", 150)) * time.Millisecond,
+		ProbeHistoryRetention:    time.Duration(getEnvInt("PROBE_HISTORY_RETENTION_DAYS", 7)) * 24 * time.Hour,
+
+		// Router health
+		RouterHealthInterval:  time.Duration(getEnvInt("ROUTER_HEALTH_INTERVAL_SECONDS", 30)) * time.Second,
+		RouterHealthRetention: time.Duration(getEnvInt("ROUTER_HEALTH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+
+		// Health checks
+		HealthCheckInterval:   time.Duration(getEnvInt("HEALTH_CHECK_INTERVAL_SECONDS", 10)) * time.Second,
+		HealthCheckTimeout:    time.Duration(getEnvInt("HEALTH_CHECK_TIMEOUT_SECONDS", 3)) * time.Second,
+		ReadinessRequireRedis: getEnvBool("READINESS_REQUIRE_REDIS", false),
+
+		// Prometheus metrics
+		MetricsCustomerTraffic:   getEnvBool("METRICS_CUSTOMER_TRAFFIC", false),
+		MetricsMaxCustomerSeries: getEnvInt("METRICS_MAX_CUSTOMER_SERIES", 50),
+
+		// Bandwidth test
+		BtestUsername: getEnv("BTEST_USERNAME", ""),
+		BtestPassword: getEnv("BTEST_PASSWORD", ""),
+
+		// Hotspot vouchers
+		HotspotLoginURL:     getEnv("HOTSPOT_LOGIN_URL", ""),
+		VoucherSyncInterval: time.Duration(getEnvInt("VOUCHER_SYNC_INTERVAL_SECONDS", 60)) * time.Second,
+
 	}
 }
 
@@ -94,6 +212,14 @@ func (c *Config) MikroTikPortInt() int {
 	return port
 }
 
+// defaultInstanceID is the hostname, which is unique per container or pod
+func defaultInstanceID() string {
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "collector-1"
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -121,3 +247,14 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getEnvList reads a comma-separated list, skipping empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
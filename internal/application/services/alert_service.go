@@ -0,0 +1,684 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/notifier"
+
+	"github.com/google/uuid"
+)
+
+const notifyTimeout = 15 * time.Second
+
+// alertSeries is the latest sample of one metric for one subject
+type alertSeries struct {
+	metric    string
+	subject   string
+	label     string // human readable subject, e.g. customer name
+	value     float64
+	updatedAt time.Time
+}
+
+// AlertService evaluates alert rules against metric samples reported by the
+// other services (traffic, ping, session events), keeps one alert per rule and
+// subject, and sends firing/resolved notifications unless silenced.
+type AlertService struct {
+	repo      domain.AlertRepository
+	publisher domain.RedisPublisher
+	interval  time.Duration
+	stale     time.Duration // sampled series older than this are dropped, 0 = never
+
+	mu        sync.Mutex
+	rules     []*domain.AlertRule
+	notifiers map[string]*domain.Notifier
+	silences  []*domain.Silence
+	series    map[string]*alertSeries  // metric|subject -> latest sample
+	pending   map[string]time.Time     // rule|subject -> first breach, waiting for ForSeconds
+	firing    map[string]*domain.Alert // rule|subject -> firing alert
+	outbox    []alertChange            // changes not yet written, published and announced
+
+	flushMu sync.Mutex // keeps flushes, and so database writes, in the order of the changes
+}
+
+// NewAlertService creates a new alert service. interval is how often pending
+// and firing alerts are re-evaluated between samples; a sampled series without
+// a new sample for stale is dropped and its alerts resolved.
+func NewAlertService(repo domain.AlertRepository, publisher domain.RedisPublisher, interval, stale time.Duration) *AlertService {
+	return &AlertService{
+		repo:      repo,
+		publisher: publisher,
+		interval:  interval,
+		stale:     stale,
+		notifiers: make(map[string]*domain.Notifier),
+		series:    make(map[string]*alertSeries),
+		pending:   make(map[string]time.Time),
+		firing:    make(map[string]*domain.Alert),
+	}
+}
+
+// Observe records a metric sample and evaluates the rules for it. label is an
+// optional human readable name of the subject used in notifications. It is safe
+// to call on a nil service, so reporters work without the database.
+func (s *AlertService) Observe(metric, subject, label string, value float64) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	key := metric + "|" + subject
+	series, ok := s.series[key]
+	if !ok {
+		series = &alertSeries{metric: metric, subject: subject}
+		s.series[key] = series
+	}
+	if label != "" {
+		series.label = label
+	}
+	series.value = value
+	series.updatedAt = time.Now()
+
+	s.evaluate(series, time.Now())
+	s.mu.Unlock()
+
+	s.flush()
+}
+
+// Run loads rules, notifiers, silences and firing alerts, then re-evaluates
+// every interval until ctx is cancelled
+func (s *AlertService) Run(ctx context.Context) {
+	if err := s.load(); err != nil {
+		log.Printf("[Alert] Failed to load alerting state: %v", err)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.evaluateAll()
+		}
+	}
+}
+
+func (s *AlertService) load() error {
+	if err := s.reloadRules(); err != nil {
+		return err
+	}
+	if err := s.reloadNotifiers(); err != nil {
+		return err
+	}
+	if err := s.reloadSilences(); err != nil {
+		return err
+	}
+
+	firing, err := s.repo.ListAlerts(domain.AlertStatusFiring, 0)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for _, a := range firing {
+		s.firing[a.RuleID+"|"+a.Subject] = a
+	}
+	rules, notifiers := len(s.rules), len(s.notifiers)
+	s.mu.Unlock()
+
+	log.Printf("[Alert] Loaded %d rules, %d notifiers, %d firing alerts", rules, notifiers, len(firing))
+	return nil
+}
+
+// evaluateAll re-evaluates every series, e.g. for ForSeconds and repeat
+// notifications, and drops series whose reporter stopped sampling them
+func (s *AlertService) evaluateAll() {
+	now := time.Now()
+
+	s.mu.Lock()
+	for key, series := range s.series {
+		if s.stale > 0 && !domain.EventMetric(series.metric) && now.Sub(series.updatedAt) > s.stale {
+			s.expire(series, now)
+			delete(s.series, key)
+			continue
+		}
+		s.evaluate(series, now)
+	}
+	s.mu.Unlock()
+
+	s.flush()
+}
+
+// alertChange is a copy of an alert changed under s.mu. flush writes it to the
+// database, publishes it and sends its notification after the lock is released.
+type alertChange struct {
+	rule    *domain.AlertRule
+	alert   domain.Alert
+	label   string
+	create  bool // new alert rather than an update
+	publish bool // firing or resolved, not a repeat notification
+	notify  bool
+}
+
+// evaluate applies every matching rule to a series and queues the resulting
+// changes for flush. Caller holds s.mu.
+func (s *AlertService) evaluate(series *alertSeries, now time.Time) {
+	for _, rule := range s.rules {
+		if !rule.Matches(series.metric, series.subject) {
+			continue
+		}
+
+		fp := rule.ID + "|" + series.subject
+		alert, isFiring := s.firing[fp]
+
+		if !rule.Breached(series.value) {
+			delete(s.pending, fp)
+			if isFiring {
+				s.resolve(rule, alert, series, now)
+			}
+			continue
+		}
+
+		if isFiring {
+			alert.Value = series.value
+			if s.shouldNotify(rule, alert, now) {
+				alert.LastNotifiedAt = &now
+				s.outbox = append(s.outbox, alertChange{rule: rule, alert: *alert, label: series.label, notify: true})
+			}
+			continue
+		}
+
+		since, ok := s.pending[fp]
+		if !ok {
+			since = now
+			s.pending[fp] = since
+		}
+		if now.Sub(since) < time.Duration(rule.ForSeconds)*time.Second {
+			continue
+		}
+
+		delete(s.pending, fp)
+		s.fire(rule, series, since, now)
+	}
+}
+
+// expire resolves the alerts of a series that is no longer reported, e.g. a
+// customer the probes stopped pinging after it went offline. Caller holds s.mu.
+func (s *AlertService) expire(series *alertSeries, now time.Time) {
+	log.Printf("[Alert] No %s sample for %s since %s, dropping it",
+		series.metric, series.subject, series.updatedAt.Format("15:04:05"))
+
+	for _, rule := range s.rules {
+		if !rule.Matches(series.metric, series.subject) {
+			continue
+		}
+		fp := rule.ID + "|" + series.subject
+		delete(s.pending, fp)
+		if alert, ok := s.firing[fp]; ok {
+			s.resolve(rule, alert, series, now)
+		}
+	}
+}
+
+// fire creates the alert for a rule and subject. Caller holds s.mu.
+func (s *AlertService) fire(rule *domain.AlertRule, series *alertSeries, since, now time.Time) {
+	alert := &domain.Alert{
+		ID:        uuid.New().String(),
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Metric:    rule.Metric,
+		Subject:   series.subject,
+		Severity:  rule.Severity,
+		Status:    domain.AlertStatusFiring,
+		Value:     series.value,
+		Threshold: rule.Threshold,
+		Message:   describeAlert(rule, series),
+		StartsAt:  since,
+	}
+
+	notify := !s.silenced(rule.ID, series.subject, now)
+	if notify {
+		alert.LastNotifiedAt = &now
+	}
+	s.firing[rule.ID+"|"+series.subject] = alert
+
+	log.Printf("[Alert] FIRING %s: %s", rule.Name, alert.Message)
+	s.outbox = append(s.outbox, alertChange{
+		rule: rule, alert: *alert, label: series.label,
+		create: true, publish: true, notify: notify,
+	})
+}
+
+// resolve closes a firing alert. Caller holds s.mu.
+func (s *AlertService) resolve(rule *domain.AlertRule, alert *domain.Alert, series *alertSeries, now time.Time) {
+	alert.Status = domain.AlertStatusResolved
+	alert.Value = series.value
+	alert.ResolvedAt = &now
+	delete(s.firing, rule.ID+"|"+alert.Subject)
+
+	log.Printf("[Alert] RESOLVED %s: %s", rule.Name, alert.Subject)
+
+	// Only announce the resolution of alerts that were announced
+	notify := alert.LastNotifiedAt != nil && !s.silenced(rule.ID, alert.Subject, now)
+	s.outbox = append(s.outbox, alertChange{
+		rule: rule, alert: *alert, label: series.label,
+		publish: true, notify: notify,
+	})
+}
+
+// shouldNotify reports whether a firing alert needs a (repeat) notification:
+// it was silenced when it fired and no longer is, or the repeat interval passed.
+// Caller holds s.mu.
+func (s *AlertService) shouldNotify(rule *domain.AlertRule, alert *domain.Alert, now time.Time) bool {
+	if s.silenced(rule.ID, alert.Subject, now) {
+		return false
+	}
+	if alert.LastNotifiedAt == nil {
+		return true
+	}
+	return rule.RepeatMinutes > 0 && now.Sub(*alert.LastNotifiedAt) >= time.Duration(rule.RepeatMinutes)*time.Minute
+}
+
+// silenced reports whether an active silence covers rule and subject. Caller holds s.mu.
+func (s *AlertService) silenced(ruleID, subject string, at time.Time) bool {
+	for _, silence := range s.silences {
+		if silence.Matches(ruleID, subject, at) {
+			return true
+		}
+	}
+	return false
+}
+
+// flush writes queued changes to the database and publishes them, then sends
+// their notifications in the background. It runs without s.mu, so a slow
+// database or Redis does not block Observe callers.
+func (s *AlertService) flush() {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	changes := s.outbox
+	s.outbox = nil
+	notifiers := s.notifiers
+	s.mu.Unlock()
+
+	for _, change := range changes {
+		var err error
+		if change.create {
+			err = s.repo.CreateAlert(&change.alert)
+		} else {
+			err = s.repo.UpdateAlert(&change.alert)
+		}
+		if err != nil {
+			log.Printf("[Alert] Warning: %v", err)
+		}
+		if change.publish {
+			s.publishAlert(&change.alert)
+		}
+		if !change.notify {
+			continue
+		}
+
+		msg := alertMessage(change.alert, change.label)
+		for _, n := range ruleNotifiers(change.rule, notifiers) {
+			go func(n *domain.Notifier) {
+				if err := sendNotification(n, msg); err != nil {
+					log.Printf("[Alert] Notifier %s (%s) failed: %v", n.Name, n.Type, err)
+				}
+			}(n)
+		}
+	}
+}
+
+// ruleNotifiers returns the enabled notifiers of a rule, or every enabled notifier if it names none
+func ruleNotifiers(rule *domain.AlertRule, notifiers map[string]*domain.Notifier) []*domain.Notifier {
+	var targets []*domain.Notifier
+	if len(rule.NotifierIDs) == 0 {
+		for _, n := range notifiers {
+			if n.Enabled {
+				targets = append(targets, n)
+			}
+		}
+		return targets
+	}
+
+	for _, id := range rule.NotifierIDs {
+		if n, ok := notifiers[id]; ok && n.Enabled {
+			targets = append(targets, n)
+		}
+	}
+	return targets
+}
+
+func sendNotification(n *domain.Notifier, msg notifier.Message) error {
+	impl, err := notifier.New(n)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	return impl.Send(ctx, msg)
+}
+
+// publishAlert broadcasts alert state changes on the global events channel and the alerts topic
+func (s *AlertService) publishAlert(a *domain.Alert) {
+	if s.publisher == nil {
+		return
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":   "alert",
+		"status": a.Status,
+		"alert":  a,
+	})
+	if err := s.publisher.PublishEvent(string(payload), domain.TopicAlerts); err != nil {
+		log.Printf("[Alert] Warning: failed to publish alert: %v", err)
+	}
+}
+
+func describeAlert(rule *domain.AlertRule, series *alertSeries) string {
+	subject := series.subject
+	if series.label != "" && series.label != series.subject {
+		subject = fmt.Sprintf("%s (%s)", series.label, series.subject)
+	}
+	return fmt.Sprintf("%s %s = %.2f (threshold %s %.2f)", subject, rule.Metric, series.value, rule.Operator, rule.Threshold)
+}
+
+func alertMessage(a domain.Alert, label string) notifier.Message {
+	subject := a.Subject
+	if label != "" {
+		subject = label
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Severity: %s\n", a.Severity)
+	fmt.Fprintf(&text, "Rule: %s\n", a.RuleName)
+	fmt.Fprintf(&text, "Subject: %s\n", subject)
+	fmt.Fprintf(&text, "Value: %.2f (threshold %.2f)\n", a.Value, a.Threshold)
+	fmt.Fprintf(&text, "Since: %s\n", a.StartsAt.Format("2006-01-02 15:04:05"))
+	if a.ResolvedAt != nil {
+		fmt.Fprintf(&text, "Resolved: %s\n", a.ResolvedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	return notifier.Message{
+		Title: fmt.Sprintf("[%s] %s - %s", strings.ToUpper(a.Status), a.RuleName, subject),
+		Text:  text.String(),
+		Alert: &a,
+	}
+}
+
+func (s *AlertService) reloadRules() error {
+	rules, err := s.repo.ListRules()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	byID := make(map[string]*domain.AlertRule, len(rules))
+	for _, r := range rules {
+		byID[r.ID] = r
+	}
+
+	s.mu.Lock()
+	s.rules = rules
+
+	for fp, alert := range s.firing {
+		rule, ok := byID[alert.RuleID]
+		if !ok {
+			// Rule deleted, its alerts went with it
+			delete(s.firing, fp)
+			continue
+		}
+		if !rule.Enabled {
+			series := &alertSeries{subject: alert.Subject, value: alert.Value}
+			s.resolve(rule, alert, series, now)
+		}
+	}
+	for fp := range s.pending {
+		if _, ok := byID[strings.SplitN(fp, "|", 2)[0]]; !ok {
+			delete(s.pending, fp)
+		}
+	}
+	for _, series := range s.series {
+		s.evaluate(series, now)
+	}
+	s.mu.Unlock()
+
+	s.flush()
+	return nil
+}
+
+func (s *AlertService) reloadNotifiers() error {
+	list, err := s.repo.ListNotifiers()
+	if err != nil {
+		return err
+	}
+
+	notifiers := make(map[string]*domain.Notifier, len(list))
+	for _, n := range list {
+		notifiers[n.ID] = n
+	}
+
+	s.mu.Lock()
+	s.notifiers = notifiers
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *AlertService) reloadSilences() error {
+	silences, err := s.repo.ListSilences(false)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.silences = silences
+	s.mu.Unlock()
+	return nil
+}
+
+// ListRules returns all alert rules
+func (s *AlertService) ListRules() ([]*domain.AlertRule, error) {
+	return s.repo.ListRules()
+}
+
+// GetRule returns an alert rule
+func (s *AlertService) GetRule(id string) (*domain.AlertRule, error) {
+	return s.repo.GetRule(id)
+}
+
+// CreateRule validates and stores a rule and starts evaluating it
+func (s *AlertService) CreateRule(rule *domain.AlertRule) error {
+	if err := s.validateRule(rule); err != nil {
+		return err
+	}
+	if err := s.repo.CreateRule(rule); err != nil {
+		return err
+	}
+	return s.reloadRules()
+}
+
+// UpdateRule validates and saves a rule; alerts of a disabled rule are resolved
+func (s *AlertService) UpdateRule(rule *domain.AlertRule) error {
+	old, err := s.repo.GetRule(rule.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateRule(rule); err != nil {
+		return err
+	}
+	rule.CreatedAt = old.CreatedAt
+
+	if err := s.repo.UpdateRule(rule); err != nil {
+		return err
+	}
+	return s.reloadRules()
+}
+
+// DeleteRule deletes a rule with its alerts and silences
+func (s *AlertService) DeleteRule(id string) error {
+	if err := s.repo.DeleteRule(id); err != nil {
+		return err
+	}
+	return s.reloadRules()
+}
+
+func (s *AlertService) validateRule(rule *domain.AlertRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range rule.NotifierIDs {
+		if _, ok := s.notifiers[id]; !ok {
+			return fmt.Errorf("%w: notifier %s does not exist", domain.ErrInvalidAlertRule, id)
+		}
+	}
+	return nil
+}
+
+// ListNotifiers returns all notifiers with secrets masked
+func (s *AlertService) ListNotifiers() ([]*domain.Notifier, error) {
+	list, err := s.repo.ListNotifiers()
+	if err != nil {
+		return nil, err
+	}
+
+	masked := make([]*domain.Notifier, 0, len(list))
+	for _, n := range list {
+		masked = append(masked, n.Masked())
+	}
+	return masked, nil
+}
+
+// GetNotifier returns a notifier with secrets masked
+func (s *AlertService) GetNotifier(id string) (*domain.Notifier, error) {
+	n, err := s.repo.GetNotifier(id)
+	if err != nil {
+		return nil, err
+	}
+	return n.Masked(), nil
+}
+
+// CreateNotifier validates and stores a notifier
+func (s *AlertService) CreateNotifier(n *domain.Notifier) error {
+	if err := validateNotifier(n); err != nil {
+		return err
+	}
+	if err := s.repo.CreateNotifier(n); err != nil {
+		return err
+	}
+	return s.reloadNotifiers()
+}
+
+// UpdateNotifier saves a notifier. Secret values sent back masked keep their stored value.
+func (s *AlertService) UpdateNotifier(n *domain.Notifier) error {
+	old, err := s.repo.GetNotifier(n.ID)
+	if err != nil {
+		return err
+	}
+	for k, v := range n.Config {
+		if v == domain.MaskedSecret {
+			n.Config[k] = old.Config[k]
+		}
+	}
+	n.CreatedAt = old.CreatedAt
+
+	if err := validateNotifier(n); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateNotifier(n); err != nil {
+		return err
+	}
+	return s.reloadNotifiers()
+}
+
+// DeleteNotifier deletes a notifier; rules naming it skip it from now on
+func (s *AlertService) DeleteNotifier(id string) error {
+	if err := s.repo.DeleteNotifier(id); err != nil {
+		return err
+	}
+	return s.reloadNotifiers()
+}
+
+// TestNotifier sends a test message through a notifier and returns the delivery error
+func (s *AlertService) TestNotifier(id string) error {
+	n, err := s.repo.GetNotifier(id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return sendNotification(n, notifier.Message{
+		Title: "[TEST] MikroTik alert notifier",
+		Text:  fmt.Sprintf("Test message from notifier %s (%s) at %s\n", n.Name, n.Type, now.Format("2006-01-02 15:04:05")),
+	})
+}
+
+func validateNotifier(n *domain.Notifier) error {
+	if n.Name == "" {
+		return fmt.Errorf("%w: name is required", domain.ErrInvalidNotifier)
+	}
+	if n.Config == nil {
+		n.Config = map[string]string{}
+	}
+	_, err := notifier.New(n)
+	return err
+}
+
+// ListAlerts returns alerts newest first, optionally filtered by status.
+// Firing alerts covered by an active silence are flagged as silenced.
+func (s *AlertService) ListAlerts(status string, limit int) ([]*domain.Alert, error) {
+	alerts, err := s.repo.ListAlerts(status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	for _, a := range alerts {
+		if a.Status == domain.AlertStatusFiring {
+			a.Silenced = s.silenced(a.RuleID, a.Subject, now)
+		}
+	}
+	s.mu.Unlock()
+
+	return alerts, nil
+}
+
+// ListSilences returns silences, optionally including ended ones
+func (s *AlertService) ListSilences(includeExpired bool) ([]*domain.Silence, error) {
+	return s.repo.ListSilences(includeExpired)
+}
+
+// CreateSilence stores a silence; it applies to notifications from now on
+func (s *AlertService) CreateSilence(silence *domain.Silence) error {
+	if err := silence.Validate(); err != nil {
+		return err
+	}
+	if silence.RuleID != nil {
+		if _, err := s.repo.GetRule(*silence.RuleID); err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidSilence, err)
+		}
+	}
+	if err := s.repo.CreateSilence(silence); err != nil {
+		return err
+	}
+	return s.reloadSilences()
+}
+
+// DeleteSilence ends a silence early
+func (s *AlertService) DeleteSilence(id string) error {
+	if err := s.repo.DeleteSilence(id); err != nil {
+		return err
+	}
+	return s.reloadSilences()
+}
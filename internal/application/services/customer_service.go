@@ -3,32 +3,98 @@ package services
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/metrics"
 	"mikrotik-collector/internal/infrastructure/mikrotik"
 )
 
 // CustomerService handles business logic for customers
 type CustomerService struct {
 	repo     domain.CustomerRepository
+	plans    domain.PlanRepository
+	ipam     *IPAMService
 	mtClient *mikrotik.Client
 }
 
 // NewCustomerService creates a new customer service
-func NewCustomerService(repo domain.CustomerRepository, mtClient *mikrotik.Client) *CustomerService {
+func NewCustomerService(
+	repo domain.CustomerRepository,
+	plans domain.PlanRepository,
+	ipam *IPAMService,
+	mtClient *mikrotik.Client,
+) *CustomerService {
 	return &CustomerService{
 		repo:     repo,
+		plans:    plans,
+		ipam:     ipam,
 		mtClient: mtClient,
 	}
 }
 
-// CreateCustomer creates a customer in DB and MikroTik (if PPPoE)
-func (s *CustomerService) CreateCustomer(c *domain.Customer) error {
+// resolvePlanProfile returns the PPP profile name of the given plan
+func (s *CustomerService) resolvePlanProfile(planID string) (string, error) {
+	if s.plans == nil {
+		return "", fmt.Errorf("%w: plans are not available", domain.ErrInvalidCustomer)
+	}
+	plan, err := s.plans.GetPlanByID(planID)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidCustomer, err)
+	}
+	return plan.ProfileName, nil
+}
+
+// applyPlan overrides the customer's PPP profile with the one from its plan
+func (s *CustomerService) applyPlan(c *domain.Customer) error {
+	if c.PlanID == nil || *c.PlanID == "" {
+		return nil
+	}
+	profile, err := s.resolvePlanProfile(*c.PlanID)
+	if err != nil {
+		return err
+	}
+	c.PPPoEProfile = &profile
+	return nil
+}
+
+// CreateCustomer creates a customer in DB and MikroTik (PPPoE secret or hotspot user).
+// A static address is reserved through IPAM when c.StaticIP or ipamSubnetID is set.
+func (s *CustomerService) CreateCustomer(c *domain.Customer, ipamSubnetID string) error {
+	if err := s.applyPlan(c); err != nil {
+		return err
+	}
+
 	// 1. Create in Database first (Source of Truth)
 	if err := s.repo.CreateCustomer(c); err != nil {
 		return fmt.Errorf("failed to create customer in db: %w", err)
 	}
 
+	// Reserve static address (allocation references the customer row)
+	localAddress, remoteAddress := "", ""
+	requestedIP := ""
+	if c.StaticIP != nil {
+		requestedIP = *c.StaticIP
+	}
+	if s.ipam != nil && (ipamSubnetID != "" || requestedIP != "") {
+		allocation, subnet, err := s.ipam.Allocate(c.ID, ipamSubnetID, requestedIP)
+		if err != nil {
+			s.repo.DeleteCustomer(c.ID)
+			return fmt.Errorf("failed to allocate static ip: %w", err)
+		}
+		c.StaticIP = &allocation.Address
+		remoteAddress = allocation.Address
+		if subnet.Gateway != nil {
+			localAddress = *subnet.Gateway
+		}
+		// Persist static_ip right away; the router sync below may be skipped
+		if err := s.repo.UpdateCustomer(c); err != nil {
+			s.ipam.Release(c.ID)
+			s.repo.DeleteCustomer(c.ID)
+			return fmt.Errorf("failed to save static ip: %w", err)
+		}
+	}
+
 	// 2. Sync to MikroTik if PPPoE
 	if c.ServiceType == "pppoe" && s.mtClient != nil {
 		username := ""
@@ -57,13 +123,14 @@ func (s *CustomerService) CreateCustomer(c *domain.Customer) error {
 			username,
 			password,
 			profile,
-			"", // local address
-			"", // remote address - usually assigned by profile/pool, but can be set if static
+			localAddress,
+			remoteAddress, // empty = assigned by profile/pool
 		)
 
 		if err != nil {
-			// Rollback DB
+			// Rollback DB (allocation is released by cascade)
 			log.Printf("Failed to create MikroTik secret for %s: %v. Rolling back DB.", username, err)
+			syncFailed(metrics.SyncTargetPPPSecret, metrics.SyncCreate, metrics.SyncReasonError)
 			s.repo.DeleteCustomer(c.ID)
 			return fmt.Errorf("failed to create mikrotik secret: %w", err)
 		}
@@ -73,9 +140,49 @@ func (s *CustomerService) CreateCustomer(c *domain.Customer) error {
 		s.repo.UpdateCustomer(c)
 	}
 
+	// 3. Sync to MikroTik if Hotspot
+	if c.ServiceType == "hotspot" && s.mtClient != nil {
+		user := hotspotUserFromCustomer(c)
+		if user.Name == "" {
+			s.repo.DeleteCustomer(c.ID)
+			return fmt.Errorf("hotspot username is required")
+		}
+		user.Address = remoteAddress
+
+		mtID, err := s.mtClient.CreateHotspotUser(user)
+		if err != nil {
+			// Rollback DB (allocation is released by cascade)
+			log.Printf("Failed to create MikroTik hotspot user for %s: %v. Rolling back DB.", user.Name, err)
+			syncFailed(metrics.SyncTargetHotspotUser, metrics.SyncCreate, metrics.SyncReasonError)
+			s.repo.DeleteCustomer(c.ID)
+			return fmt.Errorf("failed to create mikrotik hotspot user: %w", err)
+		}
+
+		c.MikrotikID = mtID
+		s.repo.UpdateCustomer(c)
+	}
+
 	return nil
 }
 
+// hotspotUserFromCustomer maps a hotspot customer to an /ip/hotspot/user entry
+func hotspotUserFromCustomer(c *domain.Customer) mikrotik.HotspotUser {
+	user := mikrotik.HotspotUser{
+		Profile: "default",
+		Comment: "customer:" + c.ID,
+	}
+	if c.HotspotUsername != nil {
+		user.Name = *c.HotspotUsername
+	}
+	if c.HotspotPassword != nil {
+		user.Password = *c.HotspotPassword
+	}
+	if c.HotspotMacAddr != nil {
+		user.MacAddress = *c.HotspotMacAddr
+	}
+	return user
+}
+
 // UpdateCustomer updates customer in DB and MikroTik
 func (s *CustomerService) UpdateCustomer(c *domain.Customer) error {
 	// Get existing to compare?
@@ -84,6 +191,10 @@ func (s *CustomerService) UpdateCustomer(c *domain.Customer) error {
 		return err
 	}
 
+	if err := s.applyPlan(c); err != nil {
+		return err
+	}
+
 	// 1. Update Database
 	if err := s.repo.UpdateCustomer(c); err != nil {
 		return fmt.Errorf("failed to update customer in db: %w", err)
@@ -129,6 +240,7 @@ func (s *CustomerService) UpdateCustomer(c *domain.Customer) error {
 				"", "",
 			)
 			if err != nil {
+				syncFailed(metrics.SyncTargetPPPSecret, metrics.SyncUpdate, metrics.SyncReasonError)
 				return fmt.Errorf("failed to update mikrotik secret: %w", err)
 			}
 		} else {
@@ -136,12 +248,284 @@ func (s *CustomerService) UpdateCustomer(c *domain.Customer) error {
 			// Or maybe we should create it?
 			// For Safe Update, let's just log warning.
 			log.Printf("Warning: MikroTik Secret ID not found for customer %s. Skipping MikroTik update.", c.Name)
+			syncFailed(metrics.SyncTargetPPPSecret, metrics.SyncUpdate, metrics.SyncReasonNotFound)
+		}
+	}
+
+	if c.ServiceType == "hotspot" && s.mtClient != nil {
+		mtID := s.findHotspotUserID(oldC)
+		if mtID == "" {
+			log.Printf("Warning: MikroTik hotspot user not found for customer %s. Skipping MikroTik update.", c.Name)
+			syncFailed(metrics.SyncTargetHotspotUser, metrics.SyncUpdate, metrics.SyncReasonNotFound)
+			return nil
+		}
+
+		user := hotspotUserFromCustomer(c)
+		user.Profile = "" // keep the router's profile
+		if err := s.mtClient.UpdateHotspotUser(mtID, user); err != nil {
+			syncFailed(metrics.SyncTargetHotspotUser, metrics.SyncUpdate, metrics.SyncReasonError)
+			return fmt.Errorf("failed to update mikrotik hotspot user: %w", err)
 		}
 	}
 
 	return nil
 }
 
+// PatchCustomer applies a partial update and pushes only the changed fields to MikroTik.
+// If expectedUpdatedAt is set, the patch is rejected with domain.ErrCustomerModified when stale.
+func (s *CustomerService) PatchCustomer(id string, patch domain.CustomerPatch, expectedUpdatedAt *time.Time) (*domain.Customer, error) {
+	oldC, err := s.repo.GetCustomerByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedUpdatedAt != nil && !oldC.UpdatedAt.Equal(*expectedUpdatedAt) {
+		return nil, domain.ErrCustomerModified
+	}
+
+	// A plan change drives the PPP profile
+	if planID, ok := patch["plan_id"]; ok && planID != nil {
+		profile, err := s.resolvePlanProfile(*planID)
+		if err != nil {
+			return nil, err
+		}
+		patch["pppoe_profile"] = &profile
+	}
+
+	// Validate the merged result before touching anything
+	merged := *oldC
+	patch.Apply(&merged)
+
+	if merged.ServiceType != oldC.ServiceType {
+		return nil, fmt.Errorf("%w: service_type cannot be changed with PATCH", domain.ErrInvalidCustomer)
+	}
+	if merged.ServiceType == "pppoe" && (merged.PPPoEUsername == nil || *merged.PPPoEUsername == "") {
+		return nil, fmt.Errorf("%w: pppoe username is required", domain.ErrInvalidCustomer)
+	}
+	if merged.ServiceType == "hotspot" && (merged.HotspotUsername == nil || *merged.HotspotUsername == "") {
+		return nil, fmt.Errorf("%w: hotspot username is required", domain.ErrInvalidCustomer)
+	}
+
+	// Move the IPAM allocation along with static_ip. The current address stays
+	// reserved until the customer row is written, and is restored if that fails.
+	staticIPChanged := false
+	releaseAfterWrite := false
+	reassigned := false
+	var previous *domain.IPAllocation
+	gateway := ""
+	if value, ok := patch["static_ip"]; ok && s.ipam != nil {
+		current, err := s.ipam.GetAllocation(id)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case value == nil || *value == "":
+			staticIPChanged = current != nil || oldC.StaticIP != nil
+			patch["static_ip"] = nil
+			releaseAfterWrite = current != nil
+		case current == nil || current.Address != *value:
+			staticIPChanged = true
+			allocation, subnet, prev, err := s.ipam.Reassign(id, "", *value)
+			if err != nil {
+				return nil, err
+			}
+			reassigned, previous = true, prev
+			patch["static_ip"] = &allocation.Address
+			if subnet.Gateway != nil {
+				gateway = *subnet.Gateway
+			}
+		}
+	}
+
+	// 1. Update Database
+	if err := s.repo.PatchCustomer(id, patch, expectedUpdatedAt); err != nil {
+		if reassigned {
+			s.restoreAllocation(id, previous)
+		}
+		return nil, err
+	}
+	if releaseAfterWrite {
+		if err := s.ipam.Release(id); err != nil {
+			// The address stays reserved without a user; safe, but worth knowing
+			log.Printf("Warning: failed to release static ip of customer %s: %v", id, err)
+		}
+	}
+
+	// 2. Sync changed fields to MikroTik
+	if merged.ServiceType == "pppoe" && s.mtClient != nil {
+		changes := pppoeSecretChanges(patch)
+		if staticIPChanged {
+			changes["remote-address"] = domain.DerefOrEmpty(patch["static_ip"])
+			changes["local-address"] = gateway
+		}
+		if len(changes) > 0 {
+			mtID := s.findPPPoESecretID(oldC)
+			if mtID == "" {
+				log.Printf("Warning: MikroTik Secret ID not found for customer %s. Skipping MikroTik update.", merged.Name)
+				syncFailed(metrics.SyncTargetPPPSecret, metrics.SyncUpdate, metrics.SyncReasonNotFound)
+			} else if err := s.mtClient.PatchPPPoESecret(mtID, changes); err != nil {
+				syncFailed(metrics.SyncTargetPPPSecret, metrics.SyncUpdate, metrics.SyncReasonError)
+				return nil, fmt.Errorf("failed to update mikrotik secret: %w", err)
+			}
+		}
+	}
+
+	if merged.ServiceType == "hotspot" && s.mtClient != nil {
+		changes := hotspotUserChanges(patch)
+		if staticIPChanged {
+			changes["address"] = domain.DerefOrEmpty(patch["static_ip"])
+		}
+		if len(changes) > 0 {
+			mtID := s.findHotspotUserID(oldC)
+			if mtID == "" {
+				log.Printf("Warning: MikroTik hotspot user not found for customer %s. Skipping MikroTik update.", merged.Name)
+				syncFailed(metrics.SyncTargetHotspotUser, metrics.SyncUpdate, metrics.SyncReasonNotFound)
+			} else if err := s.mtClient.PatchHotspotUser(mtID, changes); err != nil {
+				syncFailed(metrics.SyncTargetHotspotUser, metrics.SyncUpdate, metrics.SyncReasonError)
+				return nil, fmt.Errorf("failed to update mikrotik hotspot user: %w", err)
+			}
+		}
+	}
+
+	return s.repo.GetCustomerByID(id)
+}
+
+// restoreAllocation undoes an IPAM reassignment after the customer row could not be written
+func (s *CustomerService) restoreAllocation(id string, previous *domain.IPAllocation) {
+	if err := s.ipam.Restore(id, previous); err != nil {
+		log.Printf("Warning: failed to restore IPAM allocation of customer %s: %v", id, err)
+	}
+}
+
+// pppoeSecretChanges maps patched customer columns to /ppp/secret properties
+func pppoeSecretChanges(patch domain.CustomerPatch) map[string]string {
+	changes := make(map[string]string)
+
+	if v, ok := patch["pppoe_username"]; ok && v != nil {
+		changes["name"] = *v
+	}
+	if v, ok := patch["pppoe_password"]; ok {
+		changes["password"] = ""
+		if v != nil {
+			changes["password"] = *v
+		}
+	}
+	if v, ok := patch["pppoe_profile"]; ok {
+		changes["profile"] = "default"
+		if v != nil && *v != "" {
+			changes["profile"] = *v
+		}
+	}
+
+	return changes
+}
+
+// AssignStaticIP reserves an address through IPAM (first free host of subnetID, or address as given)
+// and pushes it to the router (PPP secret remote-address or hotspot user address).
+func (s *CustomerService) AssignStaticIP(id, subnetID, address string) (*domain.Customer, error) {
+	if s.ipam == nil {
+		return nil, fmt.Errorf("%w: IPAM is not available", domain.ErrInvalidCustomer)
+	}
+
+	c, err := s.repo.GetCustomerByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous address stays reserved until the customer row is written
+	allocation, subnet, previous, err := s.ipam.Reassign(id, subnetID, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.PatchCustomer(id, domain.CustomerPatch{"static_ip": &allocation.Address}, nil); err != nil {
+		s.restoreAllocation(id, previous)
+		return nil, err
+	}
+
+	if c.ServiceType == "pppoe" && s.mtClient != nil {
+		changes := map[string]string{"remote-address": allocation.Address, "local-address": ""}
+		if subnet.Gateway != nil {
+			changes["local-address"] = *subnet.Gateway
+		}
+		if mtID := s.findPPPoESecretID(c); mtID != "" {
+			if err := s.mtClient.PatchPPPoESecret(mtID, changes); err != nil {
+				syncFailed(metrics.SyncTargetPPPSecret, metrics.SyncUpdate, metrics.SyncReasonError)
+				return nil, fmt.Errorf("failed to update mikrotik secret: %w", err)
+			}
+		}
+	}
+
+	if c.ServiceType == "hotspot" && s.mtClient != nil {
+		if mtID := s.findHotspotUserID(c); mtID != "" {
+			if err := s.mtClient.PatchHotspotUser(mtID, map[string]string{"address": allocation.Address}); err != nil {
+				syncFailed(metrics.SyncTargetHotspotUser, metrics.SyncUpdate, metrics.SyncReasonError)
+				return nil, fmt.Errorf("failed to update mikrotik hotspot user: %w", err)
+			}
+		}
+	}
+
+	return s.repo.GetCustomerByID(id)
+}
+
+// ReleaseStaticIP frees the customer's IPAM address and unsets it on the router
+func (s *CustomerService) ReleaseStaticIP(id string) (*domain.Customer, error) {
+	return s.PatchCustomer(id, domain.CustomerPatch{"static_ip": nil}, nil)
+}
+
+// syncFailed counts a customer change that did not reach the router
+func syncFailed(target, operation, reason string) {
+	metrics.CustomerSyncFailures.WithLabelValues(target, operation, reason).Inc()
+}
+
+// hotspotUserChanges maps patched customer columns to /ip/hotspot/user properties
+func hotspotUserChanges(patch domain.CustomerPatch) map[string]string {
+	changes := make(map[string]string)
+
+	if v, ok := patch["hotspot_username"]; ok && v != nil {
+		changes["name"] = *v
+	}
+	if v, ok := patch["hotspot_password"]; ok {
+		changes["password"] = domain.DerefOrEmpty(v)
+	}
+	if v, ok := patch["hotspot_mac_address"]; ok {
+		changes["mac-address"] = domain.DerefOrEmpty(v) // empty unsets the MAC binding
+	}
+
+	return changes
+}
+
+// findHotspotUserID returns the stored MikroTik ID or looks it up by the customer's hotspot username
+func (s *CustomerService) findHotspotUserID(c *domain.Customer) string {
+	if c.MikrotikID != "" {
+		return c.MikrotikID
+	}
+	if c.HotspotUsername == nil || *c.HotspotUsername == "" {
+		return ""
+	}
+	foundID, err := s.mtClient.FindHotspotUserID(*c.HotspotUsername)
+	if err != nil {
+		return ""
+	}
+	return foundID
+}
+
+// findPPPoESecretID returns the stored MikroTik ID or looks it up by the customer's PPPoE username
+func (s *CustomerService) findPPPoESecretID(c *domain.Customer) string {
+	if c.MikrotikID != "" {
+		return c.MikrotikID
+	}
+	if c.PPPoEUsername == nil || *c.PPPoEUsername == "" {
+		return ""
+	}
+	foundID, err := s.mtClient.FindPPPoESecretID(*c.PPPoEUsername)
+	if err != nil {
+		return ""
+	}
+	return foundID
+}
+
 // DeleteCustomer deletes customer from DB and MikroTik
 func (s *CustomerService) DeleteCustomer(id string) error {
 	c, err := s.repo.GetCustomerByID(id)
@@ -161,12 +545,29 @@ func (s *CustomerService) DeleteCustomer(id string) error {
 		if mtID != "" {
 			if err := s.mtClient.DeletePPPoESecret(mtID); err != nil {
 				log.Printf("Warning: Failed to delete MikroTik secret: %v", err)
+				syncFailed(metrics.SyncTargetPPPSecret, metrics.SyncDelete, metrics.SyncReasonError)
 				// Proceed to delete from DB anyway?
 				// Yes, because we want to remove from our system.
 			}
 		}
 	}
 
+	if c.ServiceType == "hotspot" && s.mtClient != nil {
+		if mtID := s.findHotspotUserID(c); mtID != "" {
+			if err := s.mtClient.DeleteHotspotUser(mtID); err != nil {
+				log.Printf("Warning: Failed to delete MikroTik hotspot user: %v", err)
+				syncFailed(metrics.SyncTargetHotspotUser, metrics.SyncDelete, metrics.SyncReasonError)
+			}
+		}
+	}
+
+	// Release static address
+	if s.ipam != nil {
+		if err := s.ipam.Release(id); err != nil {
+			log.Printf("Warning: Failed to release static IP for customer %s: %v", id, err)
+		}
+	}
+
 	// 2. Delete from Database
 	return s.repo.DeleteCustomer(id)
 }
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+)
+
+// healthWaitInterval is how often WaitUntilUp re-reads a dependency's status
+const healthWaitInterval = time.Second
+
+// HealthCheck probes one dependency. Checks that cannot take ctx are still cut
+// off after the check timeout.
+type HealthCheck func(ctx context.Context) error
+
+type healthDependency struct {
+	check    HealthCheck
+	status   domain.DependencyStatus
+	running  bool // a check that ignored its timeout has not returned yet
+	watchers []func(domain.DependencyStatus)
+}
+
+// HealthService checks MikroTik, Redis and the database in the background so
+// health endpoints and degradation policies never wait on a dependency
+type HealthService struct {
+	interval time.Duration
+	timeout  time.Duration
+	started  time.Time
+
+	mu      sync.RWMutex
+	order   []string
+	deps    map[string]*healthDependency
+	lastRun time.Time
+}
+
+// NewHealthService creates a new health service
+func NewHealthService(interval, timeout time.Duration) *HealthService {
+	return &HealthService{
+		interval: interval,
+		timeout:  timeout,
+		started:  time.Now(),
+		deps:     make(map[string]*healthDependency),
+	}
+}
+
+// Register adds a dependency. A required dependency that is down fails readiness;
+// an optional one only degrades it.
+func (s *HealthService) Register(name string, required bool, check HealthCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.deps[name]; !ok {
+		s.order = append(s.order, name)
+	}
+	s.deps[name] = &healthDependency{
+		check: check,
+		status: domain.DependencyStatus{
+			Name:     name,
+			Status:   domain.DependencyUnknown,
+			Required: required,
+			Since:    time.Now(),
+		},
+	}
+}
+
+// Watch calls fn with the dependency's status after every check of it
+func (s *HealthService) Watch(name string, fn func(domain.DependencyStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dep, ok := s.deps[name]; ok {
+		dep.watchers = append(dep.watchers, fn)
+	}
+}
+
+// Run checks every dependency now and then every interval until ctx is cancelled
+func (s *HealthService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll runs the checks concurrently so one slow dependency does not delay the others
+func (s *HealthService) checkAll(ctx context.Context) {
+	s.mu.RLock()
+	names := append([]string(nil), s.order...)
+	s.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			s.checkOne(ctx, name)
+		}(name)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	s.lastRun = time.Now()
+	s.mu.Unlock()
+}
+
+func (s *HealthService) checkOne(ctx context.Context, name string) {
+	s.mu.Lock()
+	dep, ok := s.deps[name]
+	if !ok || dep.running {
+		// A hung check keeps the dependency down until it returns;
+		// starting another one would only pile up goroutines
+		s.mu.Unlock()
+		return
+	}
+	dep.running = true
+	s.mu.Unlock()
+
+	latency, err := runHealthCheck(ctx, s.timeout, dep.check, func() {
+		s.mu.Lock()
+		dep.running = false
+		s.mu.Unlock()
+	})
+	now := time.Now()
+
+	s.mu.Lock()
+	status := domain.DependencyUp
+	if err != nil {
+		status = domain.DependencyDown
+		dep.status.LastError = err.Error()
+		dep.status.LastErrorAt = &now
+	}
+
+	if status != dep.status.Status {
+		if status == domain.DependencyDown {
+			log.Printf("[Health] %s is down: %v", name, err)
+		} else if dep.status.Status == domain.DependencyDown {
+			log.Printf("[Health] %s is up again after %s", name, now.Sub(dep.status.Since).Round(time.Second))
+		}
+		dep.status.Status = status
+		dep.status.Since = now
+	}
+
+	dep.status.LatencyMs = durationMs(latency)
+	dep.status.CheckedAt = &now
+
+	current := dep.status
+	watchers := dep.watchers
+	s.mu.Unlock()
+
+	for _, fn := range watchers {
+		fn(current)
+	}
+}
+
+// runHealthCheck runs check with a timeout and returns how long it took.
+// finished is called once check has returned, which may be after the timeout.
+func runHealthCheck(ctx context.Context, timeout time.Duration, check HealthCheck, finished func()) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer finished()
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return time.Since(start), err
+	case <-ctx.Done():
+		return time.Since(start), fmt.Errorf("check timed out after %s", timeout)
+	}
+}
+
+// Dependencies returns the status of every registered dependency in registration order
+func (s *HealthService) Dependencies() []domain.DependencyStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]domain.DependencyStatus, 0, len(s.order))
+	for _, name := range s.order {
+		list = append(list, s.deps[name].status)
+	}
+	return list
+}
+
+// Available reports whether a dependency may be used. Unregistered dependencies
+// and those not checked yet count as available; only a failed check blocks.
+func (s *HealthService) Available(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dep, ok := s.deps[name]
+	return !ok || dep.status.Status != domain.DependencyDown
+}
+
+// Ready reports whether every required dependency passed its last check
+func (s *HealthService) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, dep := range s.deps {
+		if dep.status.Required && dep.status.Status != domain.DependencyUp {
+			return false
+		}
+	}
+	return true
+}
+
+// Degraded reports whether an optional dependency is down
+func (s *HealthService) Degraded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, dep := range s.deps {
+		if !dep.status.Required && dep.status.Status == domain.DependencyDown {
+			return true
+		}
+	}
+	return false
+}
+
+// Stalled reports whether the background checks stopped running, e.g. a hung
+// check loop; liveness fails so the process gets restarted
+func (s *HealthService) Stalled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	limit := 3*s.interval + s.timeout
+	if s.lastRun.IsZero() {
+		return time.Since(s.started) > limit
+	}
+	return time.Since(s.lastRun) > limit
+}
+
+// Uptime returns how long the service has been running
+func (s *HealthService) Uptime() time.Duration {
+	return time.Since(s.started)
+}
+
+// WaitUntilUp blocks until the dependency passes a check. It returns false if
+// ctx is cancelled first.
+func (s *HealthService) WaitUntilUp(ctx context.Context, name string) bool {
+	ticker := time.NewTicker(healthWaitInterval)
+	defer ticker.Stop()
+
+	for {
+		s.mu.RLock()
+		dep, ok := s.deps[name]
+		up := !ok || dep.status.Status == domain.DependencyUp
+		s.mu.RUnlock()
+		if up {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
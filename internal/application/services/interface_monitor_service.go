@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+
+	"github.com/google/uuid"
+)
+
+const (
+	historyBucket        = time.Minute
+	interfaceRetryDelay  = 10 * time.Second
+	historyPruneInterval = time.Hour
+	defaultHistoryRetain = 30 * 24 * time.Hour
+)
+
+// InterfaceStatus is the live state of a monitored interface
+type InterfaceStatus struct {
+	Interface          *domain.MonitoredInterface `json:"interface"`
+	Online             bool                       `json:"online"` // receiving samples
+	Current            *domain.TrafficRate        `json:"current"`
+	UtilizationPercent float64                    `json:"utilization_percent"`
+	AboveSince         *time.Time                 `json:"above_since"`
+	Alert              *domain.InterfaceAlert     `json:"alert"`
+	LastSampleAt       *time.Time                 `json:"last_sample_at"`
+}
+
+// InterfaceAlertHook is called when an interface alert fires or resolves
+type InterfaceAlertHook func(iface *domain.MonitoredInterface, alert *domain.InterfaceAlert)
+
+// InterfaceMonitorService continuously monitors registered infrastructure interfaces
+// through the shared monitor-traffic listen, stores per-minute history and raises an
+// alert when utilization stays above the interface's threshold.
+type InterfaceMonitorService struct {
+	repo      domain.MonitoredInterfaceRepository
+	client    *mikrotik.Client
+	mux       *mikrotik.TrafficMux
+	publisher domain.RedisPublisher
+	retain    time.Duration
+
+	mu       sync.Mutex
+	ctx      context.Context
+	watchers map[string]*interfaceWatcher // interface ID -> watcher
+	hooks    []InterfaceAlertHook
+}
+
+// interfaceWatcher holds the running state of one monitored interface
+type interfaceWatcher struct {
+	iface  *domain.MonitoredInterface
+	cancel context.CancelFunc
+
+	// guarded by InterfaceMonitorService.mu
+	online     bool
+	current    *domain.TrafficRate
+	lastSample *time.Time
+	aboveSince *time.Time
+	alert      *domain.InterfaceAlert
+}
+
+// minuteBucket accumulates samples for one history row
+type minuteBucket struct {
+	start          time.Time
+	count          uint64
+	rxSum, txSum   uint64
+	rxPeak, txPeak uint64
+}
+
+// NewInterfaceMonitorService creates a new interface monitor service
+func NewInterfaceMonitorService(
+	repo domain.MonitoredInterfaceRepository,
+	client *mikrotik.Client,
+	mux *mikrotik.TrafficMux,
+	publisher domain.RedisPublisher,
+	retain time.Duration,
+) *InterfaceMonitorService {
+	if retain <= 0 {
+		retain = defaultHistoryRetain
+	}
+	return &InterfaceMonitorService{
+		repo:      repo,
+		client:    client,
+		mux:       mux,
+		publisher: publisher,
+		retain:    retain,
+		watchers:  make(map[string]*interfaceWatcher),
+	}
+}
+
+// OnAlert registers a hook called when an alert fires or resolves
+func (s *InterfaceMonitorService) OnAlert(hook InterfaceAlertHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// Run starts watching every enabled interface of the router and prunes old
+// history until ctx is cancelled
+func (s *InterfaceMonitorService) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	list, err := s.repo.ListInterfaces(s.client.Config.RouterID)
+	if err != nil {
+		log.Printf("[InterfaceMonitor] Failed to load interfaces: %v", err)
+	}
+	for _, m := range list {
+		if m.Enabled {
+			s.startWatcher(m)
+		}
+	}
+
+	ticker := time.NewTicker(historyPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.repo.PruneHistory(time.Now().Add(-s.retain))
+			if err != nil {
+				log.Printf("[InterfaceMonitor] %v", err)
+			} else if n > 0 {
+				log.Printf("[InterfaceMonitor] Pruned %d history rows", n)
+			}
+		}
+	}
+}
+
+// CreateInterface registers an interface and starts watching it
+func (s *InterfaceMonitorService) CreateInterface(m *domain.MonitoredInterface) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	m.RouterID = s.client.Config.RouterID
+
+	if err := s.repo.CreateInterface(m); err != nil {
+		return err
+	}
+
+	if m.Enabled {
+		s.startWatcher(m)
+	}
+	return nil
+}
+
+// UpdateInterface updates an interface and restarts its watcher with the new settings
+func (s *InterfaceMonitorService) UpdateInterface(m *domain.MonitoredInterface) error {
+	old, err := s.repo.GetInterface(m.ID)
+	if err != nil {
+		return err
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	m.RouterID = old.RouterID
+	m.CreatedAt = old.CreatedAt
+
+	if err := s.repo.UpdateInterface(m); err != nil {
+		return err
+	}
+
+	s.stopWatcher(m.ID)
+	if m.Enabled {
+		s.startWatcher(m)
+	}
+	return nil
+}
+
+// DeleteInterface stops watching an interface and deletes it with its history
+func (s *InterfaceMonitorService) DeleteInterface(id string) error {
+	if _, err := s.repo.GetInterface(id); err != nil {
+		return err
+	}
+
+	s.stopWatcher(id)
+	return s.repo.DeleteInterface(id)
+}
+
+// GetInterface returns a monitored interface
+func (s *InterfaceMonitorService) GetInterface(id string) (*domain.MonitoredInterface, error) {
+	return s.repo.GetInterface(id)
+}
+
+// ListStatus returns the live status of every monitored interface of the router
+func (s *InterfaceMonitorService) ListStatus() ([]InterfaceStatus, error) {
+	list, err := s.repo.ListInterfaces(s.client.Config.RouterID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]InterfaceStatus, 0, len(list))
+	for _, m := range list {
+		statuses = append(statuses, s.status(m))
+	}
+	return statuses, nil
+}
+
+// GetStatus returns the live status of one monitored interface
+func (s *InterfaceMonitorService) GetStatus(id string) (*InterfaceStatus, error) {
+	m, err := s.repo.GetInterface(id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := s.status(m)
+	return &status, nil
+}
+
+// History returns the per-minute history of an interface
+func (s *InterfaceMonitorService) History(id string, from, to time.Time) ([]*domain.InterfaceTrafficHistory, error) {
+	if _, err := s.repo.GetInterface(id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListHistory(id, from, to)
+}
+
+// ListAlerts returns alerts, optionally for one interface and/or only open ones
+func (s *InterfaceMonitorService) ListAlerts(interfaceID string, openOnly bool) ([]*domain.InterfaceAlert, error) {
+	return s.repo.ListAlerts(interfaceID, openOnly)
+}
+
+// UplinkNames returns the enabled WAN interfaces, used as uplinks by the traffic overview
+func (s *InterfaceMonitorService) UplinkNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for _, w := range s.watchers {
+		if w.iface.Kind == domain.InterfaceKindWAN {
+			names = append(names, w.iface.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *InterfaceMonitorService) status(m *domain.MonitoredInterface) InterfaceStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := InterfaceStatus{Interface: m}
+	if w, ok := s.watchers[m.ID]; ok {
+		status.Online = w.online
+		status.Current = w.current
+		status.AboveSince = w.aboveSince
+		status.Alert = w.alert
+		status.LastSampleAt = w.lastSample
+		if w.current != nil {
+			status.UtilizationPercent = m.Utilization(w.current.RxBitsPerSecond, w.current.TxBitsPerSecond)
+		}
+	}
+	return status
+}
+
+func (s *InterfaceMonitorService) startWatcher(m *domain.MonitoredInterface) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil {
+		return // Run has not started yet; it loads every enabled interface itself
+	}
+	if _, exists := s.watchers[m.ID]; exists {
+		return
+	}
+
+	alert, err := s.repo.GetOpenAlert(m.ID)
+	if err != nil {
+		log.Printf("[InterfaceMonitor] Warning: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	w := &interfaceWatcher{iface: m, cancel: cancel, alert: alert}
+	if alert != nil {
+		started := alert.StartedAt
+		w.aboveSince = &started
+	}
+	s.watchers[m.ID] = w
+
+	log.Printf("[InterfaceMonitor] Watching %s (%s, capacity %s, alert at %d%% for %dm)",
+		m.Name, m.Kind, domain.FormatBitRate(uint64(m.CapacityBps)), m.ThresholdPercent, m.ThresholdMinutes)
+	go s.watch(ctx, w)
+}
+
+func (s *InterfaceMonitorService) stopWatcher(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w, ok := s.watchers[id]; ok {
+		w.cancel()
+		delete(s.watchers, id)
+	}
+}
+
+// watch subscribes to the interface on the shared listen and resubscribes when
+// the interface disappears (e.g. a down link) until ctx is cancelled
+func (s *InterfaceMonitorService) watch(ctx context.Context, w *interfaceWatcher) {
+	var bucket *minuteBucket
+
+	for {
+		subCtx, subCancel := context.WithCancel(ctx)
+		samples, err := s.mux.MonitorTraffic(subCtx, w.iface.Name)
+		if err != nil {
+			// Usually the router is unreachable; the watcher stays registered and tries again
+			subCancel()
+			log.Printf("[InterfaceMonitor] Failed to monitor %s, retrying in %s: %v", w.iface.Name, interfaceRetryDelay, err)
+			if !sleepContext(ctx, interfaceRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		for t := range samples {
+			now := time.Now()
+			s.mu.Lock()
+			rate := domain.NewTrafficRate(t.RxBitsPerSecond, t.TxBitsPerSecond)
+			w.online = true
+			w.current = &rate
+			w.lastSample = &now
+			s.mu.Unlock()
+
+			start := now.Truncate(historyBucket)
+			if bucket != nil && !bucket.start.Equal(start) {
+				s.flush(w, bucket)
+				bucket = nil
+			}
+			if bucket == nil {
+				bucket = &minuteBucket{start: start}
+			}
+			bucket.add(t.RxBitsPerSecond, t.TxBitsPerSecond)
+		}
+		subCancel()
+
+		s.mu.Lock()
+		w.online = false
+		w.current = nil
+		s.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("[InterfaceMonitor] Lost samples for %s, retrying in %s", w.iface.Name, interfaceRetryDelay)
+		if !sleepContext(ctx, interfaceRetryDelay) {
+			return
+		}
+	}
+}
+
+func (b *minuteBucket) add(rx, tx uint64) {
+	b.count++
+	b.rxSum += rx
+	b.txSum += tx
+	if rx > b.rxPeak {
+		b.rxPeak = rx
+	}
+	if tx > b.txPeak {
+		b.txPeak = tx
+	}
+}
+
+// flush stores a finished bucket and evaluates the utilization threshold with its average
+func (s *InterfaceMonitorService) flush(w *interfaceWatcher, b *minuteBucket) {
+	if b.count == 0 {
+		return
+	}
+
+	rxAvg := b.rxSum / b.count
+	txAvg := b.txSum / b.count
+	utilization := w.iface.Utilization(rxAvg, txAvg)
+
+	err := s.repo.SaveHistory(&domain.InterfaceTrafficHistory{
+		InterfaceID:        w.iface.ID,
+		RxAvgBps:           int64(rxAvg),
+		TxAvgBps:           int64(txAvg),
+		RxMaxBps:           int64(b.rxPeak),
+		TxMaxBps:           int64(b.txPeak),
+		UtilizationPercent: utilization,
+		SampledAt:          b.start,
+	})
+	if err != nil {
+		log.Printf("[InterfaceMonitor] Warning: %v", err)
+	}
+
+	s.evaluate(w, b.start.Add(historyBucket), utilization)
+}
+
+// evaluate fires an alert once utilization has stayed at or above the threshold for
+// ThresholdMinutes, and resolves it at the first minute back below
+func (s *InterfaceMonitorService) evaluate(w *interfaceWatcher, at time.Time, utilization float64) {
+	m := w.iface
+	above := utilization >= float64(m.ThresholdPercent)
+
+	s.mu.Lock()
+	var fired, resolved *domain.InterfaceAlert
+
+	switch {
+	case above && w.alert != nil:
+		if utilization > w.alert.PeakPercent {
+			w.alert.PeakPercent = utilization
+			if err := s.repo.UpdateAlert(w.alert); err != nil {
+				log.Printf("[InterfaceMonitor] Warning: %v", err)
+			}
+		}
+	case above:
+		if w.aboveSince == nil {
+			// The bucket that crossed the threshold started a minute before it ended
+			since := at.Add(-historyBucket)
+			w.aboveSince = &since
+		}
+		if at.Sub(*w.aboveSince) >= time.Duration(m.ThresholdMinutes)*time.Minute {
+			alert := &domain.InterfaceAlert{
+				ID:               uuid.New().String(),
+				InterfaceID:      m.ID,
+				ThresholdPercent: m.ThresholdPercent,
+				PeakPercent:      utilization,
+				StartedAt:        *w.aboveSince,
+				FiredAt:          at,
+			}
+			if err := s.repo.CreateAlert(alert); err != nil {
+				log.Printf("[InterfaceMonitor] Warning: %v", err)
+			}
+			w.alert = alert
+			fired = alert
+		}
+	default:
+		w.aboveSince = nil
+		if w.alert != nil {
+			resolvedAt := at
+			w.alert.ResolvedAt = &resolvedAt
+			if err := s.repo.UpdateAlert(w.alert); err != nil {
+				log.Printf("[InterfaceMonitor] Warning: %v", err)
+			}
+			resolved = w.alert
+			w.alert = nil
+		}
+	}
+	hooks := append([]InterfaceAlertHook(nil), s.hooks...)
+	s.mu.Unlock()
+
+	for _, alert := range []*domain.InterfaceAlert{fired, resolved} {
+		if alert == nil {
+			continue
+		}
+		s.publishAlert(m, alert)
+		for _, hook := range hooks {
+			hook(m, alert)
+		}
+	}
+}
+
+// publishAlert broadcasts the alert on the global events channel, the router topic and the alerts topic
+func (s *InterfaceMonitorService) publishAlert(m *domain.MonitoredInterface, a *domain.InterfaceAlert) {
+	status := "firing"
+	if a.ResolvedAt != nil {
+		status = "resolved"
+	}
+	log.Printf("[InterfaceMonitor] Alert %s: %s at %.1f%% (threshold %d%% for %dm)",
+		status, m.Name, a.PeakPercent, m.ThresholdPercent, m.ThresholdMinutes)
+
+	if s.publisher == nil {
+		return
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":      "interface_alert",
+		"status":    status,
+		"router_id": m.RouterID,
+		"interface": m.Name,
+		"alert":     a,
+		"message": fmt.Sprintf("%s utilization above %d%% for %d minutes (peak %.1f%%)",
+			m.Name, m.ThresholdPercent, m.ThresholdMinutes, a.PeakPercent),
+	})
+	if err := s.publisher.PublishEvent(string(payload), domain.RouterEventsTopic(m.RouterID), domain.TopicAlerts); err != nil {
+		log.Printf("[InterfaceMonitor] Warning: failed to publish alert: %v", err)
+	}
+}
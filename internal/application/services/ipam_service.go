@@ -0,0 +1,325 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/netip"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+
+	"github.com/google/uuid"
+)
+
+// IPAMService manages static address subnets and per-customer allocations.
+// Subnets must not overlap the router's /ip/pool ranges, so dynamic and static
+// assignments can never hand out the same address.
+type IPAMService struct {
+	repo     domain.IPAMRepository
+	mtClient *mikrotik.Client
+}
+
+// NewIPAMService creates a new IPAM service
+func NewIPAMService(repo domain.IPAMRepository, mtClient *mikrotik.Client) *IPAMService {
+	return &IPAMService{
+		repo:     repo,
+		mtClient: mtClient,
+	}
+}
+
+// CreateSubnet validates and stores a subnet for the service's router
+func (s *IPAMService) CreateSubnet(subnet *domain.IPSubnet) error {
+	prefix, err := netip.ParsePrefix(subnet.CIDR)
+	if err != nil || !prefix.Addr().Is4() {
+		return fmt.Errorf("%w: %q is not an IPv4 CIDR", domain.ErrInvalidSubnet, subnet.CIDR)
+	}
+	prefix = prefix.Masked()
+	subnet.CIDR = prefix.String()
+	subnet.RouterID = s.mtClient.Config.RouterID
+	subnetRange := mikrotik.PrefixRange(prefix)
+
+	if subnet.Gateway != nil && *subnet.Gateway != "" {
+		gw, err := netip.ParseAddr(*subnet.Gateway)
+		if err != nil || !subnetRange.Contains(gw) {
+			return fmt.Errorf("%w: gateway %s is not inside %s", domain.ErrInvalidSubnet, *subnet.Gateway, subnet.CIDR)
+		}
+	}
+
+	// Must not overlap other IPAM subnets on the same router
+	existing, err := s.repo.ListSubnets(subnet.RouterID)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		otherPrefix, err := netip.ParsePrefix(other.CIDR)
+		if err != nil {
+			continue
+		}
+		if subnetRange.Overlaps(mikrotik.PrefixRange(otherPrefix)) {
+			return fmt.Errorf("%w: %s overlaps subnet %s", domain.ErrAddressConflict, subnet.CIDR, other.CIDR)
+		}
+	}
+
+	// Must not overlap dynamic /ip/pool ranges
+	pools, err := s.mtClient.ListIPPools()
+	if err != nil {
+		return fmt.Errorf("failed to check router pools: %w", err)
+	}
+	for _, pool := range pools {
+		ranges, err := mikrotik.ParsePoolRanges(pool.Ranges)
+		if err != nil {
+			continue
+		}
+		for _, r := range ranges {
+			if subnetRange.Overlaps(r) {
+				return fmt.Errorf("%w: %s overlaps /ip/pool %s (%s)", domain.ErrAddressConflict, subnet.CIDR, pool.Name, pool.Ranges)
+			}
+		}
+	}
+
+	return s.repo.CreateSubnet(subnet)
+}
+
+// DeleteSubnet deletes a subnet that has no allocations left
+func (s *IPAMService) DeleteSubnet(id string) error {
+	allocations, err := s.repo.ListAllocations(id)
+	if err != nil {
+		return err
+	}
+	if len(allocations) > 0 {
+		return fmt.Errorf("%w: subnet still has %d allocations", domain.ErrAddressConflict, len(allocations))
+	}
+	return s.repo.DeleteSubnet(id)
+}
+
+// GetSubnet returns a subnet
+func (s *IPAMService) GetSubnet(id string) (*domain.IPSubnet, error) {
+	return s.repo.GetSubnetByID(id)
+}
+
+// ListSubnets returns the subnets of the service's router
+func (s *IPAMService) ListSubnets() ([]*domain.IPSubnet, error) {
+	return s.repo.ListSubnets(s.mtClient.Config.RouterID)
+}
+
+// ListAllocations returns the allocations of a subnet
+func (s *IPAMService) ListAllocations(subnetID string) ([]*domain.IPAllocation, error) {
+	return s.repo.ListAllocations(subnetID)
+}
+
+// GetAllocation returns the customer's allocation, or nil if it has none
+func (s *IPAMService) GetAllocation(customerID string) (*domain.IPAllocation, error) {
+	return s.repo.GetAllocationByCustomer(customerID)
+}
+
+// Allocate reserves an address for a customer.
+// If address is set it is reserved as-is (subnetID may be empty and is then derived);
+// otherwise the first free host of subnetID is picked.
+func (s *IPAMService) Allocate(customerID, subnetID, address string) (*domain.IPAllocation, *domain.IPSubnet, error) {
+	subnet, chosen, err := s.pickAddress(customerID, subnetID, address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allocation := &domain.IPAllocation{
+		ID:         uuid.New().String(),
+		SubnetID:   subnet.ID,
+		CustomerID: customerID,
+		Address:    chosen.String(),
+	}
+	if err := s.repo.CreateAllocation(allocation); err != nil {
+		return nil, nil, err
+	}
+
+	log.Printf("[IPAM] Allocated %s (%s) to customer %s", allocation.Address, subnet.CIDR, customerID)
+	return allocation, subnet, nil
+}
+
+// Reassign moves the customer's allocation to a new address (picked like
+// Allocate) in a single update, so the old address stays reserved until the
+// new one is taken. A customer without an allocation gets a new one. The
+// previous allocation (nil if none) is returned for Restore.
+func (s *IPAMService) Reassign(customerID, subnetID, address string) (*domain.IPAllocation, *domain.IPSubnet, *domain.IPAllocation, error) {
+	previous, err := s.repo.GetAllocationByCustomer(customerID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if previous == nil {
+		allocation, subnet, err := s.Allocate(customerID, subnetID, address)
+		return allocation, subnet, nil, err
+	}
+
+	subnet, chosen, err := s.pickAddress(customerID, subnetID, address)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	allocation := *previous
+	allocation.SubnetID = subnet.ID
+	allocation.Address = chosen.String()
+	if err := s.repo.UpdateAllocation(&allocation); err != nil {
+		return nil, nil, nil, err
+	}
+
+	log.Printf("[IPAM] Moved customer %s from %s to %s (%s)", customerID, previous.Address, allocation.Address, subnet.CIDR)
+	return &allocation, subnet, previous, nil
+}
+
+// Restore undoes a Reassign: previous is put back, or the allocation is
+// released if the customer had none
+func (s *IPAMService) Restore(customerID string, previous *domain.IPAllocation) error {
+	if previous == nil {
+		return s.Release(customerID)
+	}
+	return s.repo.UpdateAllocation(previous)
+}
+
+// pickAddress validates address, or picks the first free host of subnetID.
+// Addresses held by customerID itself count as free.
+func (s *IPAMService) pickAddress(customerID, subnetID, address string) (*domain.IPSubnet, netip.Addr, error) {
+	subnet, err := s.findSubnet(subnetID, address)
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+
+	prefix, err := netip.ParsePrefix(subnet.CIDR)
+	if err != nil {
+		return nil, netip.Addr{}, fmt.Errorf("%w: stored subnet %s is invalid", domain.ErrInvalidSubnet, subnet.CIDR)
+	}
+
+	reserved, err := s.reservedAddresses(subnet, customerID)
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+	poolRanges, err := s.poolRanges()
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+
+	var chosen netip.Addr
+	if address != "" {
+		chosen, err = netip.ParseAddr(address)
+		if err != nil || !prefix.Contains(chosen) {
+			return nil, netip.Addr{}, fmt.Errorf("%w: %s is not inside %s", domain.ErrInvalidSubnet, address, subnet.CIDR)
+		}
+		if !isHostAddress(prefix, chosen) || reserved[chosen] {
+			return nil, netip.Addr{}, fmt.Errorf("%w: %s is not available", domain.ErrAddressConflict, address)
+		}
+		if inRanges(poolRanges, chosen) {
+			return nil, netip.Addr{}, fmt.Errorf("%w: %s is inside an /ip/pool range", domain.ErrAddressConflict, address)
+		}
+	} else {
+		chosen = firstFreeHost(prefix, reserved, poolRanges)
+		if !chosen.IsValid() {
+			return nil, netip.Addr{}, fmt.Errorf("%w %s", domain.ErrSubnetExhausted, subnet.CIDR)
+		}
+	}
+
+	return subnet, chosen, nil
+}
+
+// Release frees the customer's address (no-op if none)
+func (s *IPAMService) Release(customerID string) error {
+	return s.repo.DeleteAllocationByCustomer(customerID)
+}
+
+// findSubnet returns subnetID, or the router subnet containing address
+func (s *IPAMService) findSubnet(subnetID, address string) (*domain.IPSubnet, error) {
+	if subnetID != "" {
+		return s.repo.GetSubnetByID(subnetID)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("%w: subnet_id or address is required", domain.ErrInvalidSubnet)
+	}
+
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid address %q", domain.ErrInvalidSubnet, address)
+	}
+
+	subnets, err := s.ListSubnets()
+	if err != nil {
+		return nil, err
+	}
+	for _, subnet := range subnets {
+		prefix, err := netip.ParsePrefix(subnet.CIDR)
+		if err == nil && prefix.Contains(addr) {
+			return subnet, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s is not inside any IPAM subnet", domain.ErrInvalidSubnet, address)
+}
+
+// reservedAddresses returns allocated addresses plus the gateway, except the
+// addresses of customerID
+func (s *IPAMService) reservedAddresses(subnet *domain.IPSubnet, customerID string) (map[netip.Addr]bool, error) {
+	allocations, err := s.repo.ListAllocations(subnet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[netip.Addr]bool, len(allocations)+1)
+	for _, a := range allocations {
+		if a.CustomerID == customerID {
+			continue
+		}
+		if addr, err := netip.ParseAddr(a.Address); err == nil {
+			reserved[addr] = true
+		}
+	}
+	if subnet.Gateway != nil {
+		if gw, err := netip.ParseAddr(*subnet.Gateway); err == nil {
+			reserved[gw] = true
+		}
+	}
+
+	return reserved, nil
+}
+
+// poolRanges returns all /ip/pool ranges currently configured on the router
+func (s *IPAMService) poolRanges() ([]mikrotik.AddressRange, error) {
+	pools, err := s.mtClient.ListIPPools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check router pools: %w", err)
+	}
+
+	var ranges []mikrotik.AddressRange
+	for _, pool := range pools {
+		r, err := mikrotik.ParsePoolRanges(pool.Ranges)
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, r...)
+	}
+	return ranges, nil
+}
+
+// isHostAddress excludes the network and broadcast address (except for /31 and /32)
+func isHostAddress(prefix netip.Prefix, addr netip.Addr) bool {
+	if prefix.Bits() >= 31 {
+		return true
+	}
+	r := mikrotik.PrefixRange(prefix)
+	return addr != r.Start && addr != r.End
+}
+
+func inRanges(ranges []mikrotik.AddressRange, addr netip.Addr) bool {
+	for _, r := range ranges {
+		if r.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstFreeHost(prefix netip.Prefix, reserved map[netip.Addr]bool, poolRanges []mikrotik.AddressRange) netip.Addr {
+	prefix = prefix.Masked()
+	for addr := prefix.Addr(); addr.IsValid() && prefix.Contains(addr); addr = addr.Next() {
+		if !isHostAddress(prefix, addr) || reserved[addr] || inRanges(poolRanges, addr) {
+			continue
+		}
+		return addr
+	}
+	return netip.Addr{}
+}
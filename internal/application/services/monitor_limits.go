@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+)
+
+// Monitor limit scopes reported in MonitorLimitError
+const (
+	MonitorScopeGlobal = "global"
+	MonitorScopeUser   = "user"
+)
+
+// MonitorLimits caps concurrent traffic monitors. Zero means unlimited.
+// Global counts distinct monitored customers (joining an existing monitor is
+// free); a service monitors one router, so Global is also its per-router cap.
+// PerUser counts the streams held by one client.
+type MonitorLimits struct {
+	Global  int `json:"global"`
+	PerUser int `json:"per_user"`
+
+	// QueueTimeout bounds how long WaitForSlot keeps a client queued (0 = until the client leaves)
+	QueueTimeout time.Duration `json:"-"`
+}
+
+// MonitorLimitError is returned when a monitor request exceeds a limit
+type MonitorLimitError struct {
+	Scope string
+	Limit int
+}
+
+func (e *MonitorLimitError) Error() string {
+	return fmt.Sprintf("%s (%s limit %d)", domain.ErrMonitorLimitReached, e.Scope, e.Limit)
+}
+
+// Unwrap lets errors.Is match domain.ErrMonitorLimitReached
+func (e *MonitorLimitError) Unwrap() error {
+	return domain.ErrMonitorLimitReached
+}
+
+// MonitorUsage is a snapshot of monitor admission state
+type MonitorUsage struct {
+	Active    int            `json:"active"`
+	Queued    int            `json:"queued"`
+	Observers int            `json:"observers"` // streams attached to active monitors
+	Following int            `json:"following"` // active monitors relaying another replica's events
+	PerUser   map[string]int `json:"per_user"`
+	Limits    MonitorLimits  `json:"limits"`
+}
+
+// monitorWaiter is a queued client waiting for a free slot
+type monitorWaiter struct {
+	ready chan struct{}
+}
+
+// admit checks the limits for a new stream. Caller holds s.mu.
+func (s *OnDemandTrafficService) admit(user string, newMonitor bool) error {
+	if s.limits.PerUser > 0 && s.userStreams[user] >= s.limits.PerUser {
+		return &MonitorLimitError{Scope: MonitorScopeUser, Limit: s.limits.PerUser}
+	}
+	if !newMonitor {
+		return nil
+	}
+
+	running := len(s.activeMonitors) + s.pendingMonitors
+	if s.limits.Global > 0 && running >= s.limits.Global {
+		return &MonitorLimitError{Scope: MonitorScopeGlobal, Limit: s.limits.Global}
+	}
+	return nil
+}
+
+// releaseUserStream drops one stream from the user's count. Caller holds s.mu.
+func (s *OnDemandTrafficService) releaseUserStream(user string) {
+	if s.userStreams[user] <= 1 {
+		delete(s.userStreams, user)
+	} else {
+		s.userStreams[user]--
+	}
+}
+
+// wakeWaiters lets every queued client retry admission. Caller holds s.mu.
+func (s *OnDemandTrafficService) wakeWaiters() {
+	s.releaseSeq++
+	for _, w := range s.waiters {
+		close(w.ready)
+	}
+	s.waiters = nil
+}
+
+// removeWaiter drops w from the queue if it is still there. Caller holds s.mu.
+func (s *OnDemandTrafficService) removeWaiter(w *monitorWaiter) {
+	for i, other := range s.waiters {
+		if other == w {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// WaitForSlot starts monitoring like StartMonitoring, but when a limit is reached the
+// caller is queued and retried each time a slot frees, until ctx is done or the queue timeout passes.
+// onQueued is called every time the caller (re)enters the queue. Freed slots go to
+// whichever waiter retries first, so no queue position is reported.
+func (s *OnDemandTrafficService) WaitForSlot(
+	ctx context.Context,
+	customerID string,
+	user string,
+	onQueued func(),
+) (<-chan domain.TrafficEvent, error) {
+	var timeout <-chan time.Time
+	if s.limits.QueueTimeout > 0 {
+		timer := time.NewTimer(s.limits.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		s.mu.Lock()
+		seq := s.releaseSeq
+		s.mu.Unlock()
+
+		ch, err := s.StartMonitoring(ctx, customerID, user)
+		if !errors.Is(err, domain.ErrMonitorLimitReached) {
+			return ch, err
+		}
+
+		w := &monitorWaiter{ready: make(chan struct{})}
+		s.mu.Lock()
+		if s.releaseSeq != seq {
+			// A slot freed while we were being rejected; retry right away
+			s.mu.Unlock()
+			continue
+		}
+		s.waiters = append(s.waiters, w)
+		s.mu.Unlock()
+
+		if onQueued != nil {
+			onQueued()
+		}
+
+		select {
+		case <-w.ready:
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.removeWaiter(w)
+			s.mu.Unlock()
+			return nil, ctx.Err()
+		case <-timeout:
+			s.mu.Lock()
+			s.removeWaiter(w)
+			s.mu.Unlock()
+			return nil, err
+		}
+	}
+}
+
+// Usage returns current monitor counts and limits
+func (s *OnDemandTrafficService) Usage() MonitorUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	perUser := make(map[string]int, len(s.userStreams))
+	for user, n := range s.userStreams {
+		perUser[user] = n
+	}
+
+	observers, following := 0, 0
+	for _, m := range s.activeMonitors {
+		observers += len(m.Observers)
+		if !m.Owner {
+			following++
+		}
+	}
+
+	return MonitorUsage{
+		Active:    len(s.activeMonitors),
+		Queued:    len(s.waiters),
+		Observers: observers,
+		Following: following,
+		PerUser:   perUser,
+		Limits:    s.limits,
+	}
+}
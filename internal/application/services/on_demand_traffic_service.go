@@ -5,17 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strings"
 	"sync"
 	"time"
 
 	"mikrotik-collector/internal/domain"
 	"mikrotik-collector/internal/infrastructure/mikrotik"
+
+	"github.com/google/uuid"
 )
 
 // OnDemandTrafficService monitors traffic only for requested customers
 type OnDemandTrafficService struct {
 	client    *mikrotik.Client
+	mux       *mikrotik.TrafficMux // monitor-traffic listen shared with the interface monitor
 	db        domain.CustomerRepository
 	publisher domain.RedisPublisher
 
@@ -26,6 +28,17 @@ type OnDemandTrafficService struct {
 	// Lock for preventing duplicate start/stops per customer
 	monitorLocks map[string]*sync.Mutex
 	locksMu      sync.Mutex
+
+	// Admission control (guarded by mu)
+	limits          MonitorLimits
+	userStreams     map[string]int // API user -> open streams
+	pendingMonitors int            // slots reserved by monitors still starting
+	releaseSeq      uint64         // bumped whenever a slot frees
+	waiters         []*monitorWaiter
+
+	// Replica coordination; nil = this instance monitors every customer itself
+	coordinator domain.MonitorCoordinator
+	leaseTTL    time.Duration
 }
 
 // CustomerMonitor represents a monitored customer session
@@ -34,27 +47,51 @@ type CustomerMonitor struct {
 	InterfaceName string
 	Cancel        context.CancelFunc
 	Clients       int
-	Observers     map[chan domain.CustomerTrafficData]bool
-	restartCount  int // Track restart attempts
+	Observers     map[chan domain.TrafficEvent]bool
+
+	// Owner is set while this replica holds the customer's lease and watches
+	// the router; otherwise the monitor relays the owning replica's events
+	Owner      bool
+	leaseToken string
+
+	// Signals from pppoe-up/pppoe-down callbacks (buffered, non-blocking)
+	sessionUp   chan struct{}
+	sessionDown chan struct{}
 }
 
 // NewOnDemandTrafficService creates a new on-demand traffic service
 func NewOnDemandTrafficService(
 	client *mikrotik.Client,
+	mux *mikrotik.TrafficMux,
 	db domain.CustomerRepository,
 	publisher domain.RedisPublisher,
+	limits MonitorLimits,
 ) *OnDemandTrafficService {
 	return &OnDemandTrafficService{
 		client:         client,
+		mux:            mux,
 		db:             db,
 		publisher:      publisher,
 		activeMonitors: make(map[string]*CustomerMonitor),
 		monitorLocks:   make(map[string]*sync.Mutex),
+		limits:         limits,
+		userStreams:    make(map[string]int),
 	}
 }
 
-// StartMonitoring starts monitoring a specific customer if not already started
-func (s *OnDemandTrafficService) StartMonitoring(ctx context.Context, customerID string) (<-chan domain.CustomerTrafficData, error) {
+// SetCoordinator shares monitors with other replicas through c. Leases expire
+// after ttl and are renewed every ttl/3.
+func (s *OnDemandTrafficService) SetCoordinator(c domain.MonitorCoordinator, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coordinator = c
+	s.leaseTTL = ttl
+}
+
+// StartMonitoring starts monitoring a specific customer if not already started.
+// user identifies the API caller for the per-user limit; a *MonitorLimitError is
+// returned when any limit is reached.
+func (s *OnDemandTrafficService) StartMonitoring(ctx context.Context, customerID string, user string) (<-chan domain.TrafficEvent, error) {
 	// 1. Get lock for this customer to prevent race conditions
 	s.locksMu.Lock()
 	if _, ok := s.monitorLocks[customerID]; !ok {
@@ -68,6 +105,12 @@ func (s *OnDemandTrafficService) StartMonitoring(ctx context.Context, customerID
 
 	s.mu.Lock()
 	monitor, exists := s.activeMonitors[customerID]
+	if err := s.admit(user, !exists); err != nil {
+		s.mu.Unlock()
+		log.Printf("[OnDemand] Rejected monitor for customer %s (user %s): %v", customerID, user, err)
+		return nil, err
+	}
+	s.userStreams[user]++
 	if exists {
 		// Already monitoring, just increment client count
 		monitor.Clients++
@@ -77,8 +120,20 @@ func (s *OnDemandTrafficService) StartMonitoring(ctx context.Context, customerID
 		// Subscribe to existing monitor
 		return s.addObserver(ctx, customerID)
 	}
+	s.pendingMonitors++
 	s.mu.Unlock()
 
+	started := false
+	defer func() {
+		s.mu.Lock()
+		s.pendingMonitors--
+		if !started {
+			s.releaseUserStream(user)
+			s.wakeWaiters()
+		}
+		s.mu.Unlock()
+	}()
+
 	// 2. Not monitoring yet, need to start.
 	// Get customer details first
 	customer, err := s.db.GetCustomerByID(customerID)
@@ -91,14 +146,22 @@ func (s *OnDemandTrafficService) StartMonitoring(ctx context.Context, customerID
 		return nil, fmt.Errorf("customer has no PPPoE username configured")
 	}
 
-	// Get the actual active PPPoE interface from MikroTik
-	interfaceName, err := s.getActiveInterfaceForCustomer(customer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get active interface: %w", err)
-	}
+	// With other replicas running, only the lease holder watches the router
+	owner, token := s.acquireLease(customerID)
 
-	if interfaceName == "" {
-		return nil, fmt.Errorf("customer is not currently connected (no active PPPoE session)")
+	interfaceName := ""
+	if owner {
+		// Get the actual active PPPoE interface from MikroTik
+		interfaceName, err = s.getActiveInterfaceForCustomer(customer)
+		if err != nil {
+			s.releaseLease(customerID, token)
+			return nil, fmt.Errorf("failed to get active interface: %w", err)
+		}
+
+		if interfaceName == "" {
+			s.releaseLease(customerID, token)
+			return nil, fmt.Errorf("customer is not currently connected (no active PPPoE session)")
+		}
 	}
 
 	// Create monitor context
@@ -109,83 +172,60 @@ func (s *OnDemandTrafficService) StartMonitoring(ctx context.Context, customerID
 		InterfaceName: interfaceName,
 		Cancel:        cancel,
 		Clients:       1,
-		Observers:     make(map[chan domain.CustomerTrafficData]bool),
-		restartCount:  0,
+		Observers:     make(map[chan domain.TrafficEvent]bool),
+		Owner:         owner,
+		leaseToken:    token,
+		sessionUp:     make(chan struct{}, 1),
+		sessionDown:   make(chan struct{}, 1),
 	}
 
 	s.mu.Lock()
 	s.activeMonitors[customerID] = monitor
+	started = true
 	s.mu.Unlock()
 
 	// Start the actual background monitoring for this customer
-	go s.runMonitorLoop(monitorCtx, customer, interfaceName)
-
-	log.Printf("[OnDemand] Started monitoring for customer %s (%s) on interface %s", 
-		customer.Name, *customer.PPPoEUsername, interfaceName)
+	go s.runMonitor(monitorCtx, monitor, customer)
+
+	if owner {
+		log.Printf("[OnDemand] Started monitoring for customer %s (%s) on interface %s",
+			customer.Name, *customer.PPPoEUsername, interfaceName)
+	} else {
+		log.Printf("[OnDemand] Following customer %s (%s), another replica owns the monitor",
+			customer.Name, *customer.PPPoEUsername)
+	}
 
 	return s.addObserver(ctx, customerID)
 }
 
+// MonitoredInterfaces returns the interfaces covered by the shared monitor-traffic listen
+func (s *OnDemandTrafficService) MonitoredInterfaces() []string {
+	return s.mux.Interfaces()
+}
+
 // getActiveInterfaceForCustomer finds the active PPPoE interface for a customer
 func (s *OnDemandTrafficService) getActiveInterfaceForCustomer(customer *domain.Customer) (string, error) {
 	if customer.PPPoEUsername == nil || *customer.PPPoEUsername == "" {
 		return "", fmt.Errorf("no PPPoE username configured")
 	}
 
-	username := strings.ToLower(strings.TrimSpace(*customer.PPPoEUsername))
-	
-	// Query MikroTik for active PPPoE interfaces
-	reply, err := s.client.Run(
-		"/interface/print",
-		"?type=pppoe-in",
-		"?running=yes",
-	)
+	interfaceName, err := s.client.FindActivePPPoEInterface(*customer.PPPoEUsername)
 	if err != nil {
-		return "", fmt.Errorf("failed to query MikroTik interfaces: %w", err)
+		return "", err
 	}
 
-	// Search for interface matching this username
-	for _, re := range reply.Re {
-		interfaceName := re.Map["name"]
-		if interfaceName == "" {
-			continue
-		}
-
-		// Extract username from interface name
-		// Format: <pppoe-username>
-		extractedUser := extractPPPoEUsername(interfaceName)
-		if extractedUser == "" {
-			continue
-		}
-
-		extractedUser = strings.ToLower(strings.TrimSpace(extractedUser))
-		
-		if extractedUser == username {
-			log.Printf("[OnDemand] Found active interface '%s' for username '%s'", 
-				interfaceName, username)
-			return interfaceName, nil
-		}
-	}
-
-	return "", fmt.Errorf("no active PPPoE session found for username '%s'", username)
+	log.Printf("[OnDemand] Found active interface '%s' for username '%s'",
+		interfaceName, *customer.PPPoEUsername)
+	return interfaceName, nil
 }
 
-// extractPPPoEUsername extracts username from PPPoE interface name
-func extractPPPoEUsername(interfaceName string) string {
-	// Format: <pppoe-username>
-	if len(interfaceName) < 9 {
-		return ""
-	}
-
-	if strings.HasPrefix(interfaceName, "<pppoe-") && strings.HasSuffix(interfaceName, ">") {
-		return interfaceName[7 : len(interfaceName)-1]
-	}
-
-	return ""
-}
+// StopMonitoring releases the user's stream, decrements client count and stops monitoring if zero
+func (s *OnDemandTrafficService) StopMonitoring(customerID string, user string) {
+	s.mu.Lock()
+	s.releaseUserStream(user)
+	s.wakeWaiters()
+	s.mu.Unlock()
 
-// StopMonitoring decrements client count and stops monitoring if zero
-func (s *OnDemandTrafficService) StopMonitoring(customerID string) {
 	s.locksMu.Lock()
 	if _, ok := s.monitorLocks[customerID]; !ok {
 		s.locksMu.Unlock()
@@ -218,119 +258,369 @@ func (s *OnDemandTrafficService) StopMonitoring(customerID string) {
 		}
 
 		log.Printf("[OnDemand] Stopped monitoring for customer %s", customerID)
+		s.wakeWaiters()
 	}
 	s.mu.Unlock()
 }
 
-// runMonitorLoop runs the actual MikroTik monitoring command with auto-restart
-func (s *OnDemandTrafficService) runMonitorLoop(ctx context.Context, customer *domain.Customer, interfaceName string) {
-	maxRestarts := 3
-	restartDelay := 5 * time.Second
+// acquireLease tries to take the customer's monitor lease and returns the token
+// the lease is held under. Without a coordinator, or when Redis cannot be
+// asked, this replica monitors on its own.
+func (s *OnDemandTrafficService) acquireLease(customerID string) (bool, string) {
+	if s.coordinator == nil {
+		return true, ""
+	}
+
+	token := s.coordinator.InstanceID() + "/" + uuid.NewString()
+	held, err := s.coordinator.AcquireMonitor(customerID, token, s.leaseTTL)
+	if err != nil {
+		log.Printf("[OnDemand] Lease for customer %s unavailable, monitoring locally: %v", customerID, err)
+		return true, token
+	}
+	return held, token
+}
+
+// releaseLease drops the customer's lease if token still holds it
+func (s *OnDemandTrafficService) releaseLease(customerID, token string) {
+	if s.coordinator == nil {
+		return
+	}
+	if err := s.coordinator.ReleaseMonitor(customerID, token); err != nil {
+		log.Printf("[OnDemand] Warning: failed to release lease for customer %s: %v", customerID, err)
+	}
+}
+
+// runMonitor watches the router while this replica owns the customer and
+// relays the owner's events otherwise, switching whenever the lease changes hands
+func (s *OnDemandTrafficService) runMonitor(ctx context.Context, monitor *CustomerMonitor, customer *domain.Customer) {
+	if s.coordinator == nil {
+		s.runMonitorLoop(ctx, monitor, customer)
+		return
+	}
+
+	for ctx.Err() == nil {
+		if s.isOwner(monitor) {
+			s.runOwner(ctx, monitor, customer)
+		} else {
+			s.runFollower(ctx, monitor, customer)
+		}
+	}
+
+	// No local clients left: hand the customer to a replica that still has some
+	if s.isOwner(monitor) {
+		s.releaseLease(customer.ID, monitor.leaseToken)
+		s.publishEvent(domain.TrafficEvent{
+			Type:       domain.TrafficEventHandover,
+			CustomerID: customer.ID,
+			Timestamp:  time.Now(),
+		})
+	}
+}
+
+// runOwner runs the router monitor and renews the lease until ctx is done or
+// the lease is lost, e.g. because Redis expired it while this replica was cut off
+func (s *OnDemandTrafficService) runOwner(ctx context.Context, monitor *CustomerMonitor, customer *domain.Customer) {
+	ownCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.runMonitorLoop(ownCtx, monitor, customer)
+	}()
+
+	ticker := time.NewTicker(s.leaseTTL / 3)
+	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ctx.Done():
-			log.Printf("[OnDemand] Monitor context cancelled for %s", customer.Name)
+		case <-done:
 			return
-		default:
-			// Check restart count
-			s.mu.Lock()
-			monitor, exists := s.activeMonitors[customer.ID]
-			if !exists {
-				s.mu.Unlock()
-				return
+		case <-ticker.C:
+			held, err := s.coordinator.RenewMonitor(customer.ID, monitor.leaseToken, s.leaseTTL)
+			if err != nil {
+				// Keep monitoring; a replica that took the lease meanwhile makes the next renew fail
+				log.Printf("[OnDemand] Warning: failed to renew lease for %s: %v", customer.Name, err)
+				continue
 			}
-			
-			if monitor.restartCount >= maxRestarts {
-				log.Printf("[OnDemand] Max restart attempts (%d) reached for %s, stopping monitor", 
-					maxRestarts, customer.Name)
-				monitor.Cancel()
-				delete(s.activeMonitors, customer.ID)
-				
-				// Notify observers about disconnection
-				for ch := range monitor.Observers {
-					select {
-					case ch <- domain.CustomerTrafficData{
-						CustomerID:   customer.ID,
-						CustomerName: customer.Name,
-						Timestamp:    time.Now(),
-					}:
-					default:
-					}
-					close(ch)
-				}
+			if !held {
+				log.Printf("[OnDemand] Lost lease for %s, following the new owner", customer.Name)
+				s.mu.Lock()
+				monitor.Owner = false
 				s.mu.Unlock()
+				cancel()
+				<-done
 				return
 			}
-			s.mu.Unlock()
+		}
+	}
+}
 
-			// Start monitoring stream
-			trafficChan, err := mikrotik.MonitorTraffic(ctx, s.client, interfaceName)
-			if err != nil {
-				log.Printf("[OnDemand] Failed to start monitor for %s on %s: %v", 
-					customer.Name, interfaceName, err)
-				
-				s.mu.Lock()
-				if mon, ok := s.activeMonitors[customer.ID]; ok {
-					mon.restartCount++
+// runFollower relays the owner's events to local observers and takes the lease
+// when the owner hands it over or lets it expire
+func (s *OnDemandTrafficService) runFollower(ctx context.Context, monitor *CustomerMonitor, customer *domain.Customer) {
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := s.coordinator.SubscribeEvents(subCtx, customer.ID)
+
+	ticker := time.NewTicker(s.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if event.Type == domain.TrafficEventHandover {
+				if s.takeOver(monitor, customer) {
+					return
 				}
-				s.mu.Unlock()
-				
-				time.Sleep(restartDelay)
 				continue
 			}
+			s.notifyObservers(customer.ID, event)
+		case <-ticker.C:
+			if s.takeOver(monitor, customer) {
+				return
+			}
+		}
+	}
+}
 
-			log.Printf("[OnDemand] Monitor stream active for %s on %s", customer.Name, interfaceName)
+// takeOver acquires the lease of a followed customer
+func (s *OnDemandTrafficService) takeOver(monitor *CustomerMonitor, customer *domain.Customer) bool {
+	held, err := s.coordinator.AcquireMonitor(customer.ID, monitor.leaseToken, s.leaseTTL)
+	if err != nil {
+		log.Printf("[OnDemand] Warning: lease check for %s failed: %v", customer.Name, err)
+		return false
+	}
+	if !held {
+		return false
+	}
 
-			// Process traffic data
-			streamClosed := s.processTrafficStream(ctx, customer, trafficChan)
-			
-			if streamClosed {
-				log.Printf("[OnDemand] Stream closed for %s, attempting restart...", customer.Name)
-				
-				s.mu.Lock()
-				if mon, ok := s.activeMonitors[customer.ID]; ok {
-					mon.restartCount++
-				}
-				s.mu.Unlock()
-				
-				time.Sleep(restartDelay)
+	log.Printf("[OnDemand] Took over monitoring of %s", customer.Name)
+	s.mu.Lock()
+	monitor.Owner = true
+	monitor.InterfaceName = "" // resolved again by runMonitorLoop
+	s.mu.Unlock()
+	return true
+}
+
+func (s *OnDemandTrafficService) isOwner(monitor *CustomerMonitor) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return monitor.Owner
+}
+
+// Timing for following a customer's PPPoE session
+const (
+	sessionPollInterval = 5 * time.Second  // re-resolve interval while the session is down
+	streamIdleTimeout   = 10 * time.Second // no samples for this long = stream presumed dead
+)
+
+// runMonitorLoop streams the customer's interface and follows PPPoE reconnects.
+// When the stream ends, goes quiet or a pppoe-down callback arrives, the interface is
+// re-resolved; if the session is gone observers get session_down, and once it is back
+// (found by polling or signalled by a pppoe-up callback) they get session_up and the
+// stream resumes on the new interface.
+func (s *OnDemandTrafficService) runMonitorLoop(ctx context.Context, monitor *CustomerMonitor, customer *domain.Customer) {
+	s.mu.Lock()
+	interfaceName := monitor.InterfaceName
+	s.mu.Unlock()
+
+	if interfaceName == "" {
+		// Taken over from another replica; the session may be down right now
+		if interfaceName, _ = s.getActiveInterfaceForCustomer(customer); interfaceName == "" {
+			if interfaceName = s.waitForSession(ctx, customer, monitor.sessionUp); interfaceName == "" {
+				return // cancelled
+			}
+		}
+		s.mu.Lock()
+		monitor.InterfaceName = interfaceName
+		s.mu.Unlock()
+	}
+
+	for {
+		subCtx, subCancel := context.WithCancel(ctx)
+		trafficChan, err := s.mux.MonitorTraffic(subCtx, interfaceName)
+		if err != nil {
+			subCancel()
+			log.Printf("[OnDemand] Failed to start monitor for %s on %s: %v",
+				customer.Name, interfaceName, err)
+			s.endMonitor(customer.ID)
+			return
+		}
+
+		log.Printf("[OnDemand] Monitor stream active for %s on %s", customer.Name, interfaceName)
+		s.processTrafficStream(ctx, customer, trafficChan, monitor.sessionDown)
+		subCancel()
+
+		if ctx.Err() != nil {
+			log.Printf("[OnDemand] Monitor context cancelled for %s", customer.Name)
+			return
+		}
+
+		current, _ := s.getActiveInterfaceForCustomer(customer)
+		if current == interfaceName {
+			// Session is still up; the stream was only interrupted
+			if !sleepContext(ctx, sessionPollInterval) {
+				return
 			}
+			continue
+		}
+
+		log.Printf("[OnDemand] Session down for %s (was %s)", customer.Name, interfaceName)
+		s.broadcastEvent(customer.ID, domain.TrafficEvent{
+			Type:          domain.TrafficEventSessionDown,
+			CustomerID:    customer.ID,
+			InterfaceName: interfaceName,
+			Timestamp:     time.Now(),
+		})
+
+		if current == "" {
+			if current = s.waitForSession(ctx, customer, monitor.sessionUp); current == "" {
+				return // cancelled
+			}
+		}
+
+		s.mu.Lock()
+		monitor.InterfaceName = current
+		s.mu.Unlock()
+		interfaceName = current
+
+		// A pppoe-down signal for the old session must not end the new stream
+		select {
+		case <-monitor.sessionDown:
+		default:
+		}
+
+		log.Printf("[OnDemand] Session up for %s on %s", customer.Name, interfaceName)
+		s.broadcastEvent(customer.ID, domain.TrafficEvent{
+			Type:          domain.TrafficEventSessionUp,
+			CustomerID:    customer.ID,
+			InterfaceName: interfaceName,
+			Timestamp:     time.Now(),
+		})
+	}
+}
+
+// waitForSession polls for the customer's PPPoE interface until it appears or ctx is done.
+// A pppoe-up callback (sessionUp) triggers an immediate lookup.
+func (s *OnDemandTrafficService) waitForSession(ctx context.Context, customer *domain.Customer, sessionUp <-chan struct{}) string {
+	ticker := time.NewTicker(sessionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-sessionUp:
+		case <-ticker.C:
+		}
+
+		if iface, err := s.getActiveInterfaceForCustomer(customer); err == nil && iface != "" {
+			return iface
 		}
 	}
 }
 
-// processTrafficStream processes traffic data from the stream
+// NotifySessionUp signals a running monitor that the customer's PPPoE session came up
+func (s *OnDemandTrafficService) NotifySessionUp(customerID string) {
+	s.signalMonitor(customerID, func(m *CustomerMonitor) chan struct{} { return m.sessionUp })
+}
+
+// NotifySessionDown signals a running monitor that the customer's PPPoE session went down
+func (s *OnDemandTrafficService) NotifySessionDown(customerID string) {
+	s.signalMonitor(customerID, func(m *CustomerMonitor) chan struct{} { return m.sessionDown })
+}
+
+func (s *OnDemandTrafficService) signalMonitor(customerID string, pick func(*CustomerMonitor) chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	monitor, ok := s.activeMonitors[customerID]
+	if !ok {
+		return
+	}
+	select {
+	case pick(monitor) <- struct{}{}:
+	default: // a signal is already pending
+	}
+}
+
+// endMonitor removes a monitor that cannot continue and closes its observers
+func (s *OnDemandTrafficService) endMonitor(customerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	monitor, ok := s.activeMonitors[customerID]
+	if !ok {
+		return
+	}
+	monitor.Cancel()
+	delete(s.activeMonitors, customerID)
+	for ch := range monitor.Observers {
+		close(ch)
+	}
+	s.wakeWaiters()
+}
+
+// processTrafficStream forwards samples until the stream closes, goes idle,
+// a session-down signal arrives or ctx is cancelled
 func (s *OnDemandTrafficService) processTrafficStream(
-	ctx context.Context, 
-	customer *domain.Customer, 
+	ctx context.Context,
+	customer *domain.Customer,
 	trafficChan <-chan mikrotik.InterfaceTraffic,
-) bool {
+	sessionDown <-chan struct{},
+) {
+	idle := time.NewTimer(streamIdleTimeout)
+	defer idle.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
-			return false
+			return
+		case <-sessionDown:
+			log.Printf("[OnDemand] Session down signalled for %s", customer.Name)
+			return
+		case <-idle.C:
+			log.Printf("[OnDemand] No traffic samples for %s in %s", customer.Name, streamIdleTimeout)
+			return
 		case traffic, ok := <-trafficChan:
 			if !ok {
 				log.Printf("[OnDemand] Traffic channel closed for %s", customer.Name)
-				return true // Stream closed
+				return
 			}
-			
+
 			// Validate traffic data
 			if traffic.Name == "" {
-				log.Printf("[OnDemand] WARNING: Received traffic data with empty interface name for %s", 
+				log.Printf("[OnDemand] WARNING: Received traffic data with empty interface name for %s",
 					customer.Name)
 				continue
 			}
 
+			idle.Reset(streamIdleTimeout)
+
 			data := s.mapToCustomerTraffic(customer, traffic)
 			s.publishTrafficData(data)
 		}
 	}
 }
 
+// sleepContext waits for d and reports false if ctx was cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
 // addObserver creates a channel and adds it to the monitor's observers
-func (s *OnDemandTrafficService) addObserver(ctx context.Context, customerID string) (<-chan domain.CustomerTrafficData, error) {
+func (s *OnDemandTrafficService) addObserver(ctx context.Context, customerID string) (<-chan domain.TrafficEvent, error) {
 	s.mu.Lock()
 	monitor, exists := s.activeMonitors[customerID]
 	if !exists {
@@ -339,7 +629,7 @@ func (s *OnDemandTrafficService) addObserver(ctx context.Context, customerID str
 	}
 
 	// Create buffered channel to prevent blocking
-	ch := make(chan domain.CustomerTrafficData, 50)
+	ch := make(chan domain.TrafficEvent, 50)
 	monitor.Observers[ch] = true
 	s.mu.Unlock()
 
@@ -347,9 +637,11 @@ func (s *OnDemandTrafficService) addObserver(ctx context.Context, customerID str
 	go func() {
 		<-ctx.Done()
 		s.mu.Lock()
-		if m, ok := s.activeMonitors[customerID]; ok && m.Observers != nil {
-			delete(m.Observers, ch)
-			close(ch)
+		if m, ok := s.activeMonitors[customerID]; ok {
+			if _, ok := m.Observers[ch]; ok {
+				delete(m.Observers, ch)
+				close(ch)
+			}
 		}
 		s.mu.Unlock()
 	}()
@@ -359,47 +651,79 @@ func (s *OnDemandTrafficService) addObserver(ctx context.Context, customerID str
 
 func (s *OnDemandTrafficService) mapToCustomerTraffic(c *domain.Customer, t mikrotik.InterfaceTraffic) domain.CustomerTrafficData {
 	return domain.CustomerTrafficData{
-		CustomerID:         c.ID,
-		CustomerName:       c.Name,
-		Username:           c.Username,
-		ServiceType:        c.ServiceType,
-		InterfaceName:      t.Name,
+		CustomerID:    c.ID,
+		CustomerName:  c.Name,
+		Username:      c.Username,
+		ServiceType:   c.ServiceType,
+		InterfaceName: t.Name,
+
 		RxBitsPerSecond:    t.RxBitsPerSecond,
 		TxBitsPerSecond:    t.TxBitsPerSecond,
 		RxPacketsPerSecond: t.RxPacketsPerSecond,
 		TxPacketsPerSecond: t.TxPacketsPerSecond,
-		DownloadSpeed:      formatSpeed(t.RxBitsPerSecond),
-		UploadSpeed:        formatSpeed(t.TxBitsPerSecond),
-		Timestamp:          time.Now(),
+
+		FpRxBitsPerSecond:    t.FpRxBitsPerSecond,
+		FpTxBitsPerSecond:    t.FpTxBitsPerSecond,
+		FpRxPacketsPerSecond: t.FpRxPacketsPerSecond,
+		FpTxPacketsPerSecond: t.FpTxPacketsPerSecond,
+
+		RxDropsPerSecond:      t.RxDropsPerSecond,
+		TxDropsPerSecond:      t.TxDropsPerSecond,
+		TxQueueDropsPerSecond: t.TxQueueDropsPerSecond,
+		RxErrorsPerSecond:     t.RxErrorsPerSecond,
+		TxErrorsPerSecond:     t.TxErrorsPerSecond,
+
+		DownloadSpeed: domain.FormatBitRate(t.TxBitsPerSecond),
+		UploadSpeed:   domain.FormatBitRate(t.RxBitsPerSecond),
+		Timestamp:     time.Now(),
 	}
 }
 
 func (s *OnDemandTrafficService) publishTrafficData(data domain.CustomerTrafficData) {
-	// 1. Publish to Redis (optional, for history/other consumers)
-	jsonData, _ := json.Marshal(data)
+	// 1. Publish to Redis (optional, for history/other consumers).
+	// The stream keeps the v1 schema so existing consumers are unaffected.
+	jsonData, _ := json.Marshal(data.V1())
 	s.publisher.PublishStream("mikrotik:traffic:customers", string(jsonData))
 
-	// 2. Broadcast to in-memory observers (active websockets)
+	// 2. Broadcast to observers (active websockets here and on other replicas)
+	s.broadcastEvent(data.CustomerID, domain.TrafficEvent{
+		Type:          domain.TrafficEventUpdate,
+		CustomerID:    data.CustomerID,
+		InterfaceName: data.InterfaceName,
+		Traffic:       &data,
+		Timestamp:     data.Timestamp,
+	})
+}
+
+// broadcastEvent delivers an event of the monitor this replica owns to local
+// observers and to the customer's traffic topic, which other replicas and
+// /ws subscribers follow
+func (s *OnDemandTrafficService) broadcastEvent(customerID string, event domain.TrafficEvent) {
+	s.notifyObservers(customerID, event)
+	s.publishEvent(event)
+}
+
+// publishEvent appends an event to the customer's traffic topic
+func (s *OnDemandTrafficService) publishEvent(event domain.TrafficEvent) {
+	payload, _ := json.Marshal(event)
+	if err := s.publisher.PublishTopic(domain.CustomerTrafficTopic(event.CustomerID), string(payload)); err != nil {
+		log.Printf("[OnDemand] Warning: failed to publish event for customer %s: %v", event.CustomerID, err)
+	}
+}
+
+// notifyObservers delivers an event to every local observer of a customer's monitor
+func (s *OnDemandTrafficService) notifyObservers(customerID string, event domain.TrafficEvent) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if monitor, ok := s.activeMonitors[data.CustomerID]; ok {
-		for ch := range monitor.Observers {
-			select {
-			case ch <- data:
-			default:
-				// Skip if channel full to prevent blocking
-			}
+	monitor, ok := s.activeMonitors[customerID]
+	if !ok {
+		return
+	}
+	for ch := range monitor.Observers {
+		select {
+		case ch <- event:
+		default:
 		}
 	}
 }
-
-// formatSpeed converts bits per second to human-readable format
-func formatSpeed(bps string) string {
-	if bps == "" || bps == "0" {
-		return "0 bps"
-	}
-	
-	// Simple implementation - use the one from continuous service if available
-	return bps + " bps"
-}
\ No newline at end of file
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+)
+
+// PlanService manages the plan catalog and keeps /ppp/profile in sync on every router
+type PlanService struct {
+	repo    domain.PlanRepository
+	routers []*mikrotik.Client
+}
+
+// NewPlanService creates a new plan service
+func NewPlanService(repo domain.PlanRepository, routers ...*mikrotik.Client) *PlanService {
+	return &PlanService{
+		repo:    repo,
+		routers: routers,
+	}
+}
+
+// CreatePlan stores a plan and creates its /ppp/profile on each router.
+// Router failures do not roll back the plan; they are recorded per router and can be retried with SyncPlan.
+func (s *PlanService) CreatePlan(p *domain.Plan) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.repo.CreatePlan(p); err != nil {
+		return err
+	}
+
+	p.RouterProfiles = s.syncPlan(p)
+	return nil
+}
+
+// UpdatePlan updates a plan and propagates the new rate-limit to every router.
+// Active sessions keep their old limits until they reconnect.
+func (s *PlanService) UpdatePlan(p *domain.Plan) error {
+	oldP, err := s.repo.GetPlanByID(p.ID)
+	if err != nil {
+		return err
+	}
+
+	if p.ProfileName != oldP.ProfileName {
+		return fmt.Errorf("%w: profile_name cannot be changed once created", domain.ErrInvalidPlan)
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	p.CreatedAt = oldP.CreatedAt
+	if err := s.repo.UpdatePlan(p); err != nil {
+		return err
+	}
+
+	p.RouterProfiles = s.syncPlan(p)
+	return nil
+}
+
+// DeletePlan removes a plan and its /ppp/profile from the routers
+func (s *PlanService) DeletePlan(id string) error {
+	p, err := s.repo.GetPlanByID(id)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountCustomersByPlan(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w (%d customers)", domain.ErrPlanInUse, count)
+	}
+
+	for _, router := range s.routers {
+		mtID, err := router.FindPPPProfileID(p.ProfileName)
+		if err != nil || mtID == "" {
+			continue
+		}
+		if err := router.DeletePPPProfile(mtID); err != nil {
+			log.Printf("[Plan] Warning: failed to delete profile %s on router %s: %v",
+				p.ProfileName, router.Config.RouterID, err)
+		}
+	}
+
+	return s.repo.DeletePlan(id)
+}
+
+// GetPlan returns a plan with its per-router sync status
+func (s *PlanService) GetPlan(id string) (*domain.Plan, error) {
+	p, err := s.repo.GetPlanByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := s.repo.ListRouterProfiles(id)
+	if err != nil {
+		return nil, err
+	}
+	p.RouterProfiles = profiles
+
+	return p, nil
+}
+
+// ListPlans returns all plans
+func (s *PlanService) ListPlans() ([]*domain.Plan, error) {
+	return s.repo.ListPlans()
+}
+
+// SyncPlan re-applies a plan's profile on every router
+func (s *PlanService) SyncPlan(id string) (*domain.Plan, error) {
+	p, err := s.repo.GetPlanByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	p.RouterProfiles = s.syncPlan(p)
+	return p, nil
+}
+
+// syncPlan creates or updates the plan's /ppp/profile on each router and records the result
+func (s *PlanService) syncPlan(p *domain.Plan) []domain.PlanRouterProfile {
+	profile := mikrotik.PPPProfile{
+		Name:      p.ProfileName,
+		RateLimit: p.RateLimit(),
+		Comment:   "plan: " + p.Name,
+	}
+	if p.LocalAddress != nil {
+		profile.LocalAddress = *p.LocalAddress
+	}
+	if p.RemoteAddress != nil {
+		profile.RemoteAddress = *p.RemoteAddress
+	}
+
+	results := make([]domain.PlanRouterProfile, 0, len(s.routers))
+
+	for _, router := range s.routers {
+		result := domain.PlanRouterProfile{
+			PlanID:   p.ID,
+			RouterID: router.Config.RouterID,
+		}
+
+		mtID, err := s.upsertProfile(router, profile)
+		if err != nil {
+			log.Printf("[Plan] Failed to sync profile %s on router %s: %v",
+				p.ProfileName, router.Config.RouterID, err)
+			msg := err.Error()
+			result.LastError = &msg
+		} else {
+			now := time.Now()
+			result.MikrotikID = mtID
+			result.SyncedAt = &now
+			log.Printf("[Plan] Synced profile %s (%s) on router %s",
+				p.ProfileName, profile.RateLimit, router.Config.RouterID)
+		}
+
+		if err := s.repo.SaveRouterProfile(&result); err != nil {
+			log.Printf("[Plan] Warning: failed to record sync status: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// upsertProfile updates the profile if it exists on the router, otherwise creates it.
+// Every property the plan manages is written, so values cleared on the plan are unset.
+func (s *PlanService) upsertProfile(router *mikrotik.Client, profile mikrotik.PPPProfile) (string, error) {
+	mtID, err := router.FindPPPProfileID(profile.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up profile: %w", err)
+	}
+
+	if mtID == "" {
+		return router.CreatePPPProfile(profile)
+	}
+
+	changes := map[string]string{
+		"local-address":  profile.LocalAddress,
+		"remote-address": profile.RemoteAddress,
+		"rate-limit":     profile.RateLimit,
+		"comment":        profile.Comment,
+	}
+	if err := router.PatchPPPProfile(mtID, changes); err != nil {
+		return "", err
+	}
+	return mtID, nil
+}
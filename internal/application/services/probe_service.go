@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+)
+
+const probePruneInterval = time.Hour
+
+// ProbeSettings configures background reachability probing
+type ProbeSettings struct {
+	Interval     time.Duration // time between probe rounds
+	Count        int           // pings per probe
+	Concurrency  int           // probes running at once on the router
+	DegradedLoss float64       // packet loss percent from which a customer is degraded
+	DegradedRtt  time.Duration // average RTT from which a customer is degraded
+	Retention    time.Duration // probe history kept
+}
+
+// ProbeService periodically pings the address of every online customer from the
+// router, stores latency/loss history and tracks each customer's reachability.
+type ProbeService struct {
+	client    *mikrotik.Client
+	customers domain.CustomerRepository
+	repo      domain.ProbeRepository
+	publisher domain.RedisPublisher
+	alerts    *AlertService
+	settings  ProbeSettings
+
+	mu    sync.Mutex
+	state map[string]*domain.CustomerReachability // customer ID -> latest state
+}
+
+// NewProbeService creates a new probe service
+func NewProbeService(
+	client *mikrotik.Client,
+	customers domain.CustomerRepository,
+	repo domain.ProbeRepository,
+	publisher domain.RedisPublisher,
+	alerts *AlertService,
+	settings ProbeSettings,
+) *ProbeService {
+	if settings.Count <= 0 {
+		settings.Count = 5
+	}
+	if settings.Concurrency <= 0 {
+		settings.Concurrency = 1
+	}
+	return &ProbeService{
+		client:    client,
+		customers: customers,
+		repo:      repo,
+		publisher: publisher,
+		alerts:    alerts,
+		settings:  settings,
+		state:     make(map[string]*domain.CustomerReachability),
+	}
+}
+
+// Run probes all online customers every interval until ctx is cancelled
+func (s *ProbeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.settings.Interval)
+	defer ticker.Stop()
+
+	lastPrune := time.Now()
+
+	for {
+		s.probeAll(ctx)
+
+		if time.Since(lastPrune) >= probePruneInterval {
+			lastPrune = time.Now()
+			n, err := s.repo.PruneProbes(time.Now().Add(-s.settings.Retention))
+			if err != nil {
+				log.Printf("[Probe] %v", err)
+			} else if n > 0 {
+				log.Printf("[Probe] Pruned %d probe rows", n)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeAll runs one round over every online customer, at most Concurrency at a time
+func (s *ProbeService) probeAll(ctx context.Context) {
+	customers, err := s.customers.ListOnlineCustomers()
+	if err != nil {
+		log.Printf("[Probe] Failed to list online customers: %v", err)
+		return
+	}
+
+	online := make(map[string]bool, len(customers))
+	sem := make(chan struct{}, s.settings.Concurrency)
+	var wg sync.WaitGroup
+
+	for _, c := range customers {
+		ip := probeAddress(c)
+		if ip == "" {
+			continue
+		}
+		online[c.ID] = true
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(c *domain.Customer, ip string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.probe(c, ip)
+		}(c, ip)
+	}
+	wg.Wait()
+
+	// Customers that went offline are no longer tracked
+	s.mu.Lock()
+	for id := range s.state {
+		if !online[id] {
+			delete(s.state, id)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// probe pings one customer and records the result
+func (s *ProbeService) probe(c *domain.Customer, ip string) {
+	stats, err := s.client.Ping(mikrotik.PingOptions{Address: ip, Count: s.settings.Count})
+	if err != nil {
+		log.Printf("[Probe] %s (%s): %v", c.Name, ip, err)
+		return
+	}
+
+	now := time.Now()
+	result := domain.CustomerProbe{
+		CustomerID: c.ID,
+		IPAddress:  ip,
+		Sent:       stats.Sent,
+		Received:   stats.Received,
+		PacketLoss: stats.PacketLoss,
+		MinRttMs:   durationMs(stats.MinRtt),
+		AvgRttMs:   durationMs(stats.AvgRtt),
+		MaxRttMs:   durationMs(stats.MaxRtt),
+		Status:     s.classify(stats),
+		ProbedAt:   now,
+	}
+	if err := s.repo.SaveProbe(&result); err != nil {
+		log.Printf("[Probe] Warning: %v", err)
+	}
+
+	s.alerts.Observe(domain.MetricPacketLoss, c.ID, c.Name, result.PacketLoss)
+	if stats.Reachable() {
+		s.alerts.Observe(domain.MetricLatency, c.ID, c.Name, result.AvgRttMs)
+	}
+
+	s.mu.Lock()
+	prev, known := s.state[c.ID]
+	current := &domain.CustomerReachability{
+		CustomerID:   c.ID,
+		CustomerName: c.Name,
+		Status:       result.Status,
+		Since:        now,
+		LastProbe:    result,
+	}
+	if known && prev.Status == result.Status {
+		current.Since = prev.Since
+	}
+	s.state[c.ID] = current
+	s.mu.Unlock()
+
+	if !known || prev.Status != result.Status {
+		previous := ""
+		if known {
+			previous = prev.Status
+		}
+		s.publishChange(current, previous)
+	}
+}
+
+// classify maps a ping result to a reachability state
+func (s *ProbeService) classify(stats *mikrotik.PingStats) string {
+	switch {
+	case !stats.Reachable():
+		return domain.ReachabilityUnreachable
+	case s.settings.DegradedLoss > 0 && stats.PacketLoss >= s.settings.DegradedLoss,
+		s.settings.DegradedRtt > 0 && stats.AvgRtt >= s.settings.DegradedRtt:
+		return domain.ReachabilityDegraded
+	default:
+		return domain.ReachabilityReachable
+	}
+}
+
+// publishChange broadcasts a reachability change on the global events channel and the router topic
+func (s *ProbeService) publishChange(r *domain.CustomerReachability, previous string) {
+	if previous != "" {
+		log.Printf("[Probe] %s is now %s (was %s, loss %.0f%%, avg %.1fms)",
+			r.CustomerName, r.Status, previous, r.LastProbe.PacketLoss, r.LastProbe.AvgRttMs)
+	}
+
+	if s.publisher == nil {
+		return
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":        "reachability",
+		"customer_id": r.CustomerID,
+		"name":        r.CustomerName,
+		"ip":          r.LastProbe.IPAddress,
+		"status":      r.Status,
+		"previous":    previous,
+		"packet_loss": r.LastProbe.PacketLoss,
+		"avg_rtt_ms":  r.LastProbe.AvgRttMs,
+	})
+	if err := s.publisher.PublishEvent(string(payload), domain.RouterEventsTopic(s.client.Config.RouterID)); err != nil {
+		log.Printf("[Probe] Warning: failed to publish reachability change: %v", err)
+	}
+}
+
+// ListStatus returns the latest state of every probed customer, optionally only
+// those with the given status, worst first
+func (s *ProbeService) ListStatus(status string) []domain.CustomerReachability {
+	s.mu.Lock()
+	list := make([]domain.CustomerReachability, 0, len(s.state))
+	for _, r := range s.state {
+		if status == "" || r.Status == status {
+			list = append(list, *r)
+		}
+	}
+	s.mu.Unlock()
+
+	rank := map[string]int{
+		domain.ReachabilityUnreachable: 0,
+		domain.ReachabilityDegraded:    1,
+		domain.ReachabilityReachable:   2,
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if rank[list[i].Status] != rank[list[j].Status] {
+			return rank[list[i].Status] < rank[list[j].Status]
+		}
+		return list[i].CustomerName < list[j].CustomerName
+	})
+	return list
+}
+
+// Summary returns the number of probed customers per status
+func (s *ProbeService) Summary() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := map[string]int{
+		domain.ReachabilityReachable:   0,
+		domain.ReachabilityDegraded:    0,
+		domain.ReachabilityUnreachable: 0,
+	}
+	for _, r := range s.state {
+		summary[r.Status]++
+	}
+	return summary
+}
+
+// GetStatus returns the latest state of a customer, or nil if it is not probed
+func (s *ProbeService) GetStatus(customerID string) *domain.CustomerReachability {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.state[customerID]; ok {
+		state := *r
+		return &state
+	}
+	return nil
+}
+
+// History returns the probes of a customer between from and to
+func (s *ProbeService) History(customerID string, from, to time.Time) ([]*domain.CustomerProbe, error) {
+	return s.repo.ListProbes(customerID, from, to)
+}
+
+// probeAddress returns the address to probe: the session address, else the static IP
+func probeAddress(c *domain.Customer) string {
+	if c.AssignedIP != nil && *c.AssignedIP != "" {
+		return *c.AssignedIP
+	}
+	if c.StaticIP != nil && *c.StaticIP != "" {
+		return *c.StaticIP
+	}
+	return ""
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+)
+
+const routerHealthPruneInterval = time.Hour
+
+// RouterHealthService polls the router's resources, sensors, PPP session count
+// and interface error counters, keeps history and feeds the alert rules.
+type RouterHealthService struct {
+	client    *mikrotik.Client
+	repo      domain.RouterHealthRepository
+	publisher domain.RedisPublisher
+	alerts    *AlertService
+	interval  time.Duration
+	retain    time.Duration
+
+	mu        sync.Mutex
+	latest    *domain.RouterHealth
+	counters  map[string]mikrotik.InterfaceCounters // previous poll, for error deltas
+	observers map[chan domain.RouterHealth]struct{}
+}
+
+// NewRouterHealthService creates a new router health service
+func NewRouterHealthService(
+	client *mikrotik.Client,
+	repo domain.RouterHealthRepository,
+	publisher domain.RedisPublisher,
+	alerts *AlertService,
+	interval time.Duration,
+	retain time.Duration,
+) *RouterHealthService {
+	return &RouterHealthService{
+		client:    client,
+		repo:      repo,
+		publisher: publisher,
+		alerts:    alerts,
+		interval:  interval,
+		retain:    retain,
+		observers: make(map[chan domain.RouterHealth]struct{}),
+	}
+}
+
+// Run polls every interval until ctx is cancelled
+func (s *RouterHealthService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	lastPrune := time.Now()
+
+	for {
+		s.Poll()
+
+		if time.Since(lastPrune) >= routerHealthPruneInterval {
+			lastPrune = time.Now()
+			n, err := s.repo.PruneHealthSamples(time.Now().Add(-s.retain))
+			if err != nil {
+				log.Printf("[RouterHealth] %v", err)
+			} else if n > 0 {
+				log.Printf("[RouterHealth] Pruned %d history rows", n)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll takes one health sample, stores it and notifies subscribers
+func (s *RouterHealthService) Poll() {
+	health := s.collect()
+
+	if err := s.repo.SaveHealthSample(&health.RouterHealthSample); err != nil {
+		log.Printf("[RouterHealth] Warning: %v", err)
+	}
+
+	if health.Reachable {
+		s.observe(health)
+	}
+
+	s.mu.Lock()
+	s.latest = health
+	for ch := range s.observers {
+		select {
+		case ch <- *health:
+		default:
+			// Skip slow observer
+		}
+	}
+	s.mu.Unlock()
+
+	if s.publisher != nil {
+		payload, _ := json.Marshal(health)
+		if err := s.publisher.Publish("mikrotik:router:health", string(payload)); err != nil {
+			log.Printf("[RouterHealth] Warning: failed to publish health: %v", err)
+		}
+	}
+}
+
+// collect queries the router. Only /system/resource is required; the other
+// sources are best effort because not every board or RouterOS version has them.
+func (s *RouterHealthService) collect() *domain.RouterHealth {
+	now := time.Now()
+	health := &domain.RouterHealth{
+		RouterHealthSample: domain.RouterHealthSample{
+			RouterID:  s.client.Config.RouterID,
+			SampledAt: now,
+		},
+		Sensors:    map[string]string{},
+		Interfaces: []domain.InterfaceErrorCount{},
+	}
+
+	res, err := s.client.GetSystemResource()
+	if err != nil {
+		log.Printf("[RouterHealth] %v", err)
+		health.LastError = err.Error()
+		return health
+	}
+
+	health.Reachable = true
+	health.CPULoad = res.CPULoad
+	health.FreeMemory = int64(res.FreeMemory)
+	health.TotalMemory = int64(res.TotalMemory)
+	if res.TotalMemory > 0 {
+		health.MemoryUsedPercent = float64(res.TotalMemory-res.FreeMemory) * 100 / float64(res.TotalMemory)
+	}
+	if uptime, err := mikrotik.ParseDuration(res.Uptime); err == nil {
+		health.UptimeSeconds = int64(uptime / time.Second)
+	}
+	health.Version = res.Version
+	health.Uptime = res.Uptime
+	health.BoardName = res.BoardName
+	health.Architecture = res.Architecture
+	health.CPUCount = res.CPUCount
+
+	if sensors, err := s.client.GetSystemHealth(); err == nil {
+		health.Temperature = sensors.Temperature
+		health.Voltage = sensors.Voltage
+		health.Sensors = sensors.Sensors
+	} else {
+		health.LastError = err.Error()
+	}
+
+	if n, err := s.client.CountPPPActive(); err == nil {
+		health.PPPActive = n
+	} else {
+		health.LastError = err.Error()
+	}
+
+	if counters, err := s.client.ListInterfaceCounters(); err == nil {
+		health.InterfaceErrors = s.errorDelta(counters)
+		for _, c := range counters {
+			if c.RxErrors+c.TxErrors+c.RxDrops+c.TxDrops == 0 {
+				continue
+			}
+			health.Interfaces = append(health.Interfaces, domain.InterfaceErrorCount{
+				Name:     c.Name,
+				RxErrors: c.RxErrors,
+				TxErrors: c.TxErrors,
+				RxDrops:  c.RxDrops,
+				TxDrops:  c.TxDrops,
+			})
+		}
+	} else {
+		health.LastError = err.Error()
+	}
+
+	return health
+}
+
+// errorDelta returns the errors and drops counted since the previous poll. The
+// first poll and interfaces that appeared since count as 0; a counter that
+// went backwards (reboot, reset) counts from zero.
+func (s *RouterHealthService) errorDelta(counters []mikrotik.InterfaceCounters) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	first := s.counters == nil
+	current := make(map[string]mikrotik.InterfaceCounters, len(counters))
+	var delta int64
+
+	for _, c := range counters {
+		current[c.Name] = c
+		prev, ok := s.counters[c.Name]
+		if first || !ok {
+			continue
+		}
+		total := c.RxErrors + c.TxErrors + c.RxDrops + c.TxDrops
+		prevTotal := prev.RxErrors + prev.TxErrors + prev.RxDrops + prev.TxDrops
+		if total >= prevTotal {
+			delta += int64(total - prevTotal)
+		} else {
+			delta += int64(total)
+		}
+	}
+
+	s.counters = current
+	return delta
+}
+
+// observe feeds the sample to the alert rules, subject = router ID
+func (s *RouterHealthService) observe(h *domain.RouterHealth) {
+	id := h.RouterID
+	s.alerts.Observe(domain.MetricRouterCPU, id, "", float64(h.CPULoad))
+	s.alerts.Observe(domain.MetricRouterMemory, id, "", h.MemoryUsedPercent)
+	s.alerts.Observe(domain.MetricRouterPPPSessions, id, "", float64(h.PPPActive))
+	s.alerts.Observe(domain.MetricRouterInterfaceErrors, id, "", float64(h.InterfaceErrors))
+	if h.Temperature != nil {
+		s.alerts.Observe(domain.MetricRouterTemperature, id, "", *h.Temperature)
+	}
+	if h.Voltage != nil {
+		s.alerts.Observe(domain.MetricRouterVoltage, id, "", *h.Voltage)
+	}
+}
+
+// Latest returns the most recent health state of a router, nil before the first poll
+func (s *RouterHealthService) Latest(routerID string) (*domain.RouterHealth, error) {
+	if err := s.checkRouter(routerID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest, nil
+}
+
+// History returns the health samples of a router between from and to
+func (s *RouterHealthService) History(routerID string, from, to time.Time) ([]*domain.RouterHealthSample, error) {
+	if err := s.checkRouter(routerID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListHealthSamples(routerID, from, to)
+}
+
+// Subscribe returns a channel receiving every new health state until ctx is done
+func (s *RouterHealthService) Subscribe(ctx context.Context, routerID string) (<-chan domain.RouterHealth, error) {
+	if err := s.checkRouter(routerID); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan domain.RouterHealth, 4)
+
+	s.mu.Lock()
+	s.observers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.observers, ch)
+		close(ch)
+		s.mu.Unlock()
+	}()
+
+	return ch, nil
+}
+
+// checkRouter rejects IDs of routers this collector does not poll
+func (s *RouterHealthService) checkRouter(routerID string) error {
+	if routerID != s.client.Config.RouterID {
+		return fmt.Errorf("%w: %s", domain.ErrRouterNotFound, routerID)
+	}
+	return nil
+}
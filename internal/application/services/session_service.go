@@ -0,0 +1,240 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+)
+
+// SessionService acts on customers' live sessions on the router
+type SessionService struct {
+	client    *mikrotik.Client
+	customers domain.CustomerRepository
+	audit     domain.AuditRepository
+	publisher domain.RedisPublisher
+}
+
+// CustomerSession is a customer's live router session next to what the database holds
+type CustomerSession struct {
+	CustomerID   string                 `json:"customer_id"`
+	CustomerName string                 `json:"customer_name"`
+	Username     string                 `json:"username"`
+	Online       bool                   `json:"online"`
+	Session      *mikrotik.PPPoESession `json:"session"` // nil when not connected
+	StoredStatus string                 `json:"stored_status"`
+	StoredIP     *string                `json:"stored_ip"`  // assigned_ip column before any correction
+	StoredMAC    *string                `json:"stored_mac"` // mac_address column before any correction
+	InSync       bool                   `json:"in_sync"`    // stored IP/MAC matched the live session
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(
+	client *mikrotik.Client,
+	customers domain.CustomerRepository,
+	audit domain.AuditRepository,
+	publisher domain.RedisPublisher,
+) *SessionService {
+	return &SessionService{
+		client:    client,
+		customers: customers,
+		audit:     audit,
+		publisher: publisher,
+	}
+}
+
+// DisconnectCustomer removes the customer's PPPoE session from /ppp/active so the
+// CPE has to reconnect (e.g. to pick up a new profile). actor is recorded in the
+// audit log. Returns the number of sessions removed.
+func (s *SessionService) DisconnectCustomer(customerID, actor, reason string) (int, error) {
+	customer, err := s.customers.GetCustomerByID(customerID)
+	if err != nil {
+		return 0, err
+	}
+	if customer.ServiceType != "pppoe" || customer.PPPoEUsername == nil || *customer.PPPoEUsername == "" {
+		return 0, fmt.Errorf("%w: only PPPoE sessions can be disconnected", domain.ErrInvalidCustomer)
+	}
+	username := *customer.PPPoEUsername
+
+	removed, err := s.client.DisconnectPPPoESession(username)
+	if err != nil {
+		return removed, err
+	}
+	if removed == 0 {
+		return 0, fmt.Errorf("%w: no PPPoE session for '%s'", domain.ErrNoActiveSession, username)
+	}
+
+	log.Printf("[Session] %s disconnected %s (%s), %d session(s)", actor, customer.Name, username, removed)
+
+	entry := &domain.AuditLog{
+		Action:       domain.AuditActionCustomerDisconnect,
+		ResourceType: "customer",
+		ResourceID:   customer.ID,
+		Actor:        actor,
+		Details: map[string]interface{}{
+			"username": username,
+			"sessions": removed,
+			"reason":   reason,
+		},
+	}
+	if err := s.audit.CreateAuditLog(entry); err != nil {
+		// The session is already gone; a missing audit row must not report a failed kick
+		log.Printf("[Session] Warning: %v", err)
+	}
+
+	s.publishKicked(customer, actor, reason)
+	return removed, nil
+}
+
+// publishKicked broadcasts the kick on the global events channel and the router topic
+func (s *SessionService) publishKicked(customer *domain.Customer, actor, reason string) {
+	if s.publisher == nil {
+		return
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":        "pppoe_event",
+		"status":      "kicked",
+		"customer_id": customer.ID,
+		"name":        customer.Name,
+		"by":          actor,
+		"reason":      reason,
+	})
+	if err := s.publisher.PublishEvent(string(payload), domain.RouterEventsTopic(s.client.Config.RouterID)); err != nil {
+		log.Printf("[Session] Warning: failed to publish kick: %v", err)
+	}
+}
+
+// GetSession returns the live PPPoE session of a customer from /ppp/active,
+// its interface and queue, next to the stored values. Nothing is written;
+// SyncSession corrects stale columns.
+func (s *SessionService) GetSession(customerID string) (*CustomerSession, error) {
+	customer, live, err := s.lookupSession(customerID)
+	if err != nil {
+		return nil, err
+	}
+	return newCustomerSession(customer, live), nil
+}
+
+// SyncSession corrects the stored status, address and caller-id of a customer
+// from the router, as the pppoe-up/down callbacks would have. The returned
+// stored values and InSync describe the database before the correction.
+func (s *SessionService) SyncSession(customerID string) (*CustomerSession, error) {
+	customer, live, err := s.lookupSession(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newCustomerSession(customer, live)
+	if !result.InSync {
+		if err := s.reconcile(customer, live); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// lookupSession loads a PPPoE customer and its live session, nil when offline
+func (s *SessionService) lookupSession(customerID string) (*domain.Customer, *mikrotik.PPPoESession, error) {
+	customer, err := s.customers.GetCustomerByID(customerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if customer.ServiceType != "pppoe" || customer.PPPoEUsername == nil || *customer.PPPoEUsername == "" {
+		return nil, nil, fmt.Errorf("%w: live sessions are only available for PPPoE customers", domain.ErrInvalidCustomer)
+	}
+
+	live, err := s.client.GetPPPoESession(*customer.PPPoEUsername)
+	if err != nil {
+		return nil, nil, err
+	}
+	return customer, live, nil
+}
+
+func newCustomerSession(customer *domain.Customer, live *mikrotik.PPPoESession) *CustomerSession {
+	return &CustomerSession{
+		CustomerID:   customer.ID,
+		CustomerName: customer.Name,
+		Username:     *customer.PPPoEUsername,
+		Online:       live != nil,
+		Session:      live,
+		StoredStatus: customer.Status,
+		StoredIP:     customer.AssignedIP,
+		StoredMAC:    customer.MacAddress,
+		InSync:       sessionInSync(customer, live),
+	}
+}
+
+// LiveAddress returns the address of the customer's current PPPoE session. It
+// returns "" for non-PPPoE customers, when the customer is offline or when the
+// router cannot be asked, so callers fall back to the stored address.
+func (s *SessionService) LiveAddress(customer *domain.Customer) string {
+	if s == nil || customer.ServiceType != "pppoe" || customer.PPPoEUsername == nil || *customer.PPPoEUsername == "" {
+		return ""
+	}
+
+	live, err := s.client.GetPPPActive(*customer.PPPoEUsername)
+	if err != nil {
+		log.Printf("[Session] Warning: live lookup for %s failed: %v", customer.Name, err)
+		return ""
+	}
+	if live == nil {
+		return ""
+	}
+	return live.Address
+}
+
+// sessionInSync reports whether the stored status, address and caller-id agree
+// with the router. An offline customer must not look active or keep an address.
+func sessionInSync(customer *domain.Customer, live *mikrotik.PPPoESession) bool {
+	if live == nil {
+		return customer.Status != "active" && domain.DerefOrEmpty(customer.AssignedIP) == ""
+	}
+	if live.Address == "" {
+		return true // still negotiating, nothing to compare yet
+	}
+	return customer.Status != "inactive" &&
+		domain.DerefOrEmpty(customer.AssignedIP) == live.Address &&
+		domain.DerefOrEmpty(customer.MacAddress) == live.CallerID
+}
+
+// reconcile writes the router's view over stale database values: a connected
+// customer gets the live address and caller-id, an offline one loses its address.
+func (s *SessionService) reconcile(customer *domain.Customer, live *mikrotik.PPPoESession) error {
+	status := customer.Status
+	address := ""
+	var mac *string
+
+	if live != nil {
+		// Connected customers the callback missed become active; suspended ones keep their status
+		if status == "inactive" {
+			status = "active"
+		}
+		address = live.Address
+		mac = &live.CallerID
+	} else if status == "active" {
+		status = "inactive"
+	}
+
+	log.Printf("[Session] %s: stored %s %q/%q is stale, router reports %s",
+		customer.Name, customer.Status, domain.DerefOrEmpty(customer.AssignedIP), domain.DerefOrEmpty(customer.MacAddress), describeSession(live))
+
+	if err := s.customers.UpdateCustomerStatus(customer.ID, status, &address, mac); err != nil {
+		return err
+	}
+	customer.Status = status
+	customer.AssignedIP = &address
+	if mac != nil {
+		customer.MacAddress = mac
+	}
+	return nil
+}
+
+// describeSession formats a live session for logs
+func describeSession(live *mikrotik.PPPoESession) string {
+	if live == nil {
+		return "no session"
+	}
+	return fmt.Sprintf("%q/%q", live.Address, live.CallerID)
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+)
+
+// customerCacheTTL is how long the PPPoE username -> customer map is reused
+const customerCacheTTL = time.Minute
+
+// Sort keys for TopTalkers
+const (
+	TopByRx    = "rx"
+	TopByTx    = "tx"
+	TopByTotal = "total"
+)
+
+// TrafficAggregateService samples every PPPoE session at a low rate and keeps a
+// router-wide overview: totals, uplinks, per-profile sums and top talkers.
+type TrafficAggregateService struct {
+	client    *mikrotik.Client
+	repo      domain.CustomerRepository
+	publisher domain.RedisPublisher
+	uplinks   []string
+	uplinkSrc func() []string // extra uplinks, e.g. monitored WAN interfaces
+	interval  time.Duration
+	topN      int
+
+	mu        sync.Mutex
+	latest    *domain.TrafficSnapshot
+	talkers   []domain.TalkerRate // every session of the latest sample
+	observers map[chan domain.TrafficSnapshot]struct{}
+
+	customers       map[string]*domain.Customer // lower-case PPPoE username -> customer
+	customersLoaded time.Time
+}
+
+// NewTrafficAggregateService creates a new aggregate traffic service
+func NewTrafficAggregateService(
+	client *mikrotik.Client,
+	repo domain.CustomerRepository,
+	publisher domain.RedisPublisher,
+	uplinks []string,
+	interval time.Duration,
+	topN int,
+) *TrafficAggregateService {
+	return &TrafficAggregateService{
+		client:    client,
+		repo:      repo,
+		publisher: publisher,
+		uplinks:   uplinks,
+		interval:  interval,
+		topN:      topN,
+		observers: make(map[chan domain.TrafficSnapshot]struct{}),
+	}
+}
+
+// SetUplinkSource adds the names returned by src to the configured uplinks on every sample
+func (s *TrafficAggregateService) SetUplinkSource(src func() []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uplinkSrc = src
+}
+
+// Run samples every interval until ctx is cancelled
+func (s *TrafficAggregateService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sample(); err != nil {
+			log.Printf("[TrafficAggregate] Sample failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sample takes one sample of all PPPoE sessions and uplinks and publishes the snapshot
+func (s *TrafficAggregateService) Sample() error {
+	sessions, err := s.client.ListRunningInterfaces("pppoe-in")
+	if err != nil {
+		return err
+	}
+
+	uplinks := s.uplinkNames()
+	ifaces := append(append([]string(nil), sessions...), uplinks...)
+	samples, err := s.client.SampleTraffic(ifaces)
+	if err != nil {
+		// A session ending between listing and sampling makes RouterOS reject
+		// its whole chunk; drop the interfaces that are gone and sample again
+		running, listErr := s.client.ListRunningInterfaces("")
+		if listErr != nil {
+			return err
+		}
+		ifaces = keepRunning(ifaces, running)
+		if samples, err = s.client.SampleTraffic(ifaces); err != nil {
+			return err
+		}
+	}
+
+	customers := s.customerIndex()
+
+	isUplink := make(map[string]bool, len(uplinks))
+	for _, name := range uplinks {
+		isUplink[name] = true
+	}
+
+	snapshot := domain.TrafficSnapshot{
+		RouterID:  s.client.Config.RouterID,
+		Uplinks:   []domain.InterfaceRate{},
+		Timestamp: time.Now(),
+	}
+	talkers := make([]domain.TalkerRate, 0, len(sessions))
+	profiles := make(map[string]*domain.ProfileRate)
+	var totalRx, totalTx uint64
+
+	for _, t := range samples {
+		if isUplink[t.Name] {
+			snapshot.Uplinks = append(snapshot.Uplinks, domain.InterfaceRate{
+				InterfaceName: t.Name,
+				TrafficRate:   domain.NewTrafficRate(t.RxBitsPerSecond, t.TxBitsPerSecond),
+			})
+			continue
+		}
+
+		talker := domain.TalkerRate{
+			InterfaceName: t.Name,
+			Username:      mikrotik.PPPoEUsernameFromInterface(t.Name),
+			TrafficRate:   domain.NewSessionTrafficRate(t.RxBitsPerSecond, t.TxBitsPerSecond),
+		}
+		if c, ok := customers[strings.ToLower(talker.Username)]; ok {
+			talker.CustomerID = c.ID
+			talker.CustomerName = c.Name
+			if c.PPPoEProfile != nil {
+				talker.Profile = *c.PPPoEProfile
+			}
+		}
+		talkers = append(talkers, talker)
+
+		totalRx += t.RxBitsPerSecond
+		totalTx += t.TxBitsPerSecond
+
+		key := talker.Profile
+		if key == "" {
+			key = "default"
+		}
+		p, ok := profiles[key]
+		if !ok {
+			p = &domain.ProfileRate{Profile: key}
+			profiles[key] = p
+		}
+		p.Sessions++
+		p.RxBitsPerSecond += t.RxBitsPerSecond
+		p.TxBitsPerSecond += t.TxBitsPerSecond
+	}
+
+	snapshot.Sessions = len(talkers)
+	snapshot.Total = domain.NewSessionTrafficRate(totalRx, totalTx)
+
+	snapshot.Profiles = make([]domain.ProfileRate, 0, len(profiles))
+	for _, p := range profiles {
+		snapshot.Profiles = append(snapshot.Profiles, domain.ProfileRate{
+			Profile:     p.Profile,
+			Sessions:    p.Sessions,
+			TrafficRate: domain.NewSessionTrafficRate(p.RxBitsPerSecond, p.TxBitsPerSecond),
+		})
+	}
+	sort.Slice(snapshot.Profiles, func(i, j int) bool {
+		return snapshot.Profiles[i].Profile < snapshot.Profiles[j].Profile
+	})
+
+	snapshot.TopRx = topTalkers(talkers, TopByRx, s.topN)
+	snapshot.TopTx = topTalkers(talkers, TopByTx, s.topN)
+
+	s.mu.Lock()
+	s.latest = &snapshot
+	s.talkers = talkers
+	for ch := range s.observers {
+		select {
+		case ch <- snapshot:
+		default:
+			// Skip slow observer
+		}
+	}
+	s.mu.Unlock()
+
+	if s.publisher != nil {
+		payload, _ := json.Marshal(snapshot)
+		if err := s.publisher.Publish("mikrotik:traffic:aggregate", string(payload)); err != nil {
+			log.Printf("[TrafficAggregate] Warning: failed to publish snapshot: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Latest returns the most recent snapshot, or nil before the first sample
+func (s *TrafficAggregateService) Latest() *domain.TrafficSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest
+}
+
+// TopTalkers returns the n busiest sessions of the latest sample ordered by rx, tx or total
+func (s *TrafficAggregateService) TopTalkers(n int, by string) []domain.TalkerRate {
+	s.mu.Lock()
+	talkers := s.talkers
+	s.mu.Unlock()
+
+	return topTalkers(talkers, by, n)
+}
+
+// Subscribe returns a channel receiving every new snapshot until ctx is done
+func (s *TrafficAggregateService) Subscribe(ctx context.Context) <-chan domain.TrafficSnapshot {
+	ch := make(chan domain.TrafficSnapshot, 4)
+
+	s.mu.Lock()
+	s.observers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.observers, ch)
+		close(ch)
+		s.mu.Unlock()
+	}()
+
+	return ch
+}
+
+// uplinkNames merges the configured uplinks with the uplink source, without duplicates
+func (s *TrafficAggregateService) uplinkNames() []string {
+	s.mu.Lock()
+	src := s.uplinkSrc
+	s.mu.Unlock()
+
+	if src == nil {
+		return s.uplinks
+	}
+
+	names := append([]string(nil), s.uplinks...)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, name := range src() {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// customerIndex returns the cached username -> customer map, reloading it when stale
+func (s *TrafficAggregateService) customerIndex() map[string]*domain.Customer {
+	if s.customers != nil && time.Since(s.customersLoaded) < customerCacheTTL {
+		return s.customers
+	}
+
+	customers, err := s.repo.ListPPPoECustomers()
+	if err != nil {
+		log.Printf("[TrafficAggregate] Warning: failed to load customers: %v", err)
+		if s.customers == nil {
+			return map[string]*domain.Customer{}
+		}
+		return s.customers
+	}
+
+	index := make(map[string]*domain.Customer, len(customers))
+	for _, c := range customers {
+		if c.PPPoEUsername != nil {
+			index[strings.ToLower(*c.PPPoEUsername)] = c
+		}
+	}
+	s.customers = index
+	s.customersLoaded = time.Now()
+	return index
+}
+
+// keepRunning returns the names of ifaces that are in running
+func keepRunning(ifaces, running []string) []string {
+	present := make(map[string]bool, len(running))
+	for _, name := range running {
+		present[name] = true
+	}
+
+	kept := make([]string, 0, len(ifaces))
+	for _, name := range ifaces {
+		if present[name] {
+			kept = append(kept, name)
+		} else {
+			log.Printf("[TrafficAggregate] Interface %s is gone, skipping it", name)
+		}
+	}
+	return kept
+}
+
+// topTalkers sorts a copy of talkers by the given key and returns the first n
+func topTalkers(talkers []domain.TalkerRate, by string, n int) []domain.TalkerRate {
+	sorted := append([]domain.TalkerRate(nil), talkers...)
+
+	key := func(t domain.TalkerRate) uint64 {
+		switch by {
+		case TopByTx:
+			return t.TxBitsPerSecond
+		case TopByTotal:
+			return t.RxBitsPerSecond + t.TxBitsPerSecond
+		default:
+			return t.RxBitsPerSecond
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i]) > key(sorted[j])
+	})
+
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+
+	"github.com/google/uuid"
+)
+
+// voucherAlphabet leaves out characters that are easy to misread on paper (0/O, 1/I/L)
+const voucherAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+const maxVouchersPerBatch = 1000
+
+// maxVoucherCodeLength is the longest code (prefix included) a batch may produce
+const maxVoucherCodeLength = 50
+
+// voucherJobRetention is how long a finished generation job stays queryable
+const voucherJobRetention = time.Hour
+
+// VoucherCodeOptions controls how voucher credentials are generated
+type VoucherCodeOptions struct {
+	Prefix         string
+	CodeLength     int // default 8
+	PasswordLength int // 0 = password equals the code
+}
+
+// VoucherService generates hotspot vouchers and tracks their usage on the router
+type VoucherService struct {
+	repo     domain.VoucherRepository
+	mtClient *mikrotik.Client
+
+	mu   sync.Mutex
+	jobs map[string]*domain.VoucherJob // by batch ID
+}
+
+// NewVoucherService creates a new voucher service
+func NewVoucherService(repo domain.VoucherRepository, mtClient *mikrotik.Client) *VoucherService {
+	return &VoucherService{
+		repo:     repo,
+		mtClient: mtClient,
+		jobs:     make(map[string]*domain.VoucherJob),
+	}
+}
+
+// StartBatch validates the batch and generates it in the background, since a large
+// batch takes one router call per voucher. Progress is read with Job(batch.ID).
+func (s *VoucherService) StartBatch(batch *domain.VoucherBatch, opts VoucherCodeOptions) (domain.VoucherJob, error) {
+	if err := validateVoucherBatch(batch); err != nil {
+		return domain.VoucherJob{}, err
+	}
+	if err := validateVoucherCodeOptions(&opts); err != nil {
+		return domain.VoucherJob{}, err
+	}
+	batch.RouterID = s.mtClient.Config.RouterID
+
+	job := &domain.VoucherJob{
+		ID:        batch.ID,
+		BatchName: batch.Name,
+		Status:    domain.VoucherJobRunning,
+		Count:     batch.Count,
+		StartedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.pruneJobs(job.StartedAt)
+	s.jobs[job.ID] = job
+	snapshot := *job
+	s.mu.Unlock()
+
+	go s.runJob(job, batch, opts)
+	return snapshot, nil
+}
+
+// Job returns the state of a generation job
+func (s *VoucherService) Job(id string) (domain.VoucherJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return domain.VoucherJob{}, fmt.Errorf("%w: %s", domain.ErrVoucherJobNotFound, id)
+	}
+	return *job, nil
+}
+
+// runJob generates the batch and records the outcome on the job
+func (s *VoucherService) runJob(job *domain.VoucherJob, batch *domain.VoucherBatch, opts VoucherCodeOptions) {
+	_, err := s.generateBatch(batch, opts, func(created int) {
+		s.mu.Lock()
+		job.Created = created
+		s.mu.Unlock()
+	})
+	if err != nil {
+		log.Printf("[Voucher] Failed to generate batch %s: %v", batch.Name, err)
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.FinishedAt = &now
+	if err != nil {
+		job.Status = domain.VoucherJobFailed
+		job.Error = err.Error()
+		job.Created = 0 // rolled back
+	} else {
+		job.Status = domain.VoucherJobDone
+	}
+}
+
+// pruneJobs forgets jobs that finished more than voucherJobRetention ago. Caller holds s.mu.
+func (s *VoucherService) pruneJobs(now time.Time) {
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > voucherJobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// generateBatch creates batch.Count vouchers as /ip/hotspot/user entries and stores them,
+// reporting the number created after each one. If any router call fails, users created
+// so far are removed and nothing is stored.
+func (s *VoucherService) generateBatch(
+	batch *domain.VoucherBatch,
+	opts VoucherCodeOptions,
+	progress func(created int),
+) ([]*domain.Voucher, error) {
+	// Avoid codes that already exist as hotspot users
+	existing, err := s.mtClient.ListHotspotUsers("")
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, u := range existing {
+		taken[u.Name] = true
+	}
+
+	user := mikrotik.HotspotUser{
+		Profile: batch.Profile,
+		Comment: "voucher:" + batch.ID,
+	}
+	if batch.LimitUptime != nil {
+		user.LimitUptime = *batch.LimitUptime
+	}
+	if batch.LimitBytesTotal != nil {
+		user.LimitBytesTotal = uint64(*batch.LimitBytesTotal)
+	}
+
+	vouchers := make([]*domain.Voucher, 0, batch.Count)
+	for len(vouchers) < batch.Count {
+		code, err := randomVoucherString(opts.CodeLength)
+		if err != nil {
+			s.removeHotspotUsers(vouchers)
+			return nil, err
+		}
+		code = opts.Prefix + code
+		if taken[code] {
+			continue
+		}
+		taken[code] = true
+
+		password := code
+		if opts.PasswordLength > 0 {
+			if password, err = randomVoucherString(opts.PasswordLength); err != nil {
+				s.removeHotspotUsers(vouchers)
+				return nil, err
+			}
+		}
+
+		user.Name = code
+		user.Password = password
+		mtID, err := s.mtClient.CreateHotspotUser(user)
+		if err != nil {
+			s.removeHotspotUsers(vouchers)
+			return nil, err
+		}
+
+		vouchers = append(vouchers, &domain.Voucher{
+			ID:         uuid.New().String(),
+			BatchID:    batch.ID,
+			Code:       code,
+			Password:   password,
+			MikrotikID: mtID,
+			Status:     domain.VoucherUnused,
+		})
+		progress(len(vouchers))
+	}
+
+	if err := s.repo.CreateBatch(batch, vouchers); err != nil {
+		s.removeHotspotUsers(vouchers)
+		return nil, err
+	}
+
+	log.Printf("[Voucher] Generated batch %s (%d vouchers, profile %s)", batch.Name, len(vouchers), batch.Profile)
+	return vouchers, nil
+}
+
+// DeleteBatch removes a batch and its hotspot users
+func (s *VoucherService) DeleteBatch(id string) error {
+	if _, err := s.repo.GetBatch(id); err != nil {
+		return err
+	}
+
+	vouchers, err := s.repo.ListVouchers(id)
+	if err != nil {
+		return err
+	}
+	s.removeHotspotUsers(vouchers)
+
+	return s.repo.DeleteBatch(id)
+}
+
+// GetBatch returns a batch with its vouchers
+func (s *VoucherService) GetBatch(id string) (*domain.VoucherBatch, []*domain.Voucher, error) {
+	batch, err := s.repo.GetBatch(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vouchers, err := s.repo.ListVouchers(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, v := range vouchers {
+		setRemainingBytes(v, batch)
+	}
+
+	return batch, vouchers, nil
+}
+
+// ListBatches returns all voucher batches
+func (s *VoucherService) ListBatches() ([]*domain.VoucherBatch, error) {
+	return s.repo.ListBatches()
+}
+
+// GetVoucher looks up a voucher by code
+func (s *VoucherService) GetVoucher(code string) (*domain.Voucher, error) {
+	v, err := s.repo.GetVoucherByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := s.repo.GetBatch(v.BatchID)
+	if err != nil {
+		return nil, err
+	}
+	setRemainingBytes(v, batch)
+
+	return v, nil
+}
+
+// Run syncs voucher usage every interval until ctx is cancelled
+func (s *VoucherService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.SyncUsage(); err != nil {
+				log.Printf("[Voucher] Usage sync failed: %v", err)
+			}
+		}
+	}
+}
+
+// SyncUsage reads the hotspot user counters and updates first use, expiry and quota state.
+// Validity starts at the first sync that sees traffic or uptime on the voucher.
+// Expired vouchers are removed from the router.
+func (s *VoucherService) SyncUsage() error {
+	vouchers, err := s.repo.ListVouchersByStatus(domain.VoucherUnused, domain.VoucherActive)
+	if err != nil {
+		return err
+	}
+	if len(vouchers) == 0 {
+		return nil
+	}
+
+	users, err := s.mtClient.ListHotspotUsers("")
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]mikrotik.HotspotUser, len(users))
+	for _, u := range users {
+		byName[u.Name] = u
+	}
+
+	batches := make(map[string]*domain.VoucherBatch)
+	now := time.Now()
+
+	for _, v := range vouchers {
+		batch, ok := batches[v.BatchID]
+		if !ok {
+			if batch, err = s.repo.GetBatch(v.BatchID); err != nil {
+				log.Printf("[Voucher] Warning: %v", err)
+				continue
+			}
+			batches[v.BatchID] = batch
+		}
+
+		oldStatus := v.Status
+		user, onRouter := byName[v.Code]
+		if onRouter {
+			v.MikrotikID = user.ID
+			v.BytesUsed = int64(user.BytesIn + user.BytesOut)
+			if user.Uptime != "" {
+				if uptime, err := mikrotik.ParseDuration(user.Uptime); err == nil {
+					v.UptimeSeconds = int64(uptime / time.Second)
+				}
+			}
+
+			if v.FirstUsedAt == nil && (v.BytesUsed > 0 || v.UptimeSeconds > 0) {
+				first := now
+				v.FirstUsedAt = &first
+				v.Status = domain.VoucherActive
+				if batch.ValiditySeconds > 0 {
+					expires := first.Add(time.Duration(batch.ValiditySeconds) * time.Second)
+					v.ExpiresAt = &expires
+				}
+			}
+		}
+
+		switch {
+		case v.ExpiresAt != nil && !now.Before(*v.ExpiresAt):
+			v.Status = domain.VoucherExpired
+		case quotaReached(v, batch):
+			v.Status = domain.VoucherExhausted
+		}
+
+		if v.Status == domain.VoucherExpired && onRouter {
+			if err := s.mtClient.DeleteHotspotUser(user.ID); err != nil {
+				log.Printf("[Voucher] Warning: failed to remove expired voucher %s: %v", v.Code, err)
+				v.Status = oldStatus // retry on the next sync
+			}
+		}
+
+		v.LastSyncedAt = &now
+		if err := s.repo.UpdateVoucher(v); err != nil {
+			log.Printf("[Voucher] Warning: %v", err)
+			continue
+		}
+		if v.Status != oldStatus {
+			log.Printf("[Voucher] %s: %s -> %s", v.Code, oldStatus, v.Status)
+		}
+	}
+
+	return nil
+}
+
+// removeHotspotUsers deletes the vouchers' hotspot users, logging failures
+func (s *VoucherService) removeHotspotUsers(vouchers []*domain.Voucher) {
+	for _, v := range vouchers {
+		mtID := v.MikrotikID
+		if mtID == "" {
+			mtID, _ = s.mtClient.FindHotspotUserID(v.Code)
+		}
+		if mtID == "" {
+			continue
+		}
+		if err := s.mtClient.DeleteHotspotUser(mtID); err != nil {
+			log.Printf("[Voucher] Warning: failed to remove hotspot user %s: %v", v.Code, err)
+		}
+	}
+}
+
+func validateVoucherBatch(b *domain.VoucherBatch) error {
+	if b.Name == "" {
+		return fmt.Errorf("%w: name is required", domain.ErrInvalidVoucherBatch)
+	}
+	if b.Count < 1 || b.Count > maxVouchersPerBatch {
+		return fmt.Errorf("%w: count must be between 1 and %d", domain.ErrInvalidVoucherBatch, maxVouchersPerBatch)
+	}
+	if b.Profile == "" {
+		b.Profile = "default"
+	}
+	if b.ValiditySeconds < 0 {
+		return fmt.Errorf("%w: validity must not be negative", domain.ErrInvalidVoucherBatch)
+	}
+	if b.Price < 0 {
+		return fmt.Errorf("%w: price must not be negative", domain.ErrInvalidVoucherBatch)
+	}
+	if b.LimitUptime != nil {
+		if _, err := mikrotik.ParseDuration(*b.LimitUptime); err != nil {
+			return fmt.Errorf("%w: limit_uptime: %v", domain.ErrInvalidVoucherBatch, err)
+		}
+	}
+	if b.LimitBytesTotal != nil && *b.LimitBytesTotal <= 0 {
+		return fmt.Errorf("%w: limit_bytes_total must be positive", domain.ErrInvalidVoucherBatch)
+	}
+	return nil
+}
+
+// validateVoucherCodeOptions applies defaults and checks that the codes fit a hotspot user name
+func validateVoucherCodeOptions(opts *VoucherCodeOptions) error {
+	if opts.CodeLength == 0 {
+		opts.CodeLength = 8
+	}
+	if opts.CodeLength < 4 || opts.CodeLength > 32 || opts.PasswordLength < 0 || opts.PasswordLength > 32 {
+		return fmt.Errorf("%w: code_length must be 4-32 and password_length 0-32", domain.ErrInvalidVoucherBatch)
+	}
+	if len(opts.Prefix)+opts.CodeLength > maxVoucherCodeLength {
+		return fmt.Errorf("%w: prefix and code_length must not exceed %d characters",
+			domain.ErrInvalidVoucherBatch, maxVoucherCodeLength)
+	}
+	for _, r := range opts.Prefix {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("%w: prefix may only contain letters, digits, '-' and '_'", domain.ErrInvalidVoucherBatch)
+		}
+	}
+	return nil
+}
+
+// quotaReached reports whether the voucher used up its byte or uptime limit
+func quotaReached(v *domain.Voucher, b *domain.VoucherBatch) bool {
+	if b.LimitBytesTotal != nil && v.BytesUsed >= *b.LimitBytesTotal {
+		return true
+	}
+	if b.LimitUptime != nil {
+		limit, err := mikrotik.ParseDuration(*b.LimitUptime)
+		if err == nil && limit > 0 && v.UptimeSeconds >= int64(limit/time.Second) {
+			return true
+		}
+	}
+	return false
+}
+
+func setRemainingBytes(v *domain.Voucher, b *domain.VoucherBatch) {
+	if b.LimitBytesTotal == nil {
+		return
+	}
+	remaining := *b.LimitBytesTotal - v.BytesUsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	v.RemainingBytes = &remaining
+}
+
+func randomVoucherString(n int) (string, error) {
+	max := big.NewInt(int64(len(voucherAlphabet)))
+	buf := make([]byte, n)
+	for i := range buf {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate voucher code: %w", err)
+		}
+		buf[i] = voucherAlphabet[idx.Int64()]
+	}
+	return string(buf), nil
+}
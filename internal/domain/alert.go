@@ -0,0 +1,255 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Alerting validation errors
+var (
+	ErrInvalidAlertRule = errors.New("invalid alert rule")
+	ErrInvalidNotifier  = errors.New("invalid notifier")
+	ErrInvalidSilence   = errors.New("invalid silence")
+)
+
+// Alerting lookup errors
+var (
+	ErrAlertRuleNotFound = errors.New("alert rule not found")
+	ErrNotifierNotFound  = errors.New("notifier not found")
+	ErrSilenceNotFound   = errors.New("silence not found")
+)
+
+// Metrics alert rules are evaluated against. The subject of a sample is the
+// customer ID, interface name or router ID it belongs to.
+const (
+	MetricCustomerOffline       = "customer_offline"           // 1 while the customer's session is down, 0 when up
+	MetricPacketLoss            = "packet_loss_percent"        // per customer ping
+	MetricLatency               = "latency_ms"                 // per customer ping, average round trip
+	MetricRouterUnreachable     = "router_unreachable"         // 1 while the router API cannot be reached
+	MetricRouterCPU             = "router_cpu_percent"         // per router, /system/resource cpu-load
+	MetricRouterMemory          = "router_memory_percent"      // per router, used memory
+	MetricRouterTemperature     = "router_temperature_celsius" // per router, boards with a sensor only
+	MetricRouterVoltage         = "router_voltage"             // per router, boards with a sensor only
+	MetricRouterPPPSessions     = "router_ppp_sessions"        // per router, active PPP sessions
+	MetricRouterInterfaceErrors = "router_interface_errors"    // per router, errors + drops since the previous poll
+)
+
+// EventMetric reports whether a metric is only reported when its state changes,
+// e.g. by the PPPoE callbacks, instead of being sampled periodically. Such
+// series stay valid however old their last sample is.
+func EventMetric(metric string) bool {
+	return metric == MetricCustomerOffline
+}
+
+// Alert statuses
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
+// Alert severities
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Notifier types
+const (
+	NotifierWebhook  = "webhook"
+	NotifierEmail    = "email"
+	NotifierTelegram = "telegram"
+	NotifierWhatsApp = "whatsapp"
+)
+
+// AlertRule fires when a metric breaches the threshold for at least ForSeconds
+type AlertRule struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	Name          string    `json:"name" gorm:"column:name"`
+	Metric        string    `json:"metric" gorm:"column:metric"`
+	Operator      string    `json:"operator" gorm:"column:operator"`
+	Threshold     float64   `json:"threshold" gorm:"column:threshold"`
+	ForSeconds    int       `json:"for_seconds" gorm:"column:for_seconds"`
+	Subject       *string   `json:"subject" gorm:"column:subject"` // nil = every subject
+	Severity      string    `json:"severity" gorm:"column:severity"`
+	NotifierIDs   []string  `json:"notifier_ids" gorm:"column:notifier_ids;serializer:json"` // empty = every enabled notifier
+	RepeatMinutes int       `json:"repeat_minutes" gorm:"column:repeat_minutes"`
+	Enabled       bool      `json:"enabled" gorm:"column:enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Validate checks the rule and fills defaults
+func (r *AlertRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAlertRule)
+	}
+	switch r.Metric {
+	case MetricCustomerOffline, MetricPacketLoss, MetricLatency, MetricRouterUnreachable,
+		MetricRouterCPU, MetricRouterMemory, MetricRouterTemperature, MetricRouterVoltage,
+		MetricRouterPPPSessions, MetricRouterInterfaceErrors:
+	default:
+		return fmt.Errorf("%w: unknown metric %q", ErrInvalidAlertRule, r.Metric)
+	}
+	switch r.Operator {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return fmt.Errorf("%w: operator must be one of > >= < <= == !=", ErrInvalidAlertRule)
+	}
+	if r.ForSeconds < 0 || r.RepeatMinutes < 0 {
+		return fmt.Errorf("%w: for_seconds and repeat_minutes cannot be negative", ErrInvalidAlertRule)
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityWarning
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return fmt.Errorf("%w: severity must be info, warning or critical", ErrInvalidAlertRule)
+	}
+	if r.NotifierIDs == nil {
+		r.NotifierIDs = []string{}
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to a sample of metric for subject
+func (r *AlertRule) Matches(metric, subject string) bool {
+	return r.Enabled && r.Metric == metric && (r.Subject == nil || *r.Subject == subject)
+}
+
+// Breached reports whether value satisfies the rule condition
+func (r *AlertRule) Breached(value float64) bool {
+	switch r.Operator {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	case "==":
+		return value == r.Threshold
+	case "!=":
+		return value != r.Threshold
+	}
+	return false
+}
+
+// Notifier is a configured notification channel
+type Notifier struct {
+	ID        string            `json:"id" gorm:"primaryKey"`
+	Name      string            `json:"name" gorm:"column:name"`
+	Type      string            `json:"type" gorm:"column:type"`
+	Config    map[string]string `json:"config" gorm:"column:config;serializer:json"`
+	Enabled   bool              `json:"enabled" gorm:"column:enabled"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// TableName overrides the table name
+func (Notifier) TableName() string {
+	return "alert_notifiers"
+}
+
+// notifierSecretKeys are config keys hidden from API responses
+var notifierSecretKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"bot_token":     true,
+	"authorization": true,
+}
+
+// MaskedSecret replaces secret notifier config values in API responses
+const MaskedSecret = "********"
+
+// Masked returns a copy with secret config values replaced by MaskedSecret
+func (n *Notifier) Masked() *Notifier {
+	masked := *n
+	masked.Config = make(map[string]string, len(n.Config))
+	for k, v := range n.Config {
+		if notifierSecretKeys[k] && v != "" {
+			v = MaskedSecret
+		}
+		masked.Config[k] = v
+	}
+	return &masked
+}
+
+// Alert is one firing (or resolved) occurrence of a rule for a subject
+type Alert struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	RuleID         string     `json:"rule_id" gorm:"column:rule_id"`
+	RuleName       string     `json:"rule_name" gorm:"column:rule_name"`
+	Metric         string     `json:"metric" gorm:"column:metric"`
+	Subject        string     `json:"subject" gorm:"column:subject"`
+	Severity       string     `json:"severity" gorm:"column:severity"`
+	Status         string     `json:"status" gorm:"column:status"`
+	Value          float64    `json:"value" gorm:"column:value"`
+	Threshold      float64    `json:"threshold" gorm:"column:threshold"`
+	Message        string     `json:"message" gorm:"column:message"`
+	StartsAt       time.Time  `json:"starts_at" gorm:"column:starts_at"`
+	ResolvedAt     *time.Time `json:"resolved_at" gorm:"column:resolved_at"`
+	LastNotifiedAt *time.Time `json:"last_notified_at" gorm:"column:last_notified_at"`
+	Silenced       bool       `json:"silenced" gorm:"-"`
+}
+
+// Silence suppresses notifications for matching alerts during a time window
+type Silence struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	RuleID    *string   `json:"rule_id" gorm:"column:rule_id"` // nil = every rule
+	Subject   *string   `json:"subject" gorm:"column:subject"` // nil = every subject
+	StartsAt  time.Time `json:"starts_at" gorm:"column:starts_at"`
+	EndsAt    time.Time `json:"ends_at" gorm:"column:ends_at"`
+	Comment   *string   `json:"comment" gorm:"column:comment"`
+	CreatedBy *string   `json:"created_by" gorm:"column:created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName overrides the table name
+func (Silence) TableName() string {
+	return "alert_silences"
+}
+
+// Validate checks the silence window
+func (s *Silence) Validate() error {
+	if s.StartsAt.IsZero() {
+		s.StartsAt = time.Now()
+	}
+	if !s.EndsAt.After(s.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSilence)
+	}
+	return nil
+}
+
+// Matches reports whether the silence covers an alert of ruleID for subject at time at
+func (s *Silence) Matches(ruleID, subject string, at time.Time) bool {
+	if at.Before(s.StartsAt) || !at.Before(s.EndsAt) {
+		return false
+	}
+	return (s.RuleID == nil || *s.RuleID == ruleID) && (s.Subject == nil || *s.Subject == subject)
+}
+
+// AlertRepository defines database operations for alerting
+type AlertRepository interface {
+	CreateRule(r *AlertRule) error
+	UpdateRule(r *AlertRule) error
+	DeleteRule(id string) error
+	GetRule(id string) (*AlertRule, error)
+	ListRules() ([]*AlertRule, error)
+
+	CreateNotifier(n *Notifier) error
+	UpdateNotifier(n *Notifier) error
+	DeleteNotifier(id string) error
+	GetNotifier(id string) (*Notifier, error)
+	ListNotifiers() ([]*Notifier, error)
+
+	CreateAlert(a *Alert) error
+	UpdateAlert(a *Alert) error
+	ListAlerts(status string, limit int) ([]*Alert, error) // status "" = all, newest first
+
+	CreateSilence(s *Silence) error
+	DeleteSilence(id string) error
+	ListSilences(includeExpired bool) ([]*Silence, error) // false = only silences that have not ended
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// Audited actions
+const (
+	AuditActionCustomerDisconnect = "customer.disconnect"
+)
+
+// AuditLog records an operator action
+type AuditLog struct {
+	ID           int64                  `json:"id" gorm:"primaryKey"`
+	Action       string                 `json:"action" gorm:"column:action"`
+	ResourceType string                 `json:"resource_type" gorm:"column:resource_type"`
+	ResourceID   string                 `json:"resource_id" gorm:"column:resource_id"`
+	Actor        string                 `json:"actor" gorm:"column:actor"`
+	Details      map[string]interface{} `json:"details" gorm:"column:details;serializer:json"`
+	CreatedAt    time.Time              `json:"created_at" gorm:"column:created_at"`
+}
+
+// AuditRepository defines database operations for the audit log
+type AuditRepository interface {
+	CreateAuditLog(entry *AuditLog) error
+}
@@ -1,10 +1,24 @@
 package domain
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrCustomerModified is returned when an optimistic-concurrency precondition
+// (If-Match / updated_at) no longer matches the stored customer.
+var ErrCustomerModified = errors.New("customer was modified by another request")
+
+// ErrInvalidCustomer wraps validation failures on customer input
+var ErrInvalidCustomer = errors.New("invalid customer")
+
+// ErrNoActiveSession is returned when a customer has no session on the router
+var ErrNoActiveSession = errors.New("customer has no active session")
+
+// ErrCustomerNotFound is returned when no customer matches the lookup
+var ErrCustomerNotFound = errors.New("customer not found")
+
 // Customer represents a customer in the system
 type Customer struct {
 	ID          string  `json:"id" gorm:"primaryKey"`
@@ -20,10 +34,13 @@ type Customer struct {
 	PPPoEPassword *string `json:"pppoe_password" gorm:"column:pppoe_password"`
 	PPPoEProfile  *string `json:"pppoe_profile" gorm:"column:pppoe_profile"`
 
+	// Service plan (drives PPPoEProfile when set)
+	PlanID *string `json:"plan_id" gorm:"column:plan_id"`
+
 	// Hotspot specific
 	HotspotUsername *string `json:"hotspot_username" gorm:"column:hotspot_username"`
 	HotspotPassword *string `json:"hotspot_password" gorm:"column:hotspot_password"`
-	HotspotMacAddr  *string `json:"hotspot_mac_addr" gorm:"column:hotspot_mac_addr"`
+	HotspotMacAddr  *string `json:"hotspot_mac_addr" gorm:"column:hotspot_mac_address"`
 
 	// Static IP
 	StaticIP *string `json:"static_ip" gorm:"column:static_ip"`
@@ -38,25 +55,98 @@ type Customer struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// CustomerTrafficData represents traffic data for a customer
+// CustomerPatch holds a partial customer update keyed by DB column.
+// A nil value clears the column (JSON Merge Patch null), absent keys are left untouched.
+type CustomerPatch map[string]*string
+
+// Apply copies the patched values onto c
+func (p CustomerPatch) Apply(c *Customer) {
+	for column, value := range p {
+		switch column {
+		case "name":
+			c.Name = DerefOrEmpty(value)
+		case "username":
+			c.Username = DerefOrEmpty(value)
+		case "service_type":
+			c.ServiceType = DerefOrEmpty(value)
+		case "status":
+			c.Status = DerefOrEmpty(value)
+		case "phone":
+			c.Phone = value
+		case "email":
+			c.Email = value
+		case "pppoe_username":
+			c.PPPoEUsername = value
+		case "pppoe_password":
+			c.PPPoEPassword = value
+		case "pppoe_profile":
+			c.PPPoEProfile = value
+		case "hotspot_username":
+			c.HotspotUsername = value
+		case "hotspot_password":
+			c.HotspotPassword = value
+		case "hotspot_mac_address":
+			c.HotspotMacAddr = value
+		case "static_ip":
+			c.StaticIP = value
+		case "plan_id":
+			c.PlanID = value
+		}
+	}
+}
+
+// Has reports whether the patch touches the given column
+func (p CustomerPatch) Has(column string) bool {
+	_, ok := p[column]
+	return ok
+}
+
+// DerefOrEmpty returns the pointed-to string, or "" for nil
+func DerefOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// CustomerTrafficData represents traffic data for a customer (schema v2).
+// Rates are per second and counted on the router interface: on <pppoe-…> rx is the
+// customer's upload and tx their download, the same order as Plan.RateLimit.
+// V1 keeps the legacy speed strings, see CustomerTrafficData.V1.
 type CustomerTrafficData struct {
-	CustomerID         string    `json:"customer_id"`
-	CustomerName       string    `json:"customer_name"`
-	Username           string    `json:"username"`
-	ServiceType        string    `json:"service_type"`
-	InterfaceName      string    `json:"interface_name"`
-	RxBitsPerSecond    string    `json:"rx_bits_per_second"`
-	TxBitsPerSecond    string    `json:"tx_bits_per_second"`
-	RxPacketsPerSecond string    `json:"rx_packets_per_second"`
-	TxPacketsPerSecond string    `json:"tx_packets_per_second"`
-	DownloadSpeed      string    `json:"download_speed"`
-	UploadSpeed        string    `json:"upload_speed"`
-	Timestamp          time.Time `json:"timestamp"`
+	CustomerID    string `json:"customer_id"`
+	CustomerName  string `json:"customer_name"`
+	Username      string `json:"username"`
+	ServiceType   string `json:"service_type"`
+	InterfaceName string `json:"interface_name"`
+
+	RxBitsPerSecond    uint64 `json:"rx_bits_per_second"`
+	TxBitsPerSecond    uint64 `json:"tx_bits_per_second"`
+	RxPacketsPerSecond uint64 `json:"rx_packets_per_second"`
+	TxPacketsPerSecond uint64 `json:"tx_packets_per_second"`
+
+	// Fast-path share of the totals above
+	FpRxBitsPerSecond    uint64 `json:"fp_rx_bits_per_second"`
+	FpTxBitsPerSecond    uint64 `json:"fp_tx_bits_per_second"`
+	FpRxPacketsPerSecond uint64 `json:"fp_rx_packets_per_second"`
+	FpTxPacketsPerSecond uint64 `json:"fp_tx_packets_per_second"`
+
+	RxDropsPerSecond      uint64 `json:"rx_drops_per_second"`
+	TxDropsPerSecond      uint64 `json:"tx_drops_per_second"`
+	TxQueueDropsPerSecond uint64 `json:"tx_queue_drops_per_second"`
+	RxErrorsPerSecond     uint64 `json:"rx_errors_per_second"`
+	TxErrorsPerSecond     uint64 `json:"tx_errors_per_second"`
+
+	DownloadSpeed string    `json:"download_speed"`
+	UploadSpeed   string    `json:"upload_speed"`
+	Timestamp     time.Time `json:"timestamp"`
 }
 
 // CustomerRepository defines database operations for customers
 type CustomerRepository interface {
 	GetActivePPPoECustomers() ([]*Customer, error)
+	ListPPPoECustomers() ([]*Customer, error)
+	ListOnlineCustomers() ([]*Customer, error)
 	GetCustomerByID(id string) (*Customer, error)
 	GetCustomerByPPPoEUsername(username string) (*Customer, error)
 	UpdateCustomerStatus(id string, status string, ipAddress *string, macAddress *string) error
@@ -64,6 +154,7 @@ type CustomerRepository interface {
 	// CRUD operations
 	CreateCustomer(customer *Customer) error
 	UpdateCustomer(customer *Customer) error
+	PatchCustomer(id string, patch CustomerPatch, expectedUpdatedAt *time.Time) error
 	DeleteCustomer(id string) error
 	ListCustomers(page, limit int) ([]*Customer, int, error)
 }
@@ -72,6 +163,9 @@ type CustomerRepository interface {
 type RedisPublisher interface {
 	Publish(channel string, message string) error
 	PublishStream(streamKey string, data string) error
+	PublishTopic(topic string, message string) error
+	// PublishEvent sends an event to the mikrotik:events channel and to each topic
+	PublishEvent(message string, topics ...string) error
 }
 
 // GetInterfaceNameForCustomer returns the interface name for monitoring
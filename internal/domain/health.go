@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Dependencies checked by the health service
+const (
+	DependencyMikroTik = "mikrotik"
+	DependencyRedis    = "redis"
+	DependencyDatabase = "database"
+)
+
+// Dependency states
+const (
+	DependencyUnknown = "unknown" // not checked yet
+	DependencyUp      = "up"
+	DependencyDown    = "down"
+)
+
+// DependencyStatus is the latest background check of one dependency
+type DependencyStatus struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	Required    bool       `json:"required"` // readiness fails while a required dependency is down
+	LatencyMs   float64    `json:"latency_ms"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+	Since       time.Time  `json:"since"` // when the status last changed
+}
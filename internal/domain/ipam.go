@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidSubnet wraps validation failures on subnet input
+	ErrInvalidSubnet = errors.New("invalid subnet")
+	// ErrAddressConflict is returned when an address overlaps a pool, subnet or existing allocation
+	ErrAddressConflict = errors.New("address conflict")
+	// ErrSubnetExhausted is returned when a subnet has no free address left
+	ErrSubnetExhausted = errors.New("no free address in subnet")
+	// ErrSubnetNotFound is returned when no subnet matches the ID
+	ErrSubnetNotFound = errors.New("subnet not found")
+)
+
+// IPSubnet is a static address range managed by IPAM on one router
+type IPSubnet struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	RouterID    string    `json:"router_id" gorm:"column:router_id"`
+	CIDR        string    `json:"cidr" gorm:"column:cidr"`
+	Gateway     *string   `json:"gateway" gorm:"column:gateway"`
+	Description *string   `json:"description" gorm:"column:description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName overrides the default GORM table name
+func (IPSubnet) TableName() string {
+	return "ipam_subnets"
+}
+
+// IPAllocation binds one address of a subnet to a customer
+type IPAllocation struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	SubnetID    string    `json:"subnet_id" gorm:"column:subnet_id"`
+	CustomerID  string    `json:"customer_id" gorm:"column:customer_id"`
+	Address     string    `json:"address" gorm:"column:address"`
+	AllocatedAt time.Time `json:"allocated_at" gorm:"column:allocated_at"`
+}
+
+// TableName overrides the default GORM table name
+func (IPAllocation) TableName() string {
+	return "ipam_allocations"
+}
+
+// IPAMRepository defines database operations for IPAM
+type IPAMRepository interface {
+	CreateSubnet(subnet *IPSubnet) error
+	DeleteSubnet(id string) error
+	GetSubnetByID(id string) (*IPSubnet, error)
+	ListSubnets(routerID string) ([]*IPSubnet, error)
+
+	CreateAllocation(allocation *IPAllocation) error
+	UpdateAllocation(allocation *IPAllocation) error
+	DeleteAllocationByCustomer(customerID string) error
+	GetAllocationByCustomer(customerID string) (*IPAllocation, error)
+	ListAllocations(subnetID string) ([]*IPAllocation, error)
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidMonitoredInterface wraps validation failures on monitored interface input
+var ErrInvalidMonitoredInterface = errors.New("invalid monitored interface")
+
+// ErrMonitoredInterfaceNotFound is returned when no monitored interface matches the ID
+var ErrMonitoredInterfaceNotFound = errors.New("monitored interface not found")
+
+// Monitored interface kinds
+const (
+	InterfaceKindWAN    = "wan"
+	InterfaceKindBridge = "bridge"
+	InterfaceKindVLAN   = "vlan"
+	InterfaceKindOther  = "other"
+)
+
+// MonitoredInterface is an infrastructure interface watched continuously against its capacity
+type MonitoredInterface struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	RouterID         string    `json:"router_id" gorm:"column:router_id"`
+	Name             string    `json:"name" gorm:"column:name"`
+	Kind             string    `json:"kind" gorm:"column:kind"`
+	CapacityBps      int64     `json:"capacity_bps" gorm:"column:capacity_bps"`
+	ThresholdPercent int       `json:"threshold_percent" gorm:"column:threshold_percent"`
+	ThresholdMinutes int       `json:"threshold_minutes" gorm:"column:threshold_minutes"`
+	Enabled          bool      `json:"enabled" gorm:"column:enabled"`
+	Description      *string   `json:"description" gorm:"column:description"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Validate checks the interface definition and fills defaults
+func (m *MonitoredInterface) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidMonitoredInterface)
+	}
+	switch m.Kind {
+	case "":
+		m.Kind = InterfaceKindWAN
+	case InterfaceKindWAN, InterfaceKindBridge, InterfaceKindVLAN, InterfaceKindOther:
+	default:
+		return fmt.Errorf("%w: kind must be wan, bridge, vlan or other", ErrInvalidMonitoredInterface)
+	}
+	if m.CapacityBps <= 0 {
+		return fmt.Errorf("%w: capacity_bps must be positive", ErrInvalidMonitoredInterface)
+	}
+	if m.ThresholdPercent < 1 || m.ThresholdPercent > 100 {
+		return fmt.Errorf("%w: threshold_percent must be between 1 and 100", ErrInvalidMonitoredInterface)
+	}
+	if m.ThresholdMinutes < 1 {
+		return fmt.Errorf("%w: threshold_minutes must be at least 1", ErrInvalidMonitoredInterface)
+	}
+	return nil
+}
+
+// Utilization returns the busier direction as a percentage of capacity
+func (m *MonitoredInterface) Utilization(rxBps, txBps uint64) float64 {
+	if m.CapacityBps <= 0 {
+		return 0
+	}
+	busiest := rxBps
+	if txBps > busiest {
+		busiest = txBps
+	}
+	return float64(busiest) * 100 / float64(m.CapacityBps)
+}
+
+// InterfaceTrafficHistory is a one-minute traffic bucket of a monitored interface
+type InterfaceTrafficHistory struct {
+	ID                 int64     `json:"-" gorm:"primaryKey"`
+	InterfaceID        string    `json:"interface_id" gorm:"column:interface_id"`
+	RxAvgBps           int64     `json:"rx_avg_bps" gorm:"column:rx_avg_bps"`
+	TxAvgBps           int64     `json:"tx_avg_bps" gorm:"column:tx_avg_bps"`
+	RxMaxBps           int64     `json:"rx_max_bps" gorm:"column:rx_max_bps"`
+	TxMaxBps           int64     `json:"tx_max_bps" gorm:"column:tx_max_bps"`
+	UtilizationPercent float64   `json:"utilization_percent" gorm:"column:utilization_percent"`
+	SampledAt          time.Time `json:"sampled_at" gorm:"column:sampled_at"`
+}
+
+// TableName overrides the table name
+func (InterfaceTrafficHistory) TableName() string {
+	return "interface_traffic_history"
+}
+
+// InterfaceAlert records a period of sustained high utilization
+type InterfaceAlert struct {
+	ID               string     `json:"id" gorm:"primaryKey"`
+	InterfaceID      string     `json:"interface_id" gorm:"column:interface_id"`
+	ThresholdPercent int        `json:"threshold_percent" gorm:"column:threshold_percent"`
+	PeakPercent      float64    `json:"peak_percent" gorm:"column:peak_percent"`
+	StartedAt        time.Time  `json:"started_at" gorm:"column:started_at"`
+	FiredAt          time.Time  `json:"fired_at" gorm:"column:fired_at"`
+	ResolvedAt       *time.Time `json:"resolved_at" gorm:"column:resolved_at"`
+}
+
+// MonitoredInterfaceRepository defines database operations for monitored interfaces
+type MonitoredInterfaceRepository interface {
+	CreateInterface(m *MonitoredInterface) error
+	UpdateInterface(m *MonitoredInterface) error
+	DeleteInterface(id string) error
+	GetInterface(id string) (*MonitoredInterface, error)
+	ListInterfaces(routerID string) ([]*MonitoredInterface, error)
+
+	SaveHistory(h *InterfaceTrafficHistory) error
+	ListHistory(interfaceID string, from, to time.Time) ([]*InterfaceTrafficHistory, error)
+	PruneHistory(before time.Time) (int64, error)
+
+	CreateAlert(a *InterfaceAlert) error
+	UpdateAlert(a *InterfaceAlert) error
+	GetOpenAlert(interfaceID string) (*InterfaceAlert, error) // nil when none
+	ListAlerts(interfaceID string, openOnly bool) ([]*InterfaceAlert, error)
+}
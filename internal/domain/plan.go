@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPlan wraps validation failures on plan input
+var ErrInvalidPlan = errors.New("invalid plan")
+
+// ErrPlanInUse is returned when deleting a plan that customers still reference
+var ErrPlanInUse = errors.New("plan is still assigned to customers")
+
+// ErrPlanNotFound is returned when no plan matches the ID
+var ErrPlanNotFound = errors.New("plan not found")
+
+// Plan represents a service package mapped to a /ppp/profile on every router
+type Plan struct {
+	ID          string  `json:"id" gorm:"primaryKey"`
+	Name        string  `json:"name" gorm:"column:name"`
+	Description *string `json:"description" gorm:"column:description"`
+	ProfileName string  `json:"profile_name" gorm:"column:profile_name"`
+
+	// Rates in RouterOS notation (e.g. "10M", "512k")
+	DownloadRate string `json:"download_rate" gorm:"column:download_rate"`
+	UploadRate   string `json:"upload_rate" gorm:"column:upload_rate"`
+
+	// Burst settings
+	BurstDownloadRate      *string `json:"burst_download_rate" gorm:"column:burst_download_rate"`
+	BurstUploadRate        *string `json:"burst_upload_rate" gorm:"column:burst_upload_rate"`
+	BurstThresholdDownload *string `json:"burst_threshold_download" gorm:"column:burst_threshold_download"`
+	BurstThresholdUpload   *string `json:"burst_threshold_upload" gorm:"column:burst_threshold_upload"`
+	BurstTime              *int    `json:"burst_time" gorm:"column:burst_time"` // seconds
+
+	// Profile addressing
+	LocalAddress  *string `json:"local_address" gorm:"column:local_address"`
+	RemoteAddress *string `json:"remote_address" gorm:"column:remote_address"` // address or pool name
+
+	Price      float64 `json:"price" gorm:"column:price"`
+	QuotaBytes *int64  `json:"quota_bytes" gorm:"column:quota_bytes"` // nil = unlimited
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	RouterProfiles []PlanRouterProfile `json:"router_profiles,omitempty" gorm:"-"`
+}
+
+// PlanRouterProfile tracks the /ppp/profile entry of a plan on one router
+type PlanRouterProfile struct {
+	PlanID     string     `json:"plan_id" gorm:"primaryKey;column:plan_id"`
+	RouterID   string     `json:"router_id" gorm:"primaryKey;column:router_id"`
+	MikrotikID string     `json:"mikrotik_id" gorm:"column:mikrotik_id"`
+	SyncedAt   *time.Time `json:"synced_at" gorm:"column:synced_at"`
+	LastError  *string    `json:"last_error" gorm:"column:last_error"`
+}
+
+// TableName overrides the default GORM table name
+func (PlanRouterProfile) TableName() string {
+	return "plan_router_profiles"
+}
+
+// PlanRepository defines database operations for plans
+type PlanRepository interface {
+	CreatePlan(plan *Plan) error
+	UpdatePlan(plan *Plan) error
+	DeletePlan(id string) error
+	GetPlanByID(id string) (*Plan, error)
+	ListPlans() ([]*Plan, error)
+	CountCustomersByPlan(planID string) (int, error)
+
+	SaveRouterProfile(profile *PlanRouterProfile) error
+	ListRouterProfiles(planID string) ([]PlanRouterProfile, error)
+}
+
+var rateRegexp = regexp.MustCompile(`^\d+(\.\d+)?[kKmMgG]?$`)
+
+// Validate checks the plan's required fields and rate notation
+func (p *Plan) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: plan name is required", ErrInvalidPlan)
+	}
+	if strings.TrimSpace(p.ProfileName) == "" {
+		return fmt.Errorf("%w: profile_name is required", ErrInvalidPlan)
+	}
+
+	rates := map[string]*string{
+		"download_rate":            &p.DownloadRate,
+		"upload_rate":              &p.UploadRate,
+		"burst_download_rate":      p.BurstDownloadRate,
+		"burst_upload_rate":        p.BurstUploadRate,
+		"burst_threshold_download": p.BurstThresholdDownload,
+		"burst_threshold_upload":   p.BurstThresholdUpload,
+	}
+	for field, rate := range rates {
+		if rate == nil {
+			continue
+		}
+		if *rate == "" && (field == "download_rate" || field == "upload_rate") {
+			return fmt.Errorf("%w: %s is required", ErrInvalidPlan, field)
+		}
+		if *rate != "" && !rateRegexp.MatchString(*rate) {
+			return fmt.Errorf("%w: %s %q is not a valid rate (e.g. 10M, 512k)", ErrInvalidPlan, field, *rate)
+		}
+	}
+
+	if p.HasBurst() && (p.BurstTime == nil || *p.BurstTime <= 0) {
+		return fmt.Errorf("%w: burst_time is required when burst rates are set", ErrInvalidPlan)
+	}
+	if p.Price < 0 {
+		return fmt.Errorf("%w: price cannot be negative", ErrInvalidPlan)
+	}
+
+	return nil
+}
+
+// HasBurst reports whether burst rates are configured
+func (p *Plan) HasBurst() bool {
+	return p.BurstDownloadRate != nil && *p.BurstDownloadRate != "" &&
+		p.BurstUploadRate != nil && *p.BurstUploadRate != ""
+}
+
+// RateLimit builds the RouterOS rate-limit string for the plan's /ppp/profile.
+// RouterOS reads it from the router's point of view: rx (upload) first, then tx (download).
+func (p *Plan) RateLimit() string {
+	rateLimit := p.UploadRate + "/" + p.DownloadRate
+	if !p.HasBurst() {
+		return rateLimit
+	}
+
+	threshold := p.UploadRate + "/" + p.DownloadRate
+	if p.BurstThresholdUpload != nil && p.BurstThresholdDownload != nil &&
+		*p.BurstThresholdUpload != "" && *p.BurstThresholdDownload != "" {
+		threshold = *p.BurstThresholdUpload + "/" + *p.BurstThresholdDownload
+	}
+
+	return fmt.Sprintf("%s %s/%s %s %d/%d",
+		rateLimit,
+		*p.BurstUploadRate, *p.BurstDownloadRate,
+		threshold,
+		*p.BurstTime, *p.BurstTime,
+	)
+}
@@ -0,0 +1,46 @@
+package domain
+
+import "time"
+
+// Reachability states of a probed customer
+const (
+	ReachabilityReachable   = "reachable"
+	ReachabilityDegraded    = "degraded" // replies, but with loss or latency above the limits
+	ReachabilityUnreachable = "unreachable"
+)
+
+// CustomerProbe is one background ping of a customer's address
+type CustomerProbe struct {
+	ID         int64     `json:"-" gorm:"primaryKey"`
+	CustomerID string    `json:"customer_id" gorm:"column:customer_id"`
+	IPAddress  string    `json:"ip_address" gorm:"column:ip_address"`
+	Sent       int       `json:"sent" gorm:"column:sent"`
+	Received   int       `json:"received" gorm:"column:received"`
+	PacketLoss float64   `json:"packet_loss" gorm:"column:packet_loss"` // percent
+	MinRttMs   float64   `json:"min_rtt_ms" gorm:"column:min_rtt_ms"`
+	AvgRttMs   float64   `json:"avg_rtt_ms" gorm:"column:avg_rtt_ms"`
+	MaxRttMs   float64   `json:"max_rtt_ms" gorm:"column:max_rtt_ms"`
+	Status     string    `json:"status" gorm:"column:status"`
+	ProbedAt   time.Time `json:"probed_at" gorm:"column:probed_at"`
+}
+
+// TableName overrides the table name
+func (CustomerProbe) TableName() string {
+	return "customer_probe_history"
+}
+
+// CustomerReachability is the latest probe state of an online customer
+type CustomerReachability struct {
+	CustomerID   string        `json:"customer_id"`
+	CustomerName string        `json:"customer_name"`
+	Status       string        `json:"status"`
+	Since        time.Time     `json:"since"` // when the status last changed
+	LastProbe    CustomerProbe `json:"last_probe"`
+}
+
+// ProbeRepository defines database operations for probe history
+type ProbeRepository interface {
+	SaveProbe(p *CustomerProbe) error
+	ListProbes(customerID string, from, to time.Time) ([]*CustomerProbe, error)
+	PruneProbes(before time.Time) (int64, error)
+}
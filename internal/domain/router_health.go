@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRouterNotFound is returned for router IDs this collector does not poll
+var ErrRouterNotFound = errors.New("router not found")
+
+// RouterHealthSample is one poll of a router's resources, kept as history
+type RouterHealthSample struct {
+	ID                int64     `json:"-" gorm:"primaryKey"`
+	RouterID          string    `json:"router_id" gorm:"column:router_id"`
+	Reachable         bool      `json:"reachable" gorm:"column:reachable"`
+	CPULoad           int       `json:"cpu_load" gorm:"column:cpu_load"` // percent
+	MemoryUsedPercent float64   `json:"memory_used_percent" gorm:"column:memory_used_percent"`
+	FreeMemory        int64     `json:"free_memory" gorm:"column:free_memory"` // bytes
+	TotalMemory       int64     `json:"total_memory" gorm:"column:total_memory"`
+	UptimeSeconds     int64     `json:"uptime_seconds" gorm:"column:uptime_seconds"`
+	Version           string    `json:"version" gorm:"column:version"`
+	Temperature       *float64  `json:"temperature" gorm:"column:temperature"` // °C, nil without a sensor
+	Voltage           *float64  `json:"voltage" gorm:"column:voltage"`         // V, nil without a sensor
+	PPPActive         int       `json:"ppp_active" gorm:"column:ppp_active"`
+	InterfaceErrors   int64     `json:"interface_errors" gorm:"column:interface_errors"` // errors + drops since the previous sample
+	SampledAt         time.Time `json:"sampled_at" gorm:"column:sampled_at"`
+}
+
+// TableName overrides the table name
+func (RouterHealthSample) TableName() string {
+	return "router_health_history"
+}
+
+// InterfaceErrorCount is the cumulative error and drop count of one interface
+type InterfaceErrorCount struct {
+	Name     string `json:"name"`
+	RxErrors uint64 `json:"rx_errors"`
+	TxErrors uint64 `json:"tx_errors"`
+	RxDrops  uint64 `json:"rx_drops"`
+	TxDrops  uint64 `json:"tx_drops"`
+}
+
+// RouterHealth is the latest health state of a router
+type RouterHealth struct {
+	RouterHealthSample
+	Uptime       string                `json:"uptime"`
+	BoardName    string                `json:"board_name"`
+	Architecture string                `json:"architecture"`
+	CPUCount     int                   `json:"cpu_count"`
+	Sensors      map[string]string     `json:"sensors"`    // every /system/health value by name
+	Interfaces   []InterfaceErrorCount `json:"interfaces"` // interfaces with non-zero error or drop counters
+	LastError    string                `json:"last_error,omitempty"`
+}
+
+// RouterHealthRepository defines database operations for router health history
+type RouterHealthRepository interface {
+	SaveHealthSample(s *RouterHealthSample) error
+	ListHealthSamples(routerID string, from, to time.Time) ([]*RouterHealthSample, error)
+	PruneHealthSamples(before time.Time) (int64, error)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "strings"
+
+// TopicAlerts carries alert state changes of every rule
+const TopicAlerts = "alerts"
+
+// CustomerTrafficTopic carries a monitored customer's traffic events
+func CustomerTrafficTopic(customerID string) string {
+	return "customer:" + customerID + ":traffic"
+}
+
+// CustomerOfTrafficTopic returns the customer ID of a customer traffic topic
+func CustomerOfTrafficTopic(topic string) (string, bool) {
+	parts := strings.Split(topic, ":")
+	if len(parts) != 3 || parts[0] != "customer" || parts[1] == "" || parts[2] != "traffic" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// RouterEventsTopic carries PPPoE, reachability and interface events of a router
+func RouterEventsTopic(routerID string) string {
+	return "router:" + routerID + ":events"
+}
+
+// ValidTopic reports whether topic is one of the topics clients can subscribe to
+func ValidTopic(topic string) bool {
+	if topic == TopicAlerts {
+		return true
+	}
+
+	parts := strings.Split(topic, ":")
+	if len(parts) != 3 || parts[1] == "" {
+		return false
+	}
+	return (parts[0] == "customer" && parts[2] == "traffic") ||
+		(parts[0] == "router" && parts[2] == "events")
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrMonitorLimitReached is returned when a traffic monitor request exceeds a concurrency limit
+var ErrMonitorLimitReached = errors.New("max concurrent monitors reached")
+
+// Traffic payload schema versions. v1 carries every counter as a string and is
+// kept for existing frontends; v2 carries numeric counters plus fast-path, drop and error rates.
+const (
+	TrafficSchemaV1 = 1
+	TrafficSchemaV2 = 2
+)
+
+// CustomerTrafficDataV1 is the legacy string-based traffic payload
+type CustomerTrafficDataV1 struct {
+	CustomerID         string    `json:"customer_id"`
+	CustomerName       string    `json:"customer_name"`
+	Username           string    `json:"username"`
+	ServiceType        string    `json:"service_type"`
+	InterfaceName      string    `json:"interface_name"`
+	RxBitsPerSecond    string    `json:"rx_bits_per_second"`
+	TxBitsPerSecond    string    `json:"tx_bits_per_second"`
+	RxPacketsPerSecond string    `json:"rx_packets_per_second"`
+	TxPacketsPerSecond string    `json:"tx_packets_per_second"`
+	DownloadSpeed      string    `json:"download_speed"`
+	UploadSpeed        string    `json:"upload_speed"`
+	Timestamp          time.Time `json:"timestamp"`
+}
+
+// V1 converts the data to the legacy schema. It keeps the original speed
+// mapping (rx as download) that existing frontends and stream consumers expect;
+// only v2 reports tx, the customer's download on <pppoe-…>, as download_speed.
+func (d CustomerTrafficData) V1() CustomerTrafficDataV1 {
+	return CustomerTrafficDataV1{
+		CustomerID:         d.CustomerID,
+		CustomerName:       d.CustomerName,
+		Username:           d.Username,
+		ServiceType:        d.ServiceType,
+		InterfaceName:      d.InterfaceName,
+		RxBitsPerSecond:    strconv.FormatUint(d.RxBitsPerSecond, 10),
+		TxBitsPerSecond:    strconv.FormatUint(d.TxBitsPerSecond, 10),
+		RxPacketsPerSecond: strconv.FormatUint(d.RxPacketsPerSecond, 10),
+		TxPacketsPerSecond: strconv.FormatUint(d.TxPacketsPerSecond, 10),
+		DownloadSpeed:      FormatBitRate(d.RxBitsPerSecond),
+		UploadSpeed:        FormatBitRate(d.TxBitsPerSecond),
+		Timestamp:          d.Timestamp,
+	}
+}
+
+// Versioned returns the payload in the requested schema (v1 for unknown versions)
+func (d CustomerTrafficData) Versioned(schema int) interface{} {
+	if schema == TrafficSchemaV2 {
+		return d
+	}
+	return d.V1()
+}
+
+// Traffic stream event types
+const (
+	TrafficEventUpdate      = "traffic_update"
+	TrafficEventSessionDown = "session_down"
+	TrafficEventSessionUp   = "session_up"
+
+	// TrafficEventHandover is only exchanged between replicas: the owner stopped
+	// monitoring and another replica with clients should take the lease
+	TrafficEventHandover = "handover"
+)
+
+// TrafficEvent is delivered to traffic stream observers: a traffic sample or a
+// PPPoE session status change of the monitored customer
+type TrafficEvent struct {
+	Type          string               `json:"type"`
+	CustomerID    string               `json:"customer_id"`
+	InterfaceName string               `json:"interface_name"`
+	Traffic       *CustomerTrafficData `json:"traffic,omitempty"` // set for TrafficEventUpdate
+	Timestamp     time.Time            `json:"timestamp"`
+}
+
+// MonitorCoordinator shares customer traffic monitors between collector
+// replicas. The replica holding a customer's lease runs the router monitor and
+// publishes its events to the customer's traffic topic; the others follow it.
+type MonitorCoordinator interface {
+	// InstanceID identifies this replica
+	InstanceID() string
+
+	// AcquireMonitor takes the customer's lease for token if nobody holds it
+	AcquireMonitor(customerID, token string, ttl time.Duration) (bool, error)
+	// RenewMonitor extends the lease; false means token no longer holds it
+	RenewMonitor(customerID, token string, ttl time.Duration) (bool, error)
+	// ReleaseMonitor drops the lease if token still holds it
+	ReleaseMonitor(customerID, token string) error
+
+	// SubscribeEvents follows the customer's traffic topic from now until ctx is done
+	SubscribeEvents(ctx context.Context, customerID string) <-chan TrafficEvent
+}
+
+// TrafficHistory returns a customer's recently published traffic samples
+type TrafficHistory interface {
+	// RecentTraffic returns up to limit samples taken since since, oldest first
+	RecentTraffic(customerID string, since time.Time, limit int) ([]CustomerTrafficData, error)
+}
+
+// FormatBitRate formats a bits-per-second value with SI units (e.g. "12.5 Mbps")
+func FormatBitRate(bps uint64) string {
+	switch {
+	case bps >= 1_000_000_000:
+		return fmt.Sprintf("%.2f Gbps", float64(bps)/1e9)
+	case bps >= 1_000_000:
+		return fmt.Sprintf("%.2f Mbps", float64(bps)/1e6)
+	case bps >= 1_000:
+		return fmt.Sprintf("%.1f kbps", float64(bps)/1e3)
+	default:
+		return fmt.Sprintf("%d bps", bps)
+	}
+}
+
+// TrafficRate is a bits-per-second pair with display strings
+type TrafficRate struct {
+	RxBitsPerSecond uint64 `json:"rx_bits_per_second"`
+	TxBitsPerSecond uint64 `json:"tx_bits_per_second"`
+	DownloadSpeed   string `json:"download_speed"`
+	UploadSpeed     string `json:"upload_speed"`
+}
+
+// NewTrafficRate builds a TrafficRate with formatted speeds for an uplink,
+// where rx is what the network downloads
+func NewTrafficRate(rx, tx uint64) TrafficRate {
+	return TrafficRate{
+		RxBitsPerSecond: rx,
+		TxBitsPerSecond: tx,
+		DownloadSpeed:   FormatBitRate(rx),
+		UploadSpeed:     FormatBitRate(tx),
+	}
+}
+
+// NewSessionTrafficRate builds a TrafficRate for customer sessions, where rx on
+// the <pppoe-…> interface is the customer's upload and tx their download
+func NewSessionTrafficRate(rx, tx uint64) TrafficRate {
+	return TrafficRate{
+		RxBitsPerSecond: rx,
+		TxBitsPerSecond: tx,
+		DownloadSpeed:   FormatBitRate(tx),
+		UploadSpeed:     FormatBitRate(rx),
+	}
+}
+
+// InterfaceRate is the current rate of one router interface (e.g. an uplink)
+type InterfaceRate struct {
+	InterfaceName string `json:"interface_name"`
+	TrafficRate
+}
+
+// ProfileRate sums the sessions of one PPP profile
+type ProfileRate struct {
+	Profile  string `json:"profile"`
+	Sessions int    `json:"sessions"`
+	TrafficRate
+}
+
+// TalkerRate is the current rate of one customer session
+type TalkerRate struct {
+	CustomerID    string `json:"customer_id"`
+	CustomerName  string `json:"customer_name"`
+	Username      string `json:"username"`
+	Profile       string `json:"profile"`
+	InterfaceName string `json:"interface_name"`
+	TrafficRate
+}
+
+// TrafficSnapshot is a router-wide traffic overview taken at one point in time
+type TrafficSnapshot struct {
+	RouterID  string          `json:"router_id"`
+	Sessions  int             `json:"sessions"`
+	Total     TrafficRate     `json:"total"` // sum over all PPPoE sessions
+	Uplinks   []InterfaceRate `json:"uplinks"`
+	Profiles  []ProfileRate   `json:"profiles"`
+	TopRx     []TalkerRate    `json:"top_rx"`
+	TopTx     []TalkerRate    `json:"top_tx"`
+	Timestamp time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidVoucherBatch wraps validation failures on voucher batch input
+var ErrInvalidVoucherBatch = errors.New("invalid voucher batch")
+
+// ErrVoucherBatchNotFound and ErrVoucherNotFound are returned for unknown batches and codes
+var (
+	ErrVoucherBatchNotFound = errors.New("voucher batch not found")
+	ErrVoucherNotFound      = errors.New("voucher not found")
+)
+
+// ErrVoucherJobNotFound is returned for unknown or expired generation jobs
+var ErrVoucherJobNotFound = errors.New("voucher job not found")
+
+// Voucher statuses
+const (
+	VoucherUnused    = "unused"
+	VoucherActive    = "active"
+	VoucherExpired   = "expired"
+	VoucherExhausted = "exhausted"
+)
+
+// Voucher job statuses
+const (
+	VoucherJobRunning = "running"
+	VoucherJobDone    = "done"
+	VoucherJobFailed  = "failed"
+)
+
+// VoucherJob tracks a batch being generated in the background. Jobs live in
+// memory only; the batch itself is stored once every voucher exists on the router.
+type VoucherJob struct {
+	ID         string     `json:"id"` // same as the batch ID
+	BatchName  string     `json:"batch_name"`
+	Status     string     `json:"status"`
+	Created    int        `json:"created"` // hotspot users created so far
+	Count      int        `json:"count"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// VoucherBatch is a set of hotspot vouchers generated together
+type VoucherBatch struct {
+	ID              string    `json:"id" gorm:"primaryKey"`
+	RouterID        string    `json:"router_id" gorm:"column:router_id"`
+	Name            string    `json:"name" gorm:"column:name"`
+	Profile         string    `json:"profile" gorm:"column:profile"`
+	Price           float64   `json:"price" gorm:"column:price"`
+	PriceTag        *string   `json:"price_tag" gorm:"column:price_tag"`
+	ValiditySeconds int       `json:"validity_seconds" gorm:"column:validity_seconds"`
+	LimitUptime     *string   `json:"limit_uptime" gorm:"column:limit_uptime"`
+	LimitBytesTotal *int64    `json:"limit_bytes_total" gorm:"column:limit_bytes_total"`
+	Count           int       `json:"count" gorm:"column:count"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Voucher is a single hotspot login created as /ip/hotspot/user
+type Voucher struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	BatchID       string     `json:"batch_id" gorm:"column:batch_id"`
+	Code          string     `json:"code" gorm:"column:code"`
+	Password      string     `json:"password" gorm:"column:password"`
+	MikrotikID    string     `json:"mikrotik_id" gorm:"column:mikrotik_id"`
+	Status        string     `json:"status" gorm:"column:status"`
+	FirstUsedAt   *time.Time `json:"first_used_at" gorm:"column:first_used_at"`
+	ExpiresAt     *time.Time `json:"expires_at" gorm:"column:expires_at"`
+	BytesUsed     int64      `json:"bytes_used" gorm:"column:bytes_used"`
+	UptimeSeconds int64      `json:"uptime_seconds" gorm:"column:uptime_seconds"`
+	LastSyncedAt  *time.Time `json:"last_synced_at" gorm:"column:last_synced_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	// Computed from the batch quota (nil = unlimited)
+	RemainingBytes *int64 `json:"remaining_bytes" gorm:"-"`
+}
+
+// VoucherRepository defines database operations for vouchers
+type VoucherRepository interface {
+	CreateBatch(batch *VoucherBatch, vouchers []*Voucher) error
+	DeleteBatch(id string) error
+	GetBatch(id string) (*VoucherBatch, error)
+	ListBatches() ([]*VoucherBatch, error)
+
+	ListVouchers(batchID string) ([]*Voucher, error)
+	ListVouchersByStatus(statuses ...string) ([]*Voucher, error)
+	GetVoucherByCode(code string) (*Voucher, error)
+	UpdateVoucher(voucher *Voucher) error
+}
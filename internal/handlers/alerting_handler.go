@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AlertingHandler manages alert rules, notifiers, silences and alerts
+type AlertingHandler struct {
+	service *services.AlertService
+}
+
+// NewAlertingHandler creates a new alerting handler
+func NewAlertingHandler(service *services.AlertService) *AlertingHandler {
+	return &AlertingHandler{
+		service: service,
+	}
+}
+
+// AlertRuleRequest represents payload for creating or updating an alert rule
+type AlertRuleRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Metric        string   `json:"metric" binding:"required"`
+	Operator      string   `json:"operator" binding:"required"`
+	Threshold     float64  `json:"threshold"`
+	ForSeconds    int      `json:"for_seconds"`
+	Subject       *string  `json:"subject"`
+	Severity      string   `json:"severity"`
+	NotifierIDs   []string `json:"notifier_ids"`
+	RepeatMinutes int      `json:"repeat_minutes"`
+	Enabled       *bool    `json:"enabled"` // default true
+}
+
+func (r *AlertRuleRequest) toRule(id string) *domain.AlertRule {
+	rule := &domain.AlertRule{
+		ID:            id,
+		Name:          r.Name,
+		Metric:        r.Metric,
+		Operator:      r.Operator,
+		Threshold:     r.Threshold,
+		ForSeconds:    r.ForSeconds,
+		Subject:       r.Subject,
+		Severity:      r.Severity,
+		NotifierIDs:   r.NotifierIDs,
+		RepeatMinutes: r.RepeatMinutes,
+		Enabled:       true,
+	}
+	if r.Subject != nil && *r.Subject == "" {
+		rule.Subject = nil
+	}
+	if r.Enabled != nil {
+		rule.Enabled = *r.Enabled
+	}
+	return rule
+}
+
+// NotifierRequest represents payload for creating or updating a notifier
+type NotifierRequest struct {
+	Name    string            `json:"name" binding:"required"`
+	Type    string            `json:"type" binding:"required"`
+	Config  map[string]string `json:"config"`
+	Enabled *bool             `json:"enabled"` // default true
+}
+
+func (r *NotifierRequest) toNotifier(id string) *domain.Notifier {
+	n := &domain.Notifier{
+		ID:      id,
+		Name:    r.Name,
+		Type:    r.Type,
+		Config:  r.Config,
+		Enabled: true,
+	}
+	if r.Enabled != nil {
+		n.Enabled = *r.Enabled
+	}
+	return n
+}
+
+// SilenceRequest represents payload for creating a silence
+type SilenceRequest struct {
+	RuleID    *string    `json:"rule_id"` // empty = every rule
+	Subject   *string    `json:"subject"` // empty = every subject
+	StartsAt  *time.Time `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+	Duration  string     `json:"duration"` // alternative to ends_at, e.g. "2h"
+	Comment   *string    `json:"comment"`
+	CreatedBy *string    `json:"created_by"`
+}
+
+// ListRules returns all alert rules
+// GET /api/alerting/rules
+func (h *AlertingHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules()
+	if err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": rules})
+}
+
+// GetRule returns one alert rule
+// GET /api/alerting/rules/:id
+func (h *AlertingHandler) GetRule(c *gin.Context) {
+	rule, err := h.service.GetRule(c.Param("id"))
+	if err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": rule})
+}
+
+// CreateRule creates an alert rule
+// POST /api/alerting/rules
+func (h *AlertingHandler) CreateRule(c *gin.Context) {
+	var req AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	rule := req.toRule(uuid.New().String())
+	if err := h.service.CreateRule(rule); err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"status": "success", "data": rule})
+}
+
+// UpdateRule updates an alert rule
+// PUT /api/alerting/rules/:id
+func (h *AlertingHandler) UpdateRule(c *gin.Context) {
+	var req AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	rule := req.toRule(c.Param("id"))
+	if err := h.service.UpdateRule(rule); err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": rule})
+}
+
+// DeleteRule deletes an alert rule
+// DELETE /api/alerting/rules/:id
+func (h *AlertingHandler) DeleteRule(c *gin.Context) {
+	if err := h.service.DeleteRule(c.Param("id")); err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+// ListNotifiers returns all notifiers with secrets masked
+// GET /api/alerting/notifiers
+func (h *AlertingHandler) ListNotifiers(c *gin.Context) {
+	list, err := h.service.ListNotifiers()
+	if err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": list})
+}
+
+// GetNotifier returns one notifier with secrets masked
+// GET /api/alerting/notifiers/:id
+func (h *AlertingHandler) GetNotifier(c *gin.Context) {
+	n, err := h.service.GetNotifier(c.Param("id"))
+	if err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": n})
+}
+
+// CreateNotifier creates a notifier
+// POST /api/alerting/notifiers
+func (h *AlertingHandler) CreateNotifier(c *gin.Context) {
+	var req NotifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n := req.toNotifier(uuid.New().String())
+	if err := h.service.CreateNotifier(n); err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"status": "success", "data": n.Masked()})
+}
+
+// UpdateNotifier updates a notifier; masked secrets are kept as stored
+// PUT /api/alerting/notifiers/:id
+func (h *AlertingHandler) UpdateNotifier(c *gin.Context) {
+	var req NotifierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	n := req.toNotifier(c.Param("id"))
+	if err := h.service.UpdateNotifier(n); err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": n.Masked()})
+}
+
+// DeleteNotifier deletes a notifier
+// DELETE /api/alerting/notifiers/:id
+func (h *AlertingHandler) DeleteNotifier(c *gin.Context) {
+	if err := h.service.DeleteNotifier(c.Param("id")); err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+// TestNotifier sends a test message through a notifier
+// POST /api/alerting/notifiers/:id/test
+func (h *AlertingHandler) TestNotifier(c *gin.Context) {
+	if err := h.service.TestNotifier(c.Param("id")); err != nil {
+		if errors.Is(err, domain.ErrNotifierNotFound) {
+			writeAlertingError(c, err)
+			return
+		}
+		c.JSON(502, gin.H{"status": "error", "message": "delivery failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "message": "Test message sent"})
+}
+
+// ListAlerts returns alerts newest first
+// GET /api/alerting/alerts?status=firing|resolved&limit=100
+func (h *AlertingHandler) ListAlerts(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != domain.AlertStatusFiring && status != domain.AlertStatusResolved {
+		c.JSON(400, gin.H{"status": "error", "message": "status must be firing or resolved"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(400, gin.H{"status": "error", "message": "limit must be between 1 and 1000"})
+		return
+	}
+
+	alerts, err := h.service.ListAlerts(status, limit)
+	if err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": alerts})
+}
+
+// ListSilences returns silences that have not ended (all with ?all=true)
+// GET /api/alerting/silences
+func (h *AlertingHandler) ListSilences(c *gin.Context) {
+	silences, err := h.service.ListSilences(c.Query("all") == "true")
+	if err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": silences})
+}
+
+// CreateSilence silences notifications for a rule and/or subject
+// POST /api/alerting/silences
+func (h *AlertingHandler) CreateSilence(c *gin.Context) {
+	var req SilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	silence := &domain.Silence{
+		ID:        uuid.New().String(),
+		RuleID:    emptyToNil(req.RuleID),
+		Subject:   emptyToNil(req.Subject),
+		Comment:   req.Comment,
+		CreatedBy: req.CreatedBy,
+	}
+	if req.StartsAt != nil {
+		silence.StartsAt = *req.StartsAt
+	} else {
+		silence.StartsAt = time.Now()
+	}
+
+	switch {
+	case req.EndsAt != nil:
+		silence.EndsAt = *req.EndsAt
+	case req.Duration != "":
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil {
+			c.JSON(400, gin.H{"status": "error", "message": "invalid duration: " + err.Error()})
+			return
+		}
+		silence.EndsAt = silence.StartsAt.Add(d)
+	default:
+		c.JSON(400, gin.H{"status": "error", "message": "ends_at or duration is required"})
+		return
+	}
+
+	if err := h.service.CreateSilence(silence); err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"status": "success", "data": silence})
+}
+
+// DeleteSilence ends a silence early
+// DELETE /api/alerting/silences/:id
+func (h *AlertingHandler) DeleteSilence(c *gin.Context) {
+	if err := h.service.DeleteSilence(c.Param("id")); err != nil {
+		writeAlertingError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
+
+func writeAlertingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidAlertRule),
+		errors.Is(err, domain.ErrInvalidNotifier),
+		errors.Is(err, domain.ErrInvalidSilence):
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrAlertRuleNotFound),
+		errors.Is(err, domain.ErrNotifierNotFound),
+		errors.Is(err, domain.ErrSilenceNotFound):
+		c.JSON(404, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+	}
+}
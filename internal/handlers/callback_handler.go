@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"mikrotik-collector/internal/application/services"
 	"mikrotik-collector/internal/domain"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +14,25 @@ import (
 type CallbackHandler struct {
 	repo      domain.CustomerRepository
 	publisher domain.RedisPublisher
+	traffic   *services.OnDemandTrafficService // running monitors follow session changes
+	alerts    *services.AlertService
+	routerID  string // router the PPPoE callbacks come from
 }
 
 // NewCallbackHandler creates a new callback handler
-func NewCallbackHandler(repo domain.CustomerRepository, publisher domain.RedisPublisher) *CallbackHandler {
+func NewCallbackHandler(
+	repo domain.CustomerRepository,
+	publisher domain.RedisPublisher,
+	traffic *services.OnDemandTrafficService,
+	alerts *services.AlertService,
+	routerID string,
+) *CallbackHandler {
 	return &CallbackHandler{
 		repo:      repo,
 		publisher: publisher,
+		traffic:   traffic,
+		alerts:    alerts,
+		routerID:  routerID,
 	}
 }
 
@@ -60,11 +73,15 @@ func (h *CallbackHandler) HandlePPPoEUp(c *gin.Context) {
 
 	log.Printf("Callback: Customer %s (%s) is now ONLINE", targetCustomer.Name, req.User)
 
+	// Let a running traffic monitor pick up the new interface right away
+	h.traffic.NotifySessionUp(targetCustomer.ID)
+	h.alerts.Observe(domain.MetricCustomerOffline, targetCustomer.ID, targetCustomer.Name, 0)
+
 	// Publish event to Redis
 	eventData := fmt.Sprintf(`{"type":"pppoe_event","status":"connected","customer_id":"%s","name":"%s","ip":"%s","interface":"%s"}`,
 		targetCustomer.ID, targetCustomer.Name, req.IPAddress, req.Interface)
 
-	if err := h.publisher.Publish("mikrotik:events", eventData); err != nil {
+	if err := h.publisher.PublishEvent(eventData, domain.RouterEventsTopic(h.routerID)); err != nil {
 		log.Printf("[WARN] Failed to publish Redis event: %v", err)
 	}
 
@@ -105,11 +122,14 @@ func (h *CallbackHandler) HandlePPPoEDown(c *gin.Context) {
 
 	log.Printf("Callback: Customer %s (%s) is now OFFLINE", targetCustomer.Name, req.User)
 
+	h.traffic.NotifySessionDown(targetCustomer.ID)
+	h.alerts.Observe(domain.MetricCustomerOffline, targetCustomer.ID, targetCustomer.Name, 1)
+
 	// Publish event to Redis
 	eventData := fmt.Sprintf(`{"type":"pppoe_event","status":"disconnected","customer_id":"%s","name":"%s"}`,
 		targetCustomer.ID, targetCustomer.Name)
 
-	if err := h.publisher.Publish("mikrotik:events", eventData); err != nil {
+	if err := h.publisher.PublishEvent(eventData, domain.RouterEventsTopic(h.routerID)); err != nil {
 		log.Printf("[WARN] Failed to publish Redis event: %v", err)
 	}
 
@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"mikrotik-collector/internal/application/services"
 	"mikrotik-collector/internal/domain"
@@ -32,6 +37,14 @@ type CreateCustomerRequest struct {
 	PPPoEUsername *string `json:"pppoe_username"`
 	PPPoEPassword *string `json:"pppoe_password"`
 	PPPoEProfile  *string `json:"pppoe_profile"`
+	PlanID        *string `json:"plan_id"` // overrides pppoe_profile with the plan's profile
+
+	HotspotUsername *string `json:"hotspot_username"`
+	HotspotPassword *string `json:"hotspot_password"`
+	HotspotMacAddr  *string `json:"hotspot_mac_addr"` // binds the hotspot user to this MAC
+
+	StaticIP     *string `json:"static_ip"`      // reserved through IPAM
+	IPAMSubnetID *string `json:"ipam_subnet_id"` // allocate the first free address of this subnet
 
 	Phone *string `json:"phone"`
 	Email *string `json:"email"`
@@ -56,14 +69,26 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 		PPPoEUsername: req.PPPoEUsername,
 		PPPoEPassword: req.PPPoEPassword,
 		PPPoEProfile:  req.PPPoEProfile,
-		Phone:         req.Phone,
-		Email:         req.Email,
-		Status:        "active", // Default status
+		PlanID:        req.PlanID,
+		StaticIP:      req.StaticIP,
+
+		HotspotUsername: req.HotspotUsername,
+		HotspotPassword: req.HotspotPassword,
+		HotspotMacAddr:  req.HotspotMacAddr,
+
+		Phone:  req.Phone,
+		Email:  req.Email,
+		Status: "active", // Default status
+	}
+
+	subnetID := ""
+	if req.IPAMSubnetID != nil {
+		subnetID = *req.IPAMSubnetID
 	}
 
-	if err := h.service.CreateCustomer(customer); err != nil {
+	if err := h.service.CreateCustomer(customer, subnetID); err != nil {
 		log.Printf("Failed to create customer: %v", err)
-		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+		writeCustomerError(c, err)
 		return
 	}
 
@@ -94,8 +119,13 @@ func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
 		PPPoEUsername: req.PPPoEUsername,
 		PPPoEPassword: req.PPPoEPassword,
 		PPPoEProfile:  req.PPPoEProfile,
+		PlanID:        req.PlanID,
 		Phone:         req.Phone,
 		Email:         req.Email,
+
+		HotspotUsername: req.HotspotUsername,
+		HotspotPassword: req.HotspotPassword,
+		HotspotMacAddr:  req.HotspotMacAddr,
 	}
 
 	if err := h.service.UpdateCustomer(customer); err != nil {
@@ -106,6 +136,174 @@ func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "success"})
 }
 
+// patchableCustomerFields maps JSON merge-patch keys to customer columns.
+// The bool marks fields that may be cleared with null.
+var patchableCustomerFields = map[string]struct {
+	column   string
+	nullable bool
+}{
+	"name":             {"name", false},
+	"username":         {"username", false},
+	"service_type":     {"service_type", false},
+	"status":           {"status", false},
+	"phone":            {"phone", true},
+	"email":            {"email", true},
+	"pppoe_username":   {"pppoe_username", true},
+	"pppoe_password":   {"pppoe_password", true},
+	"pppoe_profile":    {"pppoe_profile", true},
+	"hotspot_username": {"hotspot_username", true},
+	"hotspot_password": {"hotspot_password", true},
+	"hotspot_mac_addr": {"hotspot_mac_address", true},
+	"static_ip":        {"static_ip", true},
+	"plan_id":          {"plan_id", true},
+}
+
+// PatchCustomer handles partial customer updates (JSON Merge Patch, RFC 7396)
+// PATCH /api/customers/:id
+//
+// Absent fields are left untouched, null clears a field. Send If-Match with the
+// ETag from GET to reject the patch when the customer changed in between.
+func (h *CustomerHandler) PatchCustomer(c *gin.Context) {
+	id := c.Param("id")
+
+	var body map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": "body must be a JSON object: " + err.Error()})
+		return
+	}
+
+	patch, err := buildCustomerPatch(body)
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	expectedUpdatedAt, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	customer, err := h.service.PatchCustomer(id, patch, expectedUpdatedAt)
+	if err != nil {
+		writeCustomerError(c, err)
+		return
+	}
+
+	c.Header("ETag", customerETag(customer))
+	c.JSON(200, gin.H{"status": "success", "data": customer})
+}
+
+// writeCustomerError maps service errors to HTTP status codes
+func writeCustomerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCustomerModified):
+		c.JSON(412, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrInvalidCustomer), errors.Is(err, domain.ErrInvalidSubnet):
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrAddressConflict), errors.Is(err, domain.ErrSubnetExhausted),
+		errors.Is(err, domain.ErrNoActiveSession):
+		c.JSON(409, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrCustomerNotFound):
+		c.JSON(404, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+	}
+}
+
+// AssignStaticIPRequest represents payload for reserving a static address
+type AssignStaticIPRequest struct {
+	SubnetID string `json:"subnet_id"` // pick the first free address of this subnet
+	Address  string `json:"address"`   // or reserve this exact address
+}
+
+// AssignStaticIP reserves a static address via IPAM and sets it as remote-address
+// POST /api/customers/:id/static-ip
+func (h *CustomerHandler) AssignStaticIP(c *gin.Context) {
+	var req AssignStaticIPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if req.SubnetID == "" && req.Address == "" {
+		c.JSON(400, gin.H{"status": "error", "message": "subnet_id or address is required"})
+		return
+	}
+
+	customer, err := h.service.AssignStaticIP(c.Param("id"), req.SubnetID, req.Address)
+	if err != nil {
+		writeCustomerError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": customer})
+}
+
+// ReleaseStaticIP releases the customer's static address
+// DELETE /api/customers/:id/static-ip
+func (h *CustomerHandler) ReleaseStaticIP(c *gin.Context) {
+	customer, err := h.service.ReleaseStaticIP(c.Param("id"))
+	if err != nil {
+		writeCustomerError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": customer})
+}
+
+// buildCustomerPatch converts a merge-patch document into a column patch
+func buildCustomerPatch(body map[string]json.RawMessage) (domain.CustomerPatch, error) {
+	patch := make(domain.CustomerPatch, len(body))
+
+	for key, raw := range body {
+		field, ok := patchableCustomerFields[key]
+		if !ok {
+			return nil, fmt.Errorf("field %q cannot be patched", key)
+		}
+
+		if string(raw) == "null" {
+			if !field.nullable {
+				return nil, fmt.Errorf("field %q cannot be null", key)
+			}
+			patch[field.column] = nil
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("field %q must be a string or null", key)
+		}
+		if !field.nullable && value == "" {
+			return nil, fmt.Errorf("field %q cannot be empty", key)
+		}
+		patch[field.column] = &value
+	}
+
+	return patch, nil
+}
+
+// customerETag derives a strong ETag from the customer's updated_at
+func customerETag(customer *domain.Customer) string {
+	return fmt.Sprintf(`"%d"`, customer.UpdatedAt.UnixMicro())
+}
+
+// parseIfMatch turns an If-Match header into the expected updated_at (nil when absent or "*")
+func parseIfMatch(header string) (*time.Time, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	micros, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header: %s", header)
+	}
+
+	t := time.UnixMicro(micros)
+	return &t, nil
+}
+
 // DeleteCustomer handles customer deletion
 // DELETE /api/customers/:id
 func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
@@ -130,6 +328,7 @@ func (h *CustomerHandler) GetCustomer(c *gin.Context) {
 		return
 	}
 
+	c.Header("ETag", customerETag(customer))
 	c.JSON(200, gin.H{"status": "success", "data": customer})
 }
 
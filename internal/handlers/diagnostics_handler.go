@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// defaultBtestDuration is used when a bandwidth test does not specify one
+const defaultBtestDuration = 10 * time.Second
+
+// DiagnosticsHandler streams traceroute, torch and bandwidth-test runs for a customer over WebSocket
+type DiagnosticsHandler struct {
+	client        *mikrotik.Client
+	repo          domain.CustomerRepository
+	sessions      *services.SessionService
+	btestUser     string // default credentials of the btest server on the CPE
+	btestPassword string
+}
+
+// NewDiagnosticsHandler creates a new diagnostics handler
+func NewDiagnosticsHandler(
+	client *mikrotik.Client,
+	repo domain.CustomerRepository,
+	sessions *services.SessionService,
+	btestUser, btestPassword string,
+) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		client:        client,
+		repo:          repo,
+		sessions:      sessions,
+		btestUser:     btestUser,
+		btestPassword: btestPassword,
+	}
+}
+
+// TracerouteStream streams the hop table towards the customer after every round
+// GET /api/customers/:id/traceroute/ws?count=&max_hops=&timeout=&size=&src_address=&routing_table=
+func (h *DiagnosticsHandler) TracerouteStream(c *gin.Context) {
+	ws, ctx, cancel, err := openDiagnosticSocket(c)
+	if err != nil {
+		log.Printf("WS upgrade failed: %v", err)
+		return
+	}
+	defer ws.Close()
+	defer cancel()
+
+	customer, ok := h.getCustomer(ws, c.Param("id"))
+	if !ok {
+		return
+	}
+	ipAddress, err := resolveCustomerAddress(h.sessions, customer)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
+
+	opts, err := parseTracerouteOptions(c, ipAddress)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
+
+	stream, err := h.client.StreamTraceroute(ctx, opts)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
+
+	var last mikrotik.TracerouteRound
+	for round := range stream {
+		last = round
+		if err := ws.WriteJSON(map[string]interface{}{"type": "update", "data": round}); err != nil {
+			break
+		}
+	}
+
+	reached := false
+	if n := len(last.Hops); n > 0 {
+		reached = last.Hops[n-1].Address == ipAddress
+	}
+
+	ws.WriteJSON(map[string]interface{}{
+		"type": "summary",
+		"summary": map[string]interface{}{
+			"address": ipAddress,
+			"rounds":  last.Round,
+			"reached": reached,
+			"hops":    last.Hops,
+		},
+	})
+}
+
+// TorchStream streams the live flows on the customer's PPPoE interface
+// GET /api/customers/:id/torch/ws?src_address=&dst_address=&protocol=&port=
+func (h *DiagnosticsHandler) TorchStream(c *gin.Context) {
+	ws, ctx, cancel, err := openDiagnosticSocket(c)
+	if err != nil {
+		log.Printf("WS upgrade failed: %v", err)
+		return
+	}
+	defer ws.Close()
+	defer cancel()
+
+	customer, ok := h.getCustomer(ws, c.Param("id"))
+	if !ok {
+		return
+	}
+	if customer.ServiceType != "pppoe" || customer.PPPoEUsername == nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": "torch is only available for PPPoE customers"})
+		return
+	}
+
+	iface, err := h.client.FindActivePPPoEInterface(*customer.PPPoEUsername)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
+
+	opts := mikrotik.TorchOptions{
+		Interface:  iface,
+		SrcAddress: c.Query("src_address"),
+		DstAddress: c.Query("dst_address"),
+		Protocol:   c.Query("protocol"),
+		Port:       c.Query("port"),
+	}
+	stream, err := h.client.StreamTorch(ctx, opts)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
+
+	started := time.Now()
+	var samples int
+	var peakTx, peakRx uint64
+	for sample := range stream {
+		samples++
+		peakTx = max(peakTx, sample.TxBps)
+		peakRx = max(peakRx, sample.RxBps)
+		if err := ws.WriteJSON(map[string]interface{}{"type": "update", "data": sample}); err != nil {
+			break
+		}
+	}
+
+	ws.WriteJSON(map[string]interface{}{
+		"type": "summary",
+		"summary": map[string]interface{}{
+			"interface":   iface,
+			"samples":     samples,
+			"duration":    time.Since(started).Round(time.Second).String(),
+			"peak_tx_bps": peakTx,
+			"peak_rx_bps": peakRx,
+		},
+	})
+}
+
+// BandwidthTestStream runs a bandwidth test towards the customer's CPE and streams its progress
+// GET /api/customers/:id/btest/ws?direction=&protocol=&duration=&user=&password=&local_tx_speed=&remote_tx_speed=&connection_count=
+func (h *DiagnosticsHandler) BandwidthTestStream(c *gin.Context) {
+	ws, ctx, cancel, err := openDiagnosticSocket(c)
+	if err != nil {
+		log.Printf("WS upgrade failed: %v", err)
+		return
+	}
+	defer ws.Close()
+	defer cancel()
+
+	customer, ok := h.getCustomer(ws, c.Param("id"))
+	if !ok {
+		return
+	}
+	ipAddress, err := resolveCustomerAddress(h.sessions, customer)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
+
+	opts, err := h.parseBandwidthTestOptions(c, ipAddress)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
+
+	log.Printf("[Diagnostics] Bandwidth test to %s (%s), %s %s for %s",
+		customer.Name, ipAddress, opts.Protocol, opts.Direction, opts.Duration)
+
+	stream, err := h.client.StreamBandwidthTest(ctx, opts)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
+
+	var last mikrotik.BandwidthTestResult
+	for result := range stream {
+		last = result
+		if err := ws.WriteJSON(map[string]interface{}{"type": "update", "data": result}); err != nil {
+			break
+		}
+	}
+
+	ws.WriteJSON(map[string]interface{}{
+		"type": "summary",
+		"summary": map[string]interface{}{
+			"address":              ipAddress,
+			"status":               last.Status,
+			"duration":             last.Duration,
+			"tx_total_average_bps": last.TxAverageBps,
+			"rx_total_average_bps": last.RxAverageBps,
+			"lost_packets":         last.LostPackets,
+		},
+	})
+}
+
+// getCustomer loads the customer, reporting a missing one on the socket
+func (h *DiagnosticsHandler) getCustomer(ws *websocket.Conn, id string) (*domain.Customer, bool) {
+	customer, err := h.repo.GetCustomerByID(id)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": "Customer not found"})
+		return nil, false
+	}
+	return customer, true
+}
+
+// openDiagnosticSocket upgrades the request and returns a context that is
+// cancelled when the client closes the socket, which stops the router command
+func openDiagnosticSocket(c *gin.Context) (*websocket.Conn, context.Context, context.CancelFunc, error) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Handle close message from client to stop the command
+	ws.SetCloseHandler(func(code int, text string) error {
+		cancel()
+		return nil
+	})
+
+	// Start reading pump to handle close frames
+	go func() {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				cancel()
+				break
+			}
+		}
+	}()
+
+	return ws, ctx, cancel, nil
+}
+
+// parseTracerouteOptions reads count, max_hops, timeout, size, src_address
+// and routing_table from the query string and validates them
+func parseTracerouteOptions(c *gin.Context, address string) (mikrotik.TracerouteOptions, error) {
+	opts := mikrotik.TracerouteOptions{
+		Address:      address,
+		SrcAddress:   c.Query("src_address"),
+		RoutingTable: c.Query("routing_table"),
+	}
+
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"count", &opts.Count},
+		{"max_hops", &opts.MaxHops},
+		{"size", &opts.Size},
+	}
+	for _, p := range ints {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("%s must be an integer", p.name)
+		}
+		*p.value = n
+	}
+
+	if v := c.Query("timeout"); v != "" {
+		timeout, err := mikrotik.ParseDuration(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid timeout: %v", err)
+		}
+		opts.Timeout = timeout
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// parseBandwidthTestOptions reads the test parameters from the query string;
+// user and password fall back to the configured CPE credentials
+func (h *DiagnosticsHandler) parseBandwidthTestOptions(c *gin.Context, address string) (mikrotik.BandwidthTestOptions, error) {
+	opts := mikrotik.BandwidthTestOptions{
+		Address:   address,
+		Direction: c.DefaultQuery("direction", "both"),
+		Protocol:  c.DefaultQuery("protocol", "tcp"),
+		Duration:  defaultBtestDuration,
+		User:      c.DefaultQuery("user", h.btestUser),
+		Password:  c.DefaultQuery("password", h.btestPassword),
+	}
+
+	if v := c.Query("duration"); v != "" {
+		d, err := mikrotik.ParseDuration(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid duration: %v", err)
+		}
+		opts.Duration = d
+	}
+
+	speeds := []struct {
+		name  string
+		value *uint64
+	}{
+		{"local_tx_speed", &opts.LocalTxSpeed},
+		{"remote_tx_speed", &opts.RemoteTxSpeed},
+	}
+	for _, p := range speeds {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("%s must be a bit rate in bps", p.name)
+		}
+		*p.value = n
+	}
+
+	if v := c.Query("connection_count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("connection_count must be an integer")
+		}
+		opts.ConnectionCount = n
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"time"
+
+	"mikrotik-collector/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler serves liveness and readiness from the background dependency checks
+type HealthHandler struct {
+	service   *services.HealthService
+	wsHandler *WebSocketHandler
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(service *services.HealthService, wsHandler *WebSocketHandler) *HealthHandler {
+	return &HealthHandler{
+		service:   service,
+		wsHandler: wsHandler,
+	}
+}
+
+// Liveness reports whether the process is working. It does not depend on
+// MikroTik, Redis or the database; it only fails when the checks stopped running.
+// GET /healthz
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	code, status := 200, "alive"
+	if h.service.Stalled() {
+		code, status = 503, "stalled"
+	}
+
+	c.JSON(code, gin.H{
+		"status":    status,
+		"uptime":    h.service.Uptime().Round(time.Second).String(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// Readiness reports whether traffic should be sent to this instance: 503 while a
+// required dependency is down, 200 "degraded" while only an optional one is
+// GET /readyz
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	code, status := h.readiness()
+
+	c.JSON(code, gin.H{
+		"status":       status,
+		"timestamp":    time.Now().Format(time.RFC3339),
+		"dependencies": h.service.Dependencies(),
+	})
+}
+
+// Health is the readiness report plus the number of /ws clients
+// GET /health
+func (h *HealthHandler) Health(c *gin.Context) {
+	code, status := h.readiness()
+
+	c.JSON(code, gin.H{
+		"status":       status,
+		"timestamp":    time.Now().Format(time.RFC3339),
+		"clients":      h.wsHandler.GetClientCount(),
+		"dependencies": h.service.Dependencies(),
+	})
+}
+
+func (h *HealthHandler) readiness() (int, string) {
+	switch {
+	case !h.service.Ready():
+		return 503, "unavailable"
+	case h.service.Degraded():
+		return 200, "degraded"
+	default:
+		return 200, "ok"
+	}
+}
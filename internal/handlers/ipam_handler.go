@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IPAMHandler handles static address subnet management
+type IPAMHandler struct {
+	service *services.IPAMService
+}
+
+// NewIPAMHandler creates a new IPAM handler
+func NewIPAMHandler(service *services.IPAMService) *IPAMHandler {
+	return &IPAMHandler{
+		service: service,
+	}
+}
+
+// CreateSubnetRequest represents payload for creating a subnet
+type CreateSubnetRequest struct {
+	CIDR        string  `json:"cidr" binding:"required"`
+	Gateway     *string `json:"gateway"`
+	Description *string `json:"description"`
+}
+
+// CreateSubnet creates a subnet on the router
+// POST /api/ipam/subnets
+func (h *IPAMHandler) CreateSubnet(c *gin.Context) {
+	var req CreateSubnetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	subnet := &domain.IPSubnet{
+		ID:          uuid.New().String(),
+		CIDR:        req.CIDR,
+		Gateway:     req.Gateway,
+		Description: req.Description,
+	}
+
+	if err := h.service.CreateSubnet(subnet); err != nil {
+		writeIPAMError(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"status": "success", "data": subnet})
+}
+
+// ListSubnets lists the router's subnets
+// GET /api/ipam/subnets
+func (h *IPAMHandler) ListSubnets(c *gin.Context) {
+	subnets, err := h.service.ListSubnets()
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": subnets})
+}
+
+// GetSubnet returns a subnet with its allocations
+// GET /api/ipam/subnets/:id
+func (h *IPAMHandler) GetSubnet(c *gin.Context) {
+	subnet, err := h.service.GetSubnet(c.Param("id"))
+	if err != nil {
+		writeIPAMError(c, err)
+		return
+	}
+
+	allocations, err := h.service.ListAllocations(subnet.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"subnet":      subnet,
+			"allocations": allocations,
+		},
+	})
+}
+
+// DeleteSubnet deletes an empty subnet
+// DELETE /api/ipam/subnets/:id
+func (h *IPAMHandler) DeleteSubnet(c *gin.Context) {
+	if err := h.service.DeleteSubnet(c.Param("id")); err != nil {
+		writeIPAMError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+func writeIPAMError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSubnet):
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrAddressConflict):
+		c.JSON(409, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrSubnetNotFound):
+		c.JSON(404, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MonitoredInterfaceHandler handles uplink/infrastructure interface monitoring
+type MonitoredInterfaceHandler struct {
+	service *services.InterfaceMonitorService
+}
+
+// NewMonitoredInterfaceHandler creates a new monitored interface handler
+func NewMonitoredInterfaceHandler(service *services.InterfaceMonitorService) *MonitoredInterfaceHandler {
+	return &MonitoredInterfaceHandler{
+		service: service,
+	}
+}
+
+// MonitoredInterfaceRequest represents payload for registering or updating a monitored interface
+type MonitoredInterfaceRequest struct {
+	Name             string  `json:"name" binding:"required"` // interface name on the router, e.g. ether1
+	Kind             string  `json:"kind"`                    // wan (default), bridge, vlan, other
+	CapacityBps      int64   `json:"capacity_bps" binding:"required"`
+	ThresholdPercent *int    `json:"threshold_percent"` // default 80
+	ThresholdMinutes *int    `json:"threshold_minutes"` // default 5
+	Enabled          *bool   `json:"enabled"`           // default true
+	Description      *string `json:"description"`
+}
+
+func (r *MonitoredInterfaceRequest) toInterface(id string) *domain.MonitoredInterface {
+	m := &domain.MonitoredInterface{
+		ID:               id,
+		Name:             r.Name,
+		Kind:             r.Kind,
+		CapacityBps:      r.CapacityBps,
+		ThresholdPercent: 80,
+		ThresholdMinutes: 5,
+		Enabled:          true,
+		Description:      r.Description,
+	}
+	if r.ThresholdPercent != nil {
+		m.ThresholdPercent = *r.ThresholdPercent
+	}
+	if r.ThresholdMinutes != nil {
+		m.ThresholdMinutes = *r.ThresholdMinutes
+	}
+	if r.Enabled != nil {
+		m.Enabled = *r.Enabled
+	}
+	return m
+}
+
+// CreateInterface registers an interface for monitoring
+// POST /api/monitored-interfaces
+func (h *MonitoredInterfaceHandler) CreateInterface(c *gin.Context) {
+	var req MonitoredInterfaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	m := req.toInterface(uuid.New().String())
+	if err := h.service.CreateInterface(m); err != nil {
+		writeMonitoredInterfaceError(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"status": "success", "data": m})
+}
+
+// UpdateInterface updates a monitored interface
+// PUT /api/monitored-interfaces/:id
+func (h *MonitoredInterfaceHandler) UpdateInterface(c *gin.Context) {
+	var req MonitoredInterfaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	m := req.toInterface(c.Param("id"))
+	if err := h.service.UpdateInterface(m); err != nil {
+		writeMonitoredInterfaceError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": m})
+}
+
+// DeleteInterface stops monitoring an interface and deletes its history
+// DELETE /api/monitored-interfaces/:id
+func (h *MonitoredInterfaceHandler) DeleteInterface(c *gin.Context) {
+	if err := h.service.DeleteInterface(c.Param("id")); err != nil {
+		writeMonitoredInterfaceError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+// GetInterface returns a monitored interface with its live utilization
+// GET /api/monitored-interfaces/:id
+func (h *MonitoredInterfaceHandler) GetInterface(c *gin.Context) {
+	status, err := h.service.GetStatus(c.Param("id"))
+	if err != nil {
+		writeMonitoredInterfaceError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": status})
+}
+
+// ListInterfaces returns all monitored interfaces with their live utilization
+// GET /api/monitored-interfaces
+func (h *MonitoredInterfaceHandler) ListInterfaces(c *gin.Context) {
+	statuses, err := h.service.ListStatus()
+	if err != nil {
+		writeMonitoredInterfaceError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": statuses})
+}
+
+// GetHistory returns the per-minute history of an interface
+// GET /api/monitored-interfaces/:id/history?from=RFC3339&to=RFC3339 (default: last 24h)
+func (h *MonitoredInterfaceHandler) GetHistory(c *gin.Context) {
+	from, to, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	history, err := h.service.History(c.Param("id"), from, to)
+	if err != nil {
+		writeMonitoredInterfaceError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "from": from, "to": to, "data": history})
+}
+
+// ListAlerts returns utilization alerts, newest first
+// GET /api/monitored-interfaces/alerts?interface_id=&open=true
+func (h *MonitoredInterfaceHandler) ListAlerts(c *gin.Context) {
+	alerts, err := h.service.ListAlerts(c.Query("interface_id"), c.Query("open") == "true")
+	if err != nil {
+		writeMonitoredInterfaceError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": alerts})
+}
+
+// parseTimeRange reads the RFC3339 from/to query params, defaulting to the last span
+func parseTimeRange(c *gin.Context, span time.Duration) (time.Time, time.Time, error) {
+	to := time.Now()
+	from := to.Add(-span)
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, fmt.Errorf("from must be an RFC3339 timestamp")
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return from, to, fmt.Errorf("to must be an RFC3339 timestamp")
+		}
+		to = t
+	}
+	if !from.Before(to) {
+		return from, to, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+func writeMonitoredInterfaceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidMonitoredInterface):
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrMonitoredInterfaceNotFound):
+		c.JSON(404, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+	}
+}
@@ -1,29 +1,38 @@
 package handlers
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"net/http"
+	"strconv"
+	"time"
 
+	"mikrotik-collector/internal/application/services"
 	"mikrotik-collector/internal/domain"
 	"mikrotik-collector/internal/infrastructure/mikrotik"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/websocket"
 )
 
 // PingHandler handles ping requests to customer IPs
 type PingHandler struct {
-	client *mikrotik.Client
-	repo   domain.CustomerRepository
+	client   *mikrotik.Client
+	repo     domain.CustomerRepository
+	alerts   *services.AlertService   // packet loss feeds the alert rules
+	sessions *services.SessionService // live PPPoE address, preferred over the stored one
 }
 
 // NewPingHandler creates a new ping handler
-func NewPingHandler(client *mikrotik.Client, repo domain.CustomerRepository) *PingHandler {
+func NewPingHandler(
+	client *mikrotik.Client,
+	repo domain.CustomerRepository,
+	alerts *services.AlertService,
+	sessions *services.SessionService,
+) *PingHandler {
 	return &PingHandler{
-		client: client,
-		repo:   repo,
+		client:   client,
+		repo:     repo,
+		alerts:   alerts,
+		sessions: sessions,
 	}
 }
 
@@ -44,8 +53,8 @@ func (h *PingHandler) PingCustomerByID(c *gin.Context) {
 		return
 	}
 
-	// Get IP address for this customer
-	ipAddress, err := h.getCustomerIPAddress(customer)
+	// Get IP address for this customer, live from /ppp/active for PPPoE
+	ipAddress, err := resolveCustomerAddress(h.sessions, customer)
 	if err != nil {
 		c.JSON(400, gin.H{
 			"status":        "error",
@@ -57,19 +66,21 @@ func (h *PingHandler) PingCustomerByID(c *gin.Context) {
 		return
 	}
 
-	// Verify customer exists in MikroTik (if PPPoE, check active sessions)
-	if customer.ServiceType == "pppoe" {
-		// Optimization: Check repository status first? Or real-time check?
-		// Real-time check is better for ping diagnostic.
-		// We can add method to mtClient to VerifySession but currently handlers do it.
-		// For simplicity, let's skip strict session verification here or implement simple check.
-		// h.verifyPPPoESession(customer) was used before.
-
-		// Let's assume if IP is present, we try ping.
+	opts, err := parsePingOptions(c, ipAddress, 3)
+	if err == nil && opts.Count == 0 {
+		err = fmt.Errorf("count must be at least 1")
+	}
+	if err == nil && pingRunTime(opts) > restPingMaxDuration {
+		// The HTTP server's write timeout would cut the response; use /ping/ws for longer runs
+		err = fmt.Errorf("count × interval must not exceed %s, use /ping/ws for longer runs", restPingMaxDuration)
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "customer_id": customer.ID, "message": err.Error()})
+		return
 	}
 
 	// Perform ping
-	pingResult, err := h.pingIPAddress(ipAddress)
+	stats, err := h.client.Ping(opts)
 	if err != nil {
 		c.JSON(500, gin.H{
 			"status":        "error",
@@ -82,25 +93,30 @@ func (h *PingHandler) PingCustomerByID(c *gin.Context) {
 		return
 	}
 
+	h.alerts.Observe(domain.MetricPacketLoss, customer.ID, customer.Name, stats.PacketLoss)
+
 	// Build response message
 	message := fmt.Sprintf("Customer '%s' is reachable at %s", customer.Name, ipAddress)
-	if !pingResult.IsReachable {
+	if !stats.Reachable() {
 		message = fmt.Sprintf("Customer '%s' is NOT reachable at %s (100%% packet loss)", customer.Name, ipAddress)
 	}
 
 	c.JSON(200, gin.H{
-		"status":        "success",
-		"customer_id":   customer.ID,
-		"customer_name": customer.Name,
-		"ip_address":    ipAddress,
-		"is_reachable":  pingResult.IsReachable,
-		"packet_loss":   pingResult.PacketLoss,
-		"avg_time":      pingResult.AvgTime,
-		"min_time":      pingResult.MinTime,
-		"max_time":      pingResult.MaxTime,
-		"sent":          pingResult.Sent,
-		"received":      pingResult.Received,
-		"message":       message,
+		"status":           "success",
+		"customer_id":      customer.ID,
+		"customer_name":    customer.Name,
+		"ip_address":       ipAddress,
+		"is_reachable":     stats.Reachable(),
+		"packet_loss":      fmt.Sprintf("%.0f%%", stats.PacketLoss),
+		"avg_time":         stats.AvgRtt.String(),
+		"min_time":         stats.MinRtt.String(),
+		"max_time":         stats.MaxRtt.String(),
+		"jitter":           stats.Jitter.String(),
+		"sent":             strconv.Itoa(stats.Sent),
+		"received":         strconv.Itoa(stats.Received),
+		"status_breakdown": stats.Breakdown,
+		"packets":          stats.Packets,
+		"message":          message,
 	})
 }
 
@@ -109,16 +125,13 @@ func (h *PingHandler) PingCustomerByID(c *gin.Context) {
 func (h *PingHandler) PingCustomerStream(c *gin.Context) {
 	customerID := c.Param("id")
 
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
-	}
-
-	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	ws, ctx, cancel, err := openDiagnosticSocket(c)
 	if err != nil {
 		log.Printf("WS upgrade failed: %v", err)
 		return
 	}
 	defer ws.Close()
+	defer cancel()
 
 	// Get Customer
 	customer, err := h.repo.GetCustomerByID(customerID)
@@ -128,51 +141,30 @@ func (h *PingHandler) PingCustomerStream(c *gin.Context) {
 	}
 
 	// Get IP
-	ipAddress, err := h.getCustomerIPAddress(customer)
+	ipAddress, err := resolveCustomerAddress(h.sessions, customer)
 	if err != nil {
 		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
 		return
 	}
 
-	// Start Streaming Ping
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Handle close message from client to stop ping
-	ws.SetCloseHandler(func(code int, text string) error {
-		cancel()
-		return nil
-	})
-
-	// Start reading pump to handle close frames
-	go func() {
-		for {
-			if _, _, err := ws.ReadMessage(); err != nil {
-				cancel()
-				break
-			}
-		}
-	}()
+	opts, err := parsePingOptions(c, ipAddress, 0)
+	if err != nil {
+		ws.WriteJSON(map[string]string{"type": "error", "error": err.Error()})
+		return
+	}
 
-	ptStream, err := h.client.StreamPing(ctx, ipAddress, "56", "1") // 1s interval by default
+	ptStream, err := h.client.StreamPing(ctx, opts)
 	if err != nil {
 		ws.WriteJSON(map[string]string{"type": "error", "error": "Failed to start ping: " + err.Error()})
 		return
 	}
 
-	// Track stats
-	sent := 0
-	received := 0
+	// Track packets for the summary
+	var packets []mikrotik.PingPacket
 
 	for resp := range ptStream {
-		// Increment stats
-		if resp.Seq != "" {
-			sent++
-			if resp.Received != "" {
-				received++
-			} else if resp.Status == "" || resp.Time != "" {
-				received++
-			}
+		if p, ok := resp.Packet(); ok {
+			packets = append(packets, p)
 		}
 
 		// Send update to FE
@@ -186,15 +178,21 @@ func (h *PingHandler) PingCustomerStream(c *gin.Context) {
 	}
 
 	// Calculate summary
-	loss := 100.0
-	if sent > 0 {
-		loss = float64(sent-received) / float64(sent) * 100
+	stats := mikrotik.SummarizePing(ipAddress, packets)
+	if stats.Sent > 0 {
+		h.alerts.Observe(domain.MetricPacketLoss, customer.ID, customer.Name, stats.PacketLoss)
 	}
 
 	summary := map[string]interface{}{
-		"sent":        sent,
-		"received":    received,
-		"packet_loss": fmt.Sprintf("%.0f%%", loss),
+		"sent":             stats.Sent,
+		"received":         stats.Received,
+		"packet_loss":      fmt.Sprintf("%.0f%%", stats.PacketLoss),
+		"is_reachable":     stats.Reachable(),
+		"min_time":         stats.MinRtt.String(),
+		"avg_time":         stats.AvgRtt.String(),
+		"max_time":         stats.MaxRtt.String(),
+		"jitter":           stats.Jitter.String(),
+		"status_breakdown": stats.Breakdown,
 	}
 
 	ws.WriteJSON(map[string]interface{}{
@@ -203,8 +201,17 @@ func (h *PingHandler) PingCustomerStream(c *gin.Context) {
 	})
 }
 
-// getCustomerIPAddress extracts IP address based on service type
-func (h *PingHandler) getCustomerIPAddress(customer *domain.Customer) (string, error) {
+// resolveCustomerAddress prefers the address of the live PPPoE session over the
+// stored one, which is only as fresh as the last callback
+func resolveCustomerAddress(sessions *services.SessionService, customer *domain.Customer) (string, error) {
+	if live := sessions.LiveAddress(customer); live != "" {
+		return live, nil
+	}
+	return customerIPAddress(customer)
+}
+
+// customerIPAddress extracts IP address based on service type
+func customerIPAddress(customer *domain.Customer) (string, error) {
 	switch customer.ServiceType {
 	case "pppoe":
 		// For PPPoE, we need to get IP from active session OR static IP in DB
@@ -235,35 +242,61 @@ func (h *PingHandler) getCustomerIPAddress(customer *domain.Customer) (string, e
 	}
 }
 
-// Helper struct for minimal ping result
-type PingResult struct {
-	IsReachable bool
-	PacketLoss  string
-	AvgTime     string
-	MinTime     string
-	MaxTime     string
-	Sent        string
-	Received    string
+// restPingMaxDuration bounds a ping answered over plain HTTP
+const restPingMaxDuration = 10 * time.Second
+
+// pingRunTime estimates how long a finite ping takes
+func pingRunTime(opts mikrotik.PingOptions) time.Duration {
+	interval := opts.Interval
+	if interval == 0 {
+		interval = time.Second
+	}
+	return time.Duration(opts.Count) * interval
 }
 
-func (h *PingHandler) pingIPAddress(ip string) (*PingResult, error) {
-	// Execute simple ping command (non-streaming)
-	// count=3
-	cmd := []string{"/ping", "=address=" + ip, "=count=3"}
-	res, err := h.client.RunArgs(cmd)
-	if err != nil {
-		return nil, err
+// parsePingOptions reads count, size, interval, ttl, dscp, src_address and
+// routing_table from the query string and validates them. interval accepts
+// RouterOS notation ("200ms", "1s", "1" = seconds).
+func parsePingOptions(c *gin.Context, address string, defaultCount int) (mikrotik.PingOptions, error) {
+	opts := mikrotik.PingOptions{
+		Address:      address,
+		Count:        defaultCount,
+		SrcAddress:   c.Query("src_address"),
+		RoutingTable: c.Query("routing_table"),
 	}
 
-	// Parse result
-	stats := res.Done.Map
-	return &PingResult{
-		IsReachable: stats["packet-loss"] != "100", // valid assumption?
-		PacketLoss:  stats["packet-loss"] + "%",
-		AvgTime:     stats["avg-rtt"],
-		MinTime:     stats["min-rtt"],
-		MaxTime:     stats["max-rtt"],
-		Sent:        stats["sent"],
-		Received:    stats["received"],
-	}, nil
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"count", &opts.Count},
+		{"size", &opts.Size},
+		{"ttl", &opts.TTL},
+		{"dscp", &opts.DSCP},
+	}
+	for _, p := range ints {
+		v := c.Query(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("%s must be an integer", p.name)
+		}
+		*p.value = n
+	}
+	opts.HasDSCP = c.Query("dscp") != ""
+
+	if v := c.Query("interval"); v != "" {
+		interval, err := mikrotik.ParseDuration(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid interval: %v", err)
+		}
+		opts.Interval = interval
+	}
+
+	if err := opts.Validate(); err != nil {
+		return opts, err
+	}
+	return opts, nil
 }
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PlanHandler handles CRUD requests for service plans
+type PlanHandler struct {
+	service *services.PlanService
+}
+
+// NewPlanHandler creates a new plan handler
+func NewPlanHandler(service *services.PlanService) *PlanHandler {
+	return &PlanHandler{
+		service: service,
+	}
+}
+
+// PlanRequest represents payload for creating or updating a plan
+type PlanRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description"`
+	ProfileName string  `json:"profile_name" binding:"required"`
+
+	DownloadRate string `json:"download_rate" binding:"required"`
+	UploadRate   string `json:"upload_rate" binding:"required"`
+
+	BurstDownloadRate      *string `json:"burst_download_rate"`
+	BurstUploadRate        *string `json:"burst_upload_rate"`
+	BurstThresholdDownload *string `json:"burst_threshold_download"`
+	BurstThresholdUpload   *string `json:"burst_threshold_upload"`
+	BurstTime              *int    `json:"burst_time"`
+
+	LocalAddress  *string `json:"local_address"`
+	RemoteAddress *string `json:"remote_address"`
+
+	Price      float64 `json:"price"`
+	QuotaBytes *int64  `json:"quota_bytes"`
+}
+
+func (r *PlanRequest) toPlan(id string) *domain.Plan {
+	return &domain.Plan{
+		ID:                     id,
+		Name:                   r.Name,
+		Description:            r.Description,
+		ProfileName:            r.ProfileName,
+		DownloadRate:           r.DownloadRate,
+		UploadRate:             r.UploadRate,
+		BurstDownloadRate:      r.BurstDownloadRate,
+		BurstUploadRate:        r.BurstUploadRate,
+		BurstThresholdDownload: r.BurstThresholdDownload,
+		BurstThresholdUpload:   r.BurstThresholdUpload,
+		BurstTime:              r.BurstTime,
+		LocalAddress:           r.LocalAddress,
+		RemoteAddress:          r.RemoteAddress,
+		Price:                  r.Price,
+		QuotaBytes:             r.QuotaBytes,
+	}
+}
+
+// CreatePlan handles plan creation
+// POST /api/plans
+func (h *PlanHandler) CreatePlan(c *gin.Context) {
+	var req PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	plan := req.toPlan(uuid.New().String())
+
+	if err := h.service.CreatePlan(plan); err != nil {
+		log.Printf("Failed to create plan: %v", err)
+		writePlanError(c, err)
+		return
+	}
+
+	c.JSON(201, gin.H{"status": "success", "data": plan})
+}
+
+// UpdatePlan handles plan update and propagates it to every router
+// PUT /api/plans/:id
+func (h *PlanHandler) UpdatePlan(c *gin.Context) {
+	var req PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	plan := req.toPlan(c.Param("id"))
+
+	if err := h.service.UpdatePlan(plan); err != nil {
+		writePlanError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": plan})
+}
+
+// DeletePlan handles plan deletion
+// DELETE /api/plans/:id
+func (h *PlanHandler) DeletePlan(c *gin.Context) {
+	if err := h.service.DeletePlan(c.Param("id")); err != nil {
+		writePlanError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+// GetPlan handles getting a single plan with its router sync status
+// GET /api/plans/:id
+func (h *PlanHandler) GetPlan(c *gin.Context) {
+	plan, err := h.service.GetPlan(c.Param("id"))
+	if err != nil {
+		writePlanError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": plan})
+}
+
+// ListPlans handles listing all plans
+// GET /api/plans
+func (h *PlanHandler) ListPlans(c *gin.Context) {
+	plans, err := h.service.ListPlans()
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": plans})
+}
+
+// SyncPlan re-applies a plan's /ppp/profile on every router
+// POST /api/plans/:id/sync
+func (h *PlanHandler) SyncPlan(c *gin.Context) {
+	plan, err := h.service.SyncPlan(c.Param("id"))
+	if err != nil {
+		writePlanError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": plan})
+}
+
+func writePlanError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidPlan):
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrPlanInUse):
+		c.JSON(409, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrPlanNotFound):
+		c.JSON(404, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"time"
+
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProbeHandler serves background reachability probe results
+type ProbeHandler struct {
+	service *services.ProbeService
+}
+
+// NewProbeHandler creates a new probe handler
+func NewProbeHandler(service *services.ProbeService) *ProbeHandler {
+	return &ProbeHandler{
+		service: service,
+	}
+}
+
+// ListStatus returns the reachability of every online customer, worst first
+// GET /api/probes?status=reachable|degraded|unreachable
+func (h *ProbeHandler) ListStatus(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", domain.ReachabilityReachable, domain.ReachabilityDegraded, domain.ReachabilityUnreachable:
+	default:
+		c.JSON(400, gin.H{"status": "error", "message": "status must be reachable, degraded or unreachable"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":  "success",
+		"summary": h.service.Summary(),
+		"data":    h.service.ListStatus(status),
+	})
+}
+
+// GetCustomerProbes returns the current reachability and probe history of a customer
+// GET /api/customers/:id/probes?from=RFC3339&to=RFC3339 (default: last 24h)
+func (h *ProbeHandler) GetCustomerProbes(c *gin.Context) {
+	from, to, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	history, err := h.service.History(c.Param("id"), from, to)
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":  "success",
+		"current": h.service.GetStatus(c.Param("id")), // null when the customer is not probed
+		"from":    from,
+		"to":      to,
+		"data":    history,
+	})
+}
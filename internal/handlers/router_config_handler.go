@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouterConfigHandler manages /ppp/profile and /ip/pool entries on the router
+type RouterConfigHandler struct {
+	mtClient *mikrotik.Client
+}
+
+// NewRouterConfigHandler creates a new router config handler
+func NewRouterConfigHandler(mtClient *mikrotik.Client) *RouterConfigHandler {
+	return &RouterConfigHandler{
+		mtClient: mtClient,
+	}
+}
+
+// ListPPPProfiles lists PPP profiles
+// GET /api/ppp/profiles
+func (h *RouterConfigHandler) ListPPPProfiles(c *gin.Context) {
+	profiles, err := h.mtClient.ListPPPProfiles()
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": profiles})
+}
+
+// GetPPPProfile returns a single PPP profile
+// GET /api/ppp/profiles/:id
+func (h *RouterConfigHandler) GetPPPProfile(c *gin.Context) {
+	profile, err := h.mtClient.GetPPPProfile(c.Param("id"))
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if profile == nil {
+		c.JSON(404, gin.H{"status": "error", "message": "ppp profile not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": profile})
+}
+
+// CreatePPPProfile creates a PPP profile
+// POST /api/ppp/profiles
+func (h *RouterConfigHandler) CreatePPPProfile(c *gin.Context) {
+	var req mikrotik.PPPProfile
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if req.Name == "" {
+		c.JSON(400, gin.H{"status": "error", "message": "name is required"})
+		return
+	}
+
+	id, err := h.mtClient.CreatePPPProfile(req)
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	req.ID = id
+	c.JSON(201, gin.H{"status": "success", "data": req})
+}
+
+// UpdatePPPProfile updates a PPP profile (empty fields are left unchanged)
+// PUT /api/ppp/profiles/:id
+func (h *RouterConfigHandler) UpdatePPPProfile(c *gin.Context) {
+	var req mikrotik.PPPProfile
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	if err := h.mtClient.UpdatePPPProfile(c.Param("id"), req); err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+// DeletePPPProfile deletes a PPP profile
+// DELETE /api/ppp/profiles/:id
+func (h *RouterConfigHandler) DeletePPPProfile(c *gin.Context) {
+	if err := h.mtClient.DeletePPPProfile(c.Param("id")); err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+// ipPoolResponse is an IP pool enriched with its utilization
+type ipPoolResponse struct {
+	mikrotik.IPPool
+	Utilization mikrotik.PoolUtilization `json:"utilization"`
+}
+
+// ListIPPools lists IP pools with used/free address counts
+// GET /api/ip/pools
+func (h *RouterConfigHandler) ListIPPools(c *gin.Context) {
+	pools, err := h.mtClient.ListIPPools()
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	utilization, err := h.mtClient.PoolUtilizations()
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	data := make([]ipPoolResponse, 0, len(pools))
+	for _, p := range pools {
+		data = append(data, ipPoolResponse{IPPool: p, Utilization: utilization[p.Name]})
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": data})
+}
+
+// GetIPPool returns a single IP pool with its utilization
+// GET /api/ip/pools/:id
+func (h *RouterConfigHandler) GetIPPool(c *gin.Context) {
+	pool, err := h.mtClient.GetIPPool(c.Param("id"))
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if pool == nil {
+		c.JSON(404, gin.H{"status": "error", "message": "ip pool not found"})
+		return
+	}
+
+	utilization, err := h.mtClient.PoolUtilizations()
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status": "success",
+		"data":   ipPoolResponse{IPPool: *pool, Utilization: utilization[pool.Name]},
+	})
+}
+
+// ListIPPoolUsed lists the addresses currently handed out from a pool
+// GET /api/ip/pools/:id/used
+func (h *RouterConfigHandler) ListIPPoolUsed(c *gin.Context) {
+	pool, err := h.mtClient.GetIPPool(c.Param("id"))
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if pool == nil {
+		c.JSON(404, gin.H{"status": "error", "message": "ip pool not found"})
+		return
+	}
+
+	used, err := h.mtClient.ListIPPoolUsed(pool.Name)
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": used})
+}
+
+// CreateIPPool creates an IP pool
+// POST /api/ip/pools
+func (h *RouterConfigHandler) CreateIPPool(c *gin.Context) {
+	var req mikrotik.IPPool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if req.Name == "" || req.Ranges == "" {
+		c.JSON(400, gin.H{"status": "error", "message": "name and ranges are required"})
+		return
+	}
+	if _, err := mikrotik.ParsePoolRanges(req.Ranges); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	id, err := h.mtClient.CreateIPPool(req)
+	if err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	req.ID = id
+	c.JSON(201, gin.H{"status": "success", "data": req})
+}
+
+// UpdateIPPool updates an IP pool (empty fields are left unchanged)
+// PUT /api/ip/pools/:id
+func (h *RouterConfigHandler) UpdateIPPool(c *gin.Context) {
+	var req mikrotik.IPPool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	if req.Ranges != "" {
+		if _, err := mikrotik.ParsePoolRanges(req.Ranges); err != nil {
+			c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+	}
+
+	if err := h.mtClient.UpdateIPPool(c.Param("id"), req); err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+// DeleteIPPool deletes an IP pool
+// DELETE /api/ip/pools/:id
+func (h *RouterConfigHandler) DeleteIPPool(c *gin.Context) {
+	if err := h.mtClient.DeleteIPPool(c.Param("id")); err != nil {
+		c.JSON(502, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// RouterHealthHandler serves router resource and sensor monitoring
+type RouterHealthHandler struct {
+	service *services.RouterHealthService
+}
+
+// NewRouterHealthHandler creates a new router health handler
+func NewRouterHealthHandler(service *services.RouterHealthService) *RouterHealthHandler {
+	return &RouterHealthHandler{
+		service: service,
+	}
+}
+
+// GetHealth returns the latest health state of a router
+// GET /api/routers/:id/health
+func (h *RouterHealthHandler) GetHealth(c *gin.Context) {
+	health, err := h.service.Latest(c.Param("id"))
+	if err != nil {
+		writeRouterHealthError(c, err)
+		return
+	}
+	if health == nil {
+		c.JSON(503, gin.H{"status": "error", "message": "no health sample yet"})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": health})
+}
+
+// GetHistory returns the health samples of a router
+// GET /api/routers/:id/health/history?from=RFC3339&to=RFC3339 (default: last 24h)
+func (h *RouterHealthHandler) GetHistory(c *gin.Context) {
+	from, to, err := parseTimeRange(c, 24*time.Hour)
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	history, err := h.service.History(c.Param("id"), from, to)
+	if err != nil {
+		writeRouterHealthError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "from": from, "to": to, "data": history})
+}
+
+// StreamHealth pushes every new health sample over WebSocket
+// GET /api/routers/:id/health/ws
+func (h *RouterHealthHandler) StreamHealth(c *gin.Context) {
+	routerID := c.Param("id")
+
+	// Reject unknown routers before upgrading
+	latest, err := h.service.Latest(routerID)
+	if err != nil {
+		writeRouterHealthError(c, err)
+		return
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin:     func(r *http.Request) bool { return true },
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WS upgrade error: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Send the current state right away so dashboards are not blank until the next poll
+	if latest != nil {
+		if err := ws.WriteJSON(gin.H{"type": "router_health", "data": latest}); err != nil {
+			return
+		}
+	}
+
+	updates, err := h.service.Subscribe(ctx, routerID)
+	if err != nil {
+		return
+	}
+	for health := range updates {
+		if err := ws.WriteJSON(gin.H{"type": "router_health", "data": health}); err != nil {
+			log.Printf("[Handler] WS Write error: %v", err)
+			return
+		}
+	}
+}
+
+func writeRouterHealthError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrRouterNotFound) {
+		c.JSON(404, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+	c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+
+	"mikrotik-collector/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler handles actions on customers' live router sessions
+type SessionHandler struct {
+	service *services.SessionService
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(service *services.SessionService) *SessionHandler {
+	return &SessionHandler{
+		service: service,
+	}
+}
+
+// DisconnectRequest represents the optional payload of a disconnect
+type DisconnectRequest struct {
+	Reason string `json:"reason"` // stored in the audit log, e.g. "profile change"
+}
+
+// DisconnectCustomer kicks the customer's active PPPoE session so it reconnects
+// POST /api/customers/:id/disconnect
+func (h *SessionHandler) DisconnectCustomer(c *gin.Context) {
+	var req DisconnectRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	removed, err := h.service.DisconnectCustomer(c.Param("id"), apiUser(c), req.Reason)
+	if err != nil {
+		writeCustomerError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":  "success",
+		"message": "Session disconnected",
+		"data": gin.H{
+			"customer_id": c.Param("id"),
+			"sessions":    removed,
+		},
+	})
+}
+
+// GetSession returns the customer's live PPPoE session: uptime, caller-id,
+// address, service, encoding, limit-bytes, interface and queue
+// GET /api/customers/:id/session
+func (h *SessionHandler) GetSession(c *gin.Context) {
+	session, err := h.service.GetSession(c.Param("id"))
+	if err != nil {
+		writeCustomerError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": session})
+}
+
+// SyncSession corrects the customer's stored status, address and caller-id from
+// the live PPPoE session, e.g. after a missed callback
+// POST /api/customers/:id/session/sync
+func (h *SessionHandler) SyncSession(c *gin.Context) {
+	session, err := h.service.SyncSession(c.Param("id"))
+	if err != nil {
+		writeCustomerError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": session})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"mikrotik-collector/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// TrafficAggregateHandler serves the router-wide traffic overview
+type TrafficAggregateHandler struct {
+	service *services.TrafficAggregateService
+}
+
+// NewTrafficAggregateHandler creates a new aggregate traffic handler
+func NewTrafficAggregateHandler(service *services.TrafficAggregateService) *TrafficAggregateHandler {
+	return &TrafficAggregateHandler{
+		service: service,
+	}
+}
+
+// GetSummary returns the latest traffic snapshot
+// GET /api/traffic/summary
+func (h *TrafficAggregateHandler) GetSummary(c *gin.Context) {
+	snapshot := h.service.Latest()
+	if snapshot == nil {
+		c.JSON(503, gin.H{"status": "error", "message": "no traffic sample yet"})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": snapshot})
+}
+
+// GetTop returns the busiest customer sessions
+// GET /api/traffic/top?limit=10&by=rx|tx|total
+func (h *TrafficAggregateHandler) GetTop(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(400, gin.H{"status": "error", "message": "limit must be between 1 and 1000"})
+		return
+	}
+
+	by := c.DefaultQuery("by", services.TopByRx)
+	if by != services.TopByRx && by != services.TopByTx && by != services.TopByTotal {
+		c.JSON(400, gin.H{"status": "error", "message": "by must be rx, tx or total"})
+		return
+	}
+
+	snapshot := h.service.Latest()
+	if snapshot == nil {
+		c.JSON(503, gin.H{"status": "error", "message": "no traffic sample yet"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"status":    "success",
+		"by":        by,
+		"timestamp": snapshot.Timestamp,
+		"data":      h.service.TopTalkers(limit, by),
+	})
+}
+
+// StreamAggregate pushes every new snapshot over WebSocket
+// GET /api/traffic/ws
+func (h *TrafficAggregateHandler) StreamAggregate(c *gin.Context) {
+	upgrader := websocket.Upgrader{
+		CheckOrigin:     func(r *http.Request) bool { return true },
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("WS upgrade error: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// Send the current state right away so dashboards are not blank until the next sample
+	if snapshot := h.service.Latest(); snapshot != nil {
+		if err := ws.WriteJSON(gin.H{"type": "traffic_aggregate", "data": snapshot}); err != nil {
+			return
+		}
+	}
+
+	for snapshot := range h.service.Subscribe(ctx) {
+		if err := ws.WriteJSON(gin.H{"type": "traffic_aggregate", "data": snapshot}); err != nil {
+			log.Printf("[Handler] WS Write error: %v", err)
+			return
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"mikrotik-collector/internal/application/services"
 	"mikrotik-collector/internal/domain"
@@ -18,19 +23,32 @@ type TrafficMonitorHandler struct {
 	repo        domain.CustomerRepository
 	pingHandler *PingHandler
 	mtClient    *mikrotik.Client
+	sessions    *services.SessionService // live session attached to traffic streams
+	history     domain.TrafficHistory    // recent samples replayed as traffic_backfill
 }
 
+// Bounds of the ?backfill= window on traffic streams
+const (
+	maxBackfillWindow  = time.Hour
+	maxBackfillSamples = 3600
+)
+
 // NewTrafficMonitorHandler creates a new handler
 func NewTrafficMonitorHandler(
 	service *services.OnDemandTrafficService,
 	repo domain.CustomerRepository,
 	mtClient *mikrotik.Client,
+	alerts *services.AlertService,
+	sessions *services.SessionService,
+	history domain.TrafficHistory,
 ) *TrafficMonitorHandler {
 	return &TrafficMonitorHandler{
 		service:     service,
 		repo:        repo,
-		pingHandler: NewPingHandler(mtClient, repo),
+		pingHandler: NewPingHandler(mtClient, repo, alerts, sessions),
 		mtClient:    mtClient,
+		sessions:    sessions,
+		history:     history,
 	}
 }
 
@@ -58,9 +76,11 @@ func (h *TrafficMonitorHandler) GetStatus(c *gin.Context) {
 	}
 
 	c.JSON(200, gin.H{
-		"status":         "ok",
-		"customer_count": len(customers),
-		"monitor_count":  activeCount,
+		"status":               "ok",
+		"customer_count":       len(customers),
+		"monitor_count":        activeCount,
+		"monitored_interfaces": h.service.MonitoredInterfaces(),
+		"monitors":             h.service.Usage(),
 	})
 }
 
@@ -73,11 +93,29 @@ func (h *TrafficMonitorHandler) ReloadCustomers(c *gin.Context) {
 	})
 }
 
-// StreamCustomerTraffic streams traffic for a specific customer via WebSocket
+// StreamCustomerTraffic streams traffic for a specific customer via WebSocket.
+// ?schema=2 selects numeric counters; the default (v1) keeps the legacy string payload.
+// ?backfill=5m first sends the samples of the last 5 minutes as one traffic_backfill frame.
 // GET /api/customers/:id/traffic/ws
 func (h *TrafficMonitorHandler) StreamCustomerTraffic(c *gin.Context) {
 	customerID := c.Param("id")
 
+	schema, err := parseTrafficSchema(c.Query("schema"))
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	backfill, err := parseBackfillWindow(c.Query("backfill"))
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	// Limits are per client IP; X-API-User is not authenticated and would let a client pick a fresh user
+	user := c.ClientIP()
+	queue := c.Query("queue") == "true"
+
 	// Allow all origins for now
 	upgrader := websocket.Upgrader{
 		CheckOrigin:     func(r *http.Request) bool { return true },
@@ -93,19 +131,13 @@ func (h *TrafficMonitorHandler) StreamCustomerTraffic(c *gin.Context) {
 	}
 	defer ws.Close()
 
-	// Start On-Demand Monitoring
-	streamChan, err := h.service.StartMonitoring(c.Request.Context(), customerID)
-	if err != nil {
-		log.Printf("[Handler] Failed to start stream for %s: %v", customerID, err)
-		ws.WriteJSON(map[string]string{"type": "error", "message": err.Error()})
-		return
-	}
-
-	// Ensure we stop monitoring when this handler exits
-	defer h.service.StopMonitoring(customerID)
+	// The stream ends when the client goes away
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
 
 	// Listen for close messages from client
 	go func() {
+		defer cancel()
 		for {
 			if _, _, err := ws.ReadMessage(); err != nil {
 				return
@@ -113,19 +145,176 @@ func (h *TrafficMonitorHandler) StreamCustomerTraffic(c *gin.Context) {
 		}
 	}()
 
-	// Stream data to WebSocket
-	for data := range streamChan {
-		err := ws.WriteJSON(gin.H{
-			"type": "traffic_update",
-			"data": data,
+	// Start On-Demand Monitoring, optionally waiting in the queue for a free slot
+	var streamChan <-chan domain.TrafficEvent
+	if queue {
+		streamChan, err = h.service.WaitForSlot(ctx, customerID, user, func() {
+			ws.WriteJSON(gin.H{"type": "queued"})
 		})
-		if err != nil {
+	} else {
+		streamChan, err = h.service.StartMonitoring(ctx, customerID, user)
+	}
+	if err != nil {
+		log.Printf("[Handler] Failed to start stream for %s: %v", customerID, err)
+		writeMonitorError(ws, err)
+		return
+	}
+
+	// Ensure we stop monitoring when this handler exits
+	defer h.service.StopMonitoring(customerID, user)
+
+	// The stored address may be stale; tell the client what the router reports
+	if session := h.liveSession(customerID); session != nil {
+		ws.WriteJSON(gin.H{"type": "session", "data": session})
+	}
+
+	// Replay recent samples so the chart does not start empty; live samples
+	// that are already part of the backfill are skipped below
+	var backfilledUntil time.Time
+	if backfill > 0 {
+		backfilledUntil = h.writeBackfill(ws, customerID, schema, backfill)
+	}
+
+	// Stream data to WebSocket
+	for event := range streamChan {
+		var frame gin.H
+		if event.Type == domain.TrafficEventUpdate {
+			if !event.Traffic.Timestamp.After(backfilledUntil) {
+				continue
+			}
+			frame = gin.H{
+				"type":   event.Type,
+				"schema": schema,
+				"data":   event.Traffic.Versioned(schema),
+			}
+		} else {
+			frame = gin.H{
+				"type":           event.Type,
+				"customer_id":    event.CustomerID,
+				"interface_name": event.InterfaceName,
+				"timestamp":      event.Timestamp,
+			}
+			if event.Type == domain.TrafficEventSessionUp {
+				// A reconnect usually brings a new address before the callback lands
+				if session := h.liveSession(customerID); session != nil {
+					frame["session"] = session
+				}
+			}
+		}
+
+		if err := ws.WriteJSON(frame); err != nil {
 			log.Printf("[Handler] WS Write error: %v", err)
 			break
 		}
 	}
 }
 
+// writeBackfill sends the customer's samples of the last window as one
+// traffic_backfill frame and returns the timestamp of the newest one
+func (h *TrafficMonitorHandler) writeBackfill(ws *websocket.Conn, customerID string, schema int, window time.Duration) time.Time {
+	if h.history == nil {
+		return time.Time{}
+	}
+
+	samples, err := h.history.RecentTraffic(customerID, time.Now().Add(-window), maxBackfillSamples)
+	if err != nil {
+		// Live data still works; the chart just starts empty
+		log.Printf("[Handler] Backfill for %s failed: %v", customerID, err)
+		samples = nil
+	}
+
+	data := make([]interface{}, 0, len(samples))
+	for _, sample := range samples {
+		data = append(data, sample.Versioned(schema))
+	}
+	ws.WriteJSON(gin.H{
+		"type":   "traffic_backfill",
+		"schema": schema,
+		"window": window.String(),
+		"data":   data,
+	})
+
+	if len(samples) == 0 {
+		return time.Time{}
+	}
+	return samples[len(samples)-1].Timestamp
+}
+
+// liveSession returns the customer's current router session, nil when unavailable
+func (h *TrafficMonitorHandler) liveSession(customerID string) *mikrotik.PPPoESession {
+	if h.sessions == nil {
+		return nil
+	}
+	session, err := h.sessions.GetSession(customerID)
+	if err != nil {
+		log.Printf("[Handler] Live session lookup for %s failed: %v", customerID, err)
+		return nil
+	}
+	return session.Session
+}
+
+// apiUser names the caller in audit logs: the X-API-User header, else the client IP
+func apiUser(c *gin.Context) string {
+	if user := c.GetHeader("X-API-User"); user != "" {
+		return user
+	}
+	return c.ClientIP()
+}
+
+// writeMonitorError sends a structured error frame to a traffic websocket
+func writeMonitorError(ws *websocket.Conn, err error) {
+	var limitErr *services.MonitorLimitError
+	if errors.As(err, &limitErr) {
+		ws.WriteJSON(gin.H{
+			"type":    "error",
+			"code":    "monitor_limit_reached",
+			"message": err.Error(),
+			"scope":   limitErr.Scope,
+			"limit":   limitErr.Limit,
+		})
+		return
+	}
+
+	ws.WriteJSON(gin.H{
+		"type":    "error",
+		"code":    "monitor_start_failed",
+		"message": err.Error(),
+	})
+}
+
+// parseTrafficSchema validates the ?schema= query value (empty = v1)
+func parseTrafficSchema(v string) (int, error) {
+	switch v {
+	case "", "1":
+		return domain.TrafficSchemaV1, nil
+	case "2":
+		return domain.TrafficSchemaV2, nil
+	default:
+		return 0, fmt.Errorf("unsupported schema %q (supported: 1, 2)", v)
+	}
+}
+
+// parseBackfillWindow validates the ?backfill= query value: a duration such as
+// 90s or 5m, or a plain number of seconds (empty = no backfill)
+func parseBackfillWindow(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+
+	window, err := time.ParseDuration(v)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(v)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid backfill window %q (e.g. 90s, 5m)", v)
+		}
+		window = time.Duration(seconds) * time.Second
+	}
+	if window < 0 || window > maxBackfillWindow {
+		return 0, fmt.Errorf("backfill window must be between 0 and %s", maxBackfillWindow)
+	}
+	return window, nil
+}
+
 // GetPingHandler returns the ping handler for route registration
 func (h *TrafficMonitorHandler) GetPingHandler() *PingHandler {
 	return h.pingHandler
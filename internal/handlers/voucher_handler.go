@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/url"
+	"strings"
+	"time"
+
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/mikrotik"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// VoucherHandler handles hotspot voucher batches
+type VoucherHandler struct {
+	service  *services.VoucherService
+	loginURL string // hotspot login page encoded into voucher QR codes
+}
+
+// NewVoucherHandler creates a new voucher handler
+func NewVoucherHandler(service *services.VoucherService, loginURL string) *VoucherHandler {
+	return &VoucherHandler{
+		service:  service,
+		loginURL: loginURL,
+	}
+}
+
+// CreateVoucherBatchRequest represents payload for generating a voucher batch
+type CreateVoucherBatchRequest struct {
+	Name            string  `json:"name" binding:"required"`
+	Count           int     `json:"count" binding:"required"`
+	Profile         string  `json:"profile"`
+	Price           float64 `json:"price"`
+	PriceTag        *string `json:"price_tag"`
+	Validity        *string `json:"validity"` // RouterOS notation, e.g. "1d", counted from first use
+	LimitUptime     *string `json:"limit_uptime"`
+	LimitBytesTotal *int64  `json:"limit_bytes_total"`
+
+	Prefix         string `json:"prefix"`
+	CodeLength     int    `json:"code_length"`
+	PasswordLength int    `json:"password_length"` // 0 = password equals the code
+}
+
+// CreateBatch starts generating a batch of vouchers on the router.
+// The batch is created in the background; poll the returned job for progress.
+// POST /api/vouchers/batches
+func (h *VoucherHandler) CreateBatch(c *gin.Context) {
+	var req CreateVoucherBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	batch := &domain.VoucherBatch{
+		ID:              uuid.New().String(),
+		Name:            req.Name,
+		Profile:         req.Profile,
+		Price:           req.Price,
+		PriceTag:        req.PriceTag,
+		LimitUptime:     req.LimitUptime,
+		LimitBytesTotal: req.LimitBytesTotal,
+		Count:           req.Count,
+	}
+	if req.Validity != nil && *req.Validity != "" {
+		validity, err := mikrotik.ParseDuration(*req.Validity)
+		if err != nil {
+			c.JSON(400, gin.H{"status": "error", "message": "invalid validity: " + err.Error()})
+			return
+		}
+		batch.ValiditySeconds = int(validity / time.Second)
+	}
+
+	job, err := h.service.StartBatch(batch, services.VoucherCodeOptions{
+		Prefix:         req.Prefix,
+		CodeLength:     req.CodeLength,
+		PasswordLength: req.PasswordLength,
+	})
+	if err != nil {
+		writeVoucherError(c, err)
+		return
+	}
+
+	c.JSON(202, gin.H{"status": "success", "data": job})
+}
+
+// GetJob returns the progress of a batch generation job
+// GET /api/vouchers/jobs/:id
+func (h *VoucherHandler) GetJob(c *gin.Context) {
+	job, err := h.service.Job(c.Param("id"))
+	if err != nil {
+		writeVoucherError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": job})
+}
+
+// ListBatches lists voucher batches
+// GET /api/vouchers/batches
+func (h *VoucherHandler) ListBatches(c *gin.Context) {
+	batches, err := h.service.ListBatches()
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": batches})
+}
+
+// GetBatch returns a batch with its vouchers and a status summary
+// GET /api/vouchers/batches/:id
+func (h *VoucherHandler) GetBatch(c *gin.Context) {
+	batch, vouchers, err := h.service.GetBatch(c.Param("id"))
+	if err != nil {
+		writeVoucherError(c, err)
+		return
+	}
+
+	summary := map[string]int{
+		domain.VoucherUnused:    0,
+		domain.VoucherActive:    0,
+		domain.VoucherExpired:   0,
+		domain.VoucherExhausted: 0,
+	}
+	for _, v := range vouchers {
+		summary[v.Status]++
+	}
+
+	c.JSON(200, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"batch":    batch,
+			"summary":  summary,
+			"vouchers": vouchers,
+		},
+	})
+}
+
+// DeleteBatch removes a batch and its hotspot users
+// DELETE /api/vouchers/batches/:id
+func (h *VoucherHandler) DeleteBatch(c *gin.Context) {
+	if err := h.service.DeleteBatch(c.Param("id")); err != nil {
+		writeVoucherError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success"})
+}
+
+// GetVoucher returns a single voucher's usage
+// GET /api/vouchers/:code
+func (h *VoucherHandler) GetVoucher(c *gin.Context) {
+	voucher, err := h.service.GetVoucher(c.Param("code"))
+	if err != nil {
+		writeVoucherError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": voucher})
+}
+
+type printableVoucher struct {
+	Code     string
+	Password string
+	QR       template.URL
+}
+
+// PrintBatch renders an HTML sheet of voucher cards with QR codes.
+// The page is laid out for A4; use the browser's "Save as PDF" for a PDF copy.
+// GET /api/vouchers/batches/:id/print
+func (h *VoucherHandler) PrintBatch(c *gin.Context) {
+	batch, vouchers, err := h.service.GetBatch(c.Param("id"))
+	if err != nil {
+		writeVoucherError(c, err)
+		return
+	}
+
+	// Only unused vouchers are worth printing unless asked otherwise
+	includeAll := c.Query("all") == "true"
+
+	cards := make([]printableVoucher, 0, len(vouchers))
+	for _, v := range vouchers {
+		if !includeAll && v.Status != domain.VoucherUnused {
+			continue
+		}
+		png, err := qrcode.Encode(h.qrContent(v), qrcode.Medium, 160)
+		if err != nil {
+			c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		cards = append(cards, printableVoucher{
+			Code:     v.Code,
+			Password: v.Password,
+			QR:       template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png)),
+		})
+	}
+
+	data := gin.H{
+		"Batch":        batch,
+		"Vouchers":     cards,
+		"ShowPassword": hasSeparatePasswords(vouchers),
+	}
+	if batch.ValiditySeconds > 0 {
+		data["Validity"] = mikrotik.FormatDuration(time.Duration(batch.ValiditySeconds) * time.Second)
+	}
+
+	var buf bytes.Buffer
+	if err := voucherSheetTemplate.Execute(&buf, data); err != nil {
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+		return
+	}
+
+	c.Data(200, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// qrContent returns a login link when a hotspot login URL is configured, otherwise just the code
+func (h *VoucherHandler) qrContent(v *domain.Voucher) string {
+	if h.loginURL == "" {
+		return v.Code
+	}
+
+	q := url.Values{}
+	q.Set("username", v.Code)
+	q.Set("password", v.Password)
+
+	sep := "?"
+	if strings.Contains(h.loginURL, "?") {
+		sep = "&"
+	}
+	return h.loginURL + sep + q.Encode()
+}
+
+func hasSeparatePasswords(vouchers []*domain.Voucher) bool {
+	for _, v := range vouchers {
+		if v.Password != v.Code {
+			return true
+		}
+	}
+	return false
+}
+
+func writeVoucherError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidVoucherBatch):
+		c.JSON(400, gin.H{"status": "error", "message": err.Error()})
+	case errors.Is(err, domain.ErrVoucherBatchNotFound), errors.Is(err, domain.ErrVoucherNotFound),
+		errors.Is(err, domain.ErrVoucherJobNotFound):
+		c.JSON(404, gin.H{"status": "error", "message": err.Error()})
+	default:
+		c.JSON(500, gin.H{"status": "error", "message": err.Error()})
+	}
+}
+
+var voucherSheetTemplate = template.Must(template.New("vouchers").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Batch.Name}}</title>
+<style>
+  @page { size: A4; margin: 10mm; }
+  body { font-family: Arial, sans-serif; margin: 0; }
+  .sheet { display: flex; flex-wrap: wrap; gap: 4mm; }
+  .card { width: 60mm; border: 1px dashed #555; padding: 3mm; box-sizing: border-box; page-break-inside: avoid; text-align: center; }
+  .name { font-size: 9pt; color: #555; }
+  .code { font-family: monospace; font-size: 14pt; font-weight: bold; letter-spacing: 1px; }
+  .meta { font-size: 8pt; }
+  .price { font-size: 12pt; font-weight: bold; }
+  img { width: 30mm; height: 30mm; }
+</style>
+</head>
+<body>
+<div class="sheet">
+{{- range .Vouchers}}
+  <div class="card">
+    <div class="name">{{$.Batch.Name}}</div>
+    <img src="{{.QR}}" alt="{{.Code}}">
+    <div class="code">{{.Code}}</div>
+    {{- if $.ShowPassword}}
+    <div class="meta">Password: <b>{{.Password}}</b></div>
+    {{- end}}
+    {{- if $.Validity}}
+    <div class="meta">Valid {{$.Validity}} from first login</div>
+    {{- end}}
+    {{- if $.Batch.PriceTag}}
+    <div class="price">{{$.Batch.PriceTag}}</div>
+    {{- end}}
+  </div>
+{{- end}}
+</div>
+</body>
+</html>
+`))
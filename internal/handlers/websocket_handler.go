@@ -1,34 +1,142 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/metrics"
+
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
+// maxTopicsPerClient bounds the subscriptions of one /ws connection
+const maxTopicsPerClient = 100
+
+// maxClientMessageSize bounds the subscription requests a client may send
+const maxClientMessageSize = 4096
+
+// Policies for /ws clients whose send queue is full
+const (
+	SlowClientDrop       = "drop"       // drop the message, keep the client
+	SlowClientDisconnect = "disconnect" // close the client; it has to reconnect
+)
+
 var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
-// WebSocketHandler handles WebSocket connections and broadcasting
+// WebSocketOptions tunes the /ws send queues and keepalive
+type WebSocketOptions struct {
+	QueueSize        int           // messages buffered per client
+	SlowClientPolicy string        // SlowClientDrop or SlowClientDisconnect
+	WriteTimeout     time.Duration // a single write taking longer closes the client
+	PongTimeout      time.Duration // a client silent this long is closed; pings go out at 9/10 of it
+}
+
+// wsClient is one /ws connection. Clients that never subscribed receive every
+// broadcast as before; after their first subscribe they only receive their
+// topics, even once they unsubscribed from all of them.
+// Only the client's writer goroutine writes to conn.
+type wsClient struct {
+	conn *websocket.Conn
+	addr string
+	user string // caller for the per-user monitor limit, see apiUser
+	send chan []byte
+
+	done      chan struct{} // closed to make the writer send a close frame and exit
+	closeOnce sync.Once
+	closeCode int
+	closeText string
+
+	// guarded by WebSocketHandler.clientsMu
+	subscribed bool
+	topics     map[string]bool
+	monitors   map[string]func() // customer traffic topic -> stops the monitor started for it
+}
+
+// stop makes the writer close the connection with code; only the first call counts
+func (c *wsClient) stop(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		close(c.done)
+	})
+}
+
+// topicMessage is a message published on a topic
+type topicMessage struct {
+	topic string
+	data  []byte
+}
+
+// subscriptionRequest is sent by clients to change their topics, e.g.
+// {"action":"subscribe","topics":["alerts","customer:<id>:traffic"]}
+type subscriptionRequest struct {
+	Action string   `json:"action"` // subscribe | unsubscribe
+	Topics []string `json:"topics"`
+}
+
+// WebSocketHandler handles WebSocket connections and broadcasting. Broadcasts
+// are queued per client, so a slow browser never holds up the others.
 type WebSocketHandler struct {
-	clients   map[*websocket.Conn]bool
+	opts WebSocketOptions
+
+	clients   map[*websocket.Conn]*wsClient
 	clientsMu sync.RWMutex
+	topicRefs map[string]int // topic -> subscribed clients, guarded by clientsMu
+	closing   bool           // set by Close, guarded by clientsMu
+	writers   sync.WaitGroup
+
 	broadcast chan []byte
+	topicMsgs chan topicMessage
+
+	traffic *services.OnDemandTrafficService // runs monitors for customer traffic topics
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
-func NewWebSocketHandler() *WebSocketHandler {
+func NewWebSocketHandler(opts WebSocketOptions) *WebSocketHandler {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 256
+	}
+	if opts.SlowClientPolicy != SlowClientDrop && opts.SlowClientPolicy != SlowClientDisconnect {
+		if opts.SlowClientPolicy != "" {
+			log.Printf("WARNING: unknown slow client policy %q, using %q", opts.SlowClientPolicy, SlowClientDrop)
+		}
+		opts.SlowClientPolicy = SlowClientDrop
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 10 * time.Second
+	}
+	if opts.PongTimeout <= 0 {
+		opts.PongTimeout = 60 * time.Second
+	}
+
 	return &WebSocketHandler{
-		clients:   make(map[*websocket.Conn]bool),
+		opts:      opts,
+		clients:   make(map[*websocket.Conn]*wsClient),
+		topicRefs: make(map[string]int),
 		broadcast: make(chan []byte),
+		topicMsgs: make(chan topicMessage, 256),
 	}
 }
 
+// SetTrafficService lets clients subscribe to customer traffic topics. Such a
+// subscription starts the customer's monitor like a traffic stream does; without
+// the service (no database) those topics are rejected.
+func (h *WebSocketHandler) SetTrafficService(traffic *services.OnDemandTrafficService) {
+	h.traffic = traffic
+}
+
 // GetBroadcastChannel returns the broadcast channel for other components
 func (h *WebSocketHandler) GetBroadcastChannel() chan []byte {
 	return h.broadcast
@@ -41,8 +149,33 @@ func (h *WebSocketHandler) GetClientCount() int {
 	return len(h.clients)
 }
 
+// SubscribedTopics returns the topics at least one client is subscribed to
+func (h *WebSocketHandler) SubscribedTopics() []string {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
+	topics := make([]string, 0, len(h.topicRefs))
+	for topic := range h.topicRefs {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// PublishTopic forwards a topic message to its subscribers
+func (h *WebSocketHandler) PublishTopic(topic string, data []byte) {
+	h.topicMsgs <- topicMessage{topic: topic, data: data}
+}
+
 // HandleWS handles WebSocket connection requests
 func (h *WebSocketHandler) HandleWS(c *gin.Context) {
+	h.clientsMu.RLock()
+	closing := h.closing
+	h.clientsMu.RUnlock()
+	if closing {
+		c.JSON(503, gin.H{"status": "error", "message": "server is shutting down"})
+		return
+	}
+
 	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
@@ -51,53 +184,317 @@ func (h *WebSocketHandler) HandleWS(c *gin.Context) {
 
 	log.Printf("New WebSocket client connected from %s", c.Request.RemoteAddr)
 
+	client := &wsClient{
+		conn:     ws,
+		addr:     c.Request.RemoteAddr,
+		user:     c.ClientIP(),
+		send:     make(chan []byte, h.opts.QueueSize),
+		done:     make(chan struct{}),
+		topics:   make(map[string]bool),
+		monitors: make(map[string]func()),
+	}
+
 	h.clientsMu.Lock()
-	h.clients[ws] = true
+	if h.closing {
+		h.clientsMu.Unlock()
+		ws.Close()
+		return
+	}
+	h.clients[ws] = client
+	h.writers.Add(1)
 	h.clientsMu.Unlock()
 
+	go h.writePump(client)
+
 	defer func() {
-		h.clientsMu.Lock()
-		delete(h.clients, ws)
-		h.clientsMu.Unlock()
-		ws.Close()
+		h.removeClient(client)
+		client.stop(websocket.CloseNormalClosure, "")
 		log.Printf("WebSocket client disconnected")
 	}()
 
+	// Any message or pong from the client proves it is still there
+	ws.SetReadLimit(maxClientMessageSize)
+	ws.SetReadDeadline(time.Now().Add(h.opts.PongTimeout))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(h.opts.PongTimeout))
+	})
+
 	for {
-		if _, _, err := ws.ReadMessage(); err != nil {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
 			break
 		}
+		ws.SetReadDeadline(time.Now().Add(h.opts.PongTimeout))
+
+		var req subscriptionRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			h.reply(client, gin.H{"type": "error", "message": "invalid JSON"})
+			continue
+		}
+		h.handleSubscription(client, req)
 	}
 }
 
-// Broadcaster runs in a goroutine to broadcast messages to all clients
-func (h *WebSocketHandler) Broadcaster() {
+// writePump is the only writer of a client's connection. It sends queued
+// messages and pings, and closes the connection when a write fails or the
+// client is stopped.
+func (h *WebSocketHandler) writePump(client *wsClient) {
+	ticker := time.NewTicker(h.opts.PongTimeout * 9 / 10)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close() // unblocks the reader, which removes the client
+		h.writers.Done()
+	}()
+
 	for {
-		msg := <-h.broadcast
+		select {
+		case msg := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(h.opts.WriteTimeout))
+			if err := client.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				log.Printf("Write error to %s: %v", client.addr, err)
+				return
+			}
+		case <-ticker.C:
+			deadline := time.Now().Add(h.opts.WriteTimeout)
+			if err := client.conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				return
+			}
+		case <-client.done:
+			deadline := time.Now().Add(h.opts.WriteTimeout)
+			client.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(client.closeCode, client.closeText), deadline)
+			return
+		}
+	}
+}
+
+// enqueue queues msg for a client without blocking; a full queue is handled
+// by the slow client policy
+func (h *WebSocketHandler) enqueue(client *wsClient, msg []byte) {
+	select {
+	case client.send <- msg:
+		return
+	case <-client.done:
+		return
+	default:
+	}
+
+	if h.opts.SlowClientPolicy == SlowClientDisconnect {
+		metrics.WebSocketSlowClients.WithLabelValues(metrics.SlowClientDisconnected).Inc()
+		log.Printf("Disconnecting slow WebSocket client %s: send queue full", client.addr)
+		client.stop(websocket.CloseTryAgainLater, "send queue full")
+		return
+	}
+	metrics.WebSocketSlowClients.WithLabelValues(metrics.SlowClientDropped).Inc()
+}
 
-		h.clientsMu.RLock()
-		for client := range h.clients {
-			err := client.WriteMessage(websocket.TextMessage, msg)
+// handleSubscription applies a subscribe/unsubscribe request and replies with
+// the client's current topics. Requests of one client are handled one at a time
+// by its read loop.
+func (h *WebSocketHandler) handleSubscription(client *wsClient, req subscriptionRequest) {
+	if req.Action != "subscribe" && req.Action != "unsubscribe" {
+		h.reply(client, gin.H{"type": "error", "message": "action must be subscribe or unsubscribe"})
+		return
+	}
+	for _, topic := range req.Topics {
+		if !domain.ValidTopic(topic) {
+			h.reply(client, gin.H{"type": "error", "message": "invalid topic: " + topic})
+			return
+		}
+	}
+
+	var stops []func()
+	if req.Action == "subscribe" {
+		monitors, err := h.startMonitors(client, req.Topics)
+		if err != nil {
+			h.reply(client, gin.H{"type": "error", "message": err.Error()})
+			return
+		}
+
+		h.clientsMu.Lock()
+		client.subscribed = true
+		for _, topic := range req.Topics {
+			if client.topics[topic] {
+				continue
+			}
+			client.topics[topic] = true
+			h.topicRefs[topic]++
+			if stop, ok := monitors[topic]; ok {
+				client.monitors[topic] = stop
+			}
+		}
+	} else {
+		h.clientsMu.Lock()
+		for _, topic := range req.Topics {
+			if client.topics[topic] {
+				stops = append(stops, h.unsubscribeLocked(client, topic)...)
+			}
+		}
+	}
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		topics = append(topics, topic)
+	}
+	h.clientsMu.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
+
+	sort.Strings(topics)
+	h.reply(client, gin.H{"type": req.Action + "d", "topics": topics})
+}
+
+// startMonitors checks the topic limit and starts the customer monitors that
+// feed the client's new customer traffic topics. On error nothing is left running.
+func (h *WebSocketHandler) startMonitors(client *wsClient, topics []string) (map[string]func(), error) {
+	h.clientsMu.RLock()
+	var added []string
+	for _, topic := range topics {
+		if !client.topics[topic] && !slices.Contains(added, topic) {
+			added = append(added, topic)
+		}
+	}
+	total := len(client.topics) + len(added)
+	h.clientsMu.RUnlock()
+
+	if total > maxTopicsPerClient {
+		return nil, fmt.Errorf("too many topics")
+	}
+
+	monitors := make(map[string]func())
+	for _, topic := range added {
+		customerID, ok := domain.CustomerOfTrafficTopic(topic)
+		if !ok {
+			continue
+		}
+
+		stop, err := h.startMonitor(client, customerID)
+		if err != nil {
+			for _, stop := range monitors {
+				stop()
+			}
+			return nil, fmt.Errorf("cannot monitor %s: %w", topic, err)
+		}
+		monitors[topic] = stop
+	}
+	return monitors, nil
+}
+
+// startMonitor starts the customer's traffic monitor for a topic subscriber.
+// Events reach the client through the topic stream, so the observer is drained.
+func (h *WebSocketHandler) startMonitor(client *wsClient, customerID string) (func(), error) {
+	if h.traffic == nil {
+		return nil, fmt.Errorf("customer traffic is not available without the database")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := h.traffic.StartMonitoring(ctx, customerID, client.user)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		for range events {
+		}
+	}()
+
+	return func() {
+		cancel()
+		h.traffic.StopMonitoring(customerID, client.user)
+	}, nil
+}
+
+// unsubscribeLocked removes a topic of the client and returns the monitor stop
+// to call once clientsMu is released
+func (h *WebSocketHandler) unsubscribeLocked(client *wsClient, topic string) []func() {
+	delete(client.topics, topic)
+	if h.topicRefs[topic]--; h.topicRefs[topic] <= 0 {
+		delete(h.topicRefs, topic)
+	}
+
+	stop, ok := client.monitors[topic]
+	if !ok {
+		return nil
+	}
+	delete(client.monitors, topic)
+	return []func(){stop}
+}
+
+func (h *WebSocketHandler) removeClient(client *wsClient) {
+	var stops []func()
+
+	h.clientsMu.Lock()
+	if _, ok := h.clients[client.conn]; !ok {
+		h.clientsMu.Unlock()
+		return
+	}
+	for topic := range client.topics {
+		stops = append(stops, h.unsubscribeLocked(client, topic)...)
+	}
+	delete(h.clients, client.conn)
+	h.clientsMu.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
+}
+
+func (h *WebSocketHandler) reply(client *wsClient, msg gin.H) {
+	payload, _ := json.Marshal(msg)
+	h.enqueue(client, payload)
+}
+
+// Broadcaster runs in a goroutine to broadcast messages to all clients until ctx is cancelled
+func (h *WebSocketHandler) Broadcaster(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-h.broadcast:
+			h.send(msg, func(c *wsClient) bool { return !c.subscribed })
+		case m := <-h.topicMsgs:
+			frame, err := json.Marshal(gin.H{"type": "message", "topic": m.topic, "data": json.RawMessage(m.data)})
 			if err != nil {
-				log.Printf("Write error: %v", err)
-				client.Close()
-
-				h.clientsMu.RUnlock()
-				h.clientsMu.Lock()
-				delete(h.clients, client)
-				h.clientsMu.Unlock()
-				h.clientsMu.RLock()
+				log.Printf("Invalid JSON on topic %s: %v", m.topic, err)
+				continue
 			}
+			h.send(frame, func(c *wsClient) bool { return c.topics[m.topic] })
 		}
-		h.clientsMu.RUnlock()
 	}
 }
 
-// HandleHealthCheck handles health check endpoint
-func (h *WebSocketHandler) HandleHealthCheck(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"status":    "ok",
-		"timestamp": time.Now().Format(time.RFC3339),
-		"clients":   h.GetClientCount(),
-	})
+// send queues msg for every client matching want
+func (h *WebSocketHandler) send(msg []byte, want func(*wsClient) bool) {
+	h.clientsMu.RLock()
+	defer h.clientsMu.RUnlock()
+
+	for _, client := range h.clients {
+		if want(client) {
+			h.enqueue(client, msg)
+		}
+	}
+}
+
+// Close sends every client a going-away close frame and waits until the
+// writers are done or ctx expires. New connections are refused afterwards.
+func (h *WebSocketHandler) Close(ctx context.Context) {
+	h.clientsMu.Lock()
+	h.closing = true
+	for _, client := range h.clients {
+		client.stop(websocket.CloseGoingAway, "server shutting down")
+	}
+	h.clientsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.writers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("WebSocket clients did not close in time: %v", ctx.Err())
+	}
 }
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every collector metric
+const namespace = "mikrotik_collector"
+
+// Label values for RedisPublishErrors
+const (
+	RedisPubSub = "pubsub"
+	RedisStream = "stream"
+)
+
+// Label values for CustomerSyncFailures
+const (
+	SyncTargetPPPSecret   = "ppp_secret"
+	SyncTargetHotspotUser = "hotspot_user"
+
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncDelete = "delete"
+
+	SyncReasonError    = "error"     // the router rejected the command
+	SyncReasonNotFound = "not_found" // the router entry is missing, the change was skipped
+)
+
+// Label values for WebSocketSlowClients
+const (
+	SlowClientDropped      = "dropped"      // a message was dropped, the client stays connected
+	SlowClientDisconnected = "disconnected" // the client was disconnected
+)
+
+var (
+	// RouterRequestDuration is the latency of RouterOS API commands. The command
+	// label is the command path (e.g. /ppp/active/print), never its arguments.
+	RouterRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "router_api_request_duration_seconds",
+		Help:      "Latency of RouterOS API commands.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"router", "command"})
+
+	// RouterRequestErrors counts RouterOS API commands that failed
+	RouterRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "router_api_errors_total",
+		Help:      "RouterOS API commands that returned an error.",
+	}, []string{"router", "command"})
+
+	// RouterReconnects counts reconnect attempts to a router by result (success, failure)
+	RouterReconnects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "router_api_reconnects_total",
+		Help:      "Reconnect attempts to the RouterOS API.",
+	}, []string{"router", "result"})
+
+	// RedisPublishErrors counts failed Redis publishes by kind (pubsub, stream)
+	RedisPublishErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_publish_errors_total",
+		Help:      "Failed Redis Pub/Sub and Stream publishes.",
+	}, []string{"kind"})
+
+	// CustomerSyncFailures counts customer changes that did not reach the router
+	CustomerSyncFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "customer_sync_failures_total",
+		Help:      "Customer changes that could not be synced to the router.",
+	}, []string{"target", "operation", "reason"})
+
+	// WebSocketSlowClients counts what happened to /ws clients whose send queue
+	// was full, by action (dropped, disconnected)
+	WebSocketSlowClients = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_slow_client_events_total",
+		Help:      "Messages dropped or clients disconnected because a /ws send queue was full.",
+	}, []string{"action"})
+)
+
+// RegisterGauge exports fn as a gauge read at scrape time, e.g. the number of
+// connected WebSocket clients
+func RegisterGauge(name, help string, fn func() float64) {
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// Handler serves the registered metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"mikrotik-collector/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TrafficOptions controls which traffic series are exported. Per-router,
+// per-uplink and per-profile series are always on; per-customer series are
+// opt-in and capped because every customer adds a label set.
+type TrafficOptions struct {
+	PerCustomer  bool
+	MaxCustomers int // busiest sessions exported when PerCustomer is set
+}
+
+// TrafficCollector exports the latest traffic overview snapshot at scrape time
+type TrafficCollector struct {
+	latest  func() *domain.TrafficSnapshot
+	talkers func(n int) []domain.TalkerRate // the n busiest sessions by total rate
+	opts    TrafficOptions
+
+	routerRate    *prometheus.Desc
+	routerSession *prometheus.Desc
+	sampledAt     *prometheus.Desc
+	uplinkRate    *prometheus.Desc
+	profileRate   *prometheus.Desc
+	profileCount  *prometheus.Desc
+	customerRate  *prometheus.Desc
+}
+
+// NewTrafficCollector creates a collector over the traffic overview
+func NewTrafficCollector(
+	latest func() *domain.TrafficSnapshot,
+	talkers func(n int) []domain.TalkerRate,
+	opts TrafficOptions,
+) *TrafficCollector {
+	name := func(n string) string { return prometheus.BuildFQName(namespace, "", n) }
+
+	return &TrafficCollector{
+		latest:  latest,
+		talkers: talkers,
+		opts:    opts,
+
+		routerRate: prometheus.NewDesc(name("router_traffic_bits_per_second"),
+			"Sum of all PPPoE session rates on the router.", []string{"router", "direction"}, nil),
+		routerSession: prometheus.NewDesc(name("router_pppoe_sessions"),
+			"Running PPPoE sessions in the latest traffic sample.", []string{"router"}, nil),
+		sampledAt: prometheus.NewDesc(name("router_traffic_sample_timestamp_seconds"),
+			"Unix time of the latest traffic sample.", []string{"router"}, nil),
+		uplinkRate: prometheus.NewDesc(name("uplink_traffic_bits_per_second"),
+			"Rate of an uplink interface.", []string{"router", "interface", "direction"}, nil),
+		profileRate: prometheus.NewDesc(name("profile_traffic_bits_per_second"),
+			"Sum of the session rates of one PPP profile.", []string{"router", "profile", "direction"}, nil),
+		profileCount: prometheus.NewDesc(name("profile_pppoe_sessions"),
+			"Running PPPoE sessions of one PPP profile.", []string{"router", "profile"}, nil),
+		customerRate: prometheus.NewDesc(name("customer_traffic_bits_per_second"),
+			"Rate of a customer's PPPoE session (busiest sessions only).", []string{"router", "customer_id", "username", "direction"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *TrafficCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.routerRate
+	ch <- c.routerSession
+	ch <- c.sampledAt
+	ch <- c.uplinkRate
+	ch <- c.profileRate
+	ch <- c.profileCount
+	ch <- c.customerRate
+}
+
+// Collect implements prometheus.Collector
+func (c *TrafficCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.latest()
+	if snapshot == nil {
+		return
+	}
+	router := snapshot.RouterID
+
+	rates := func(desc *prometheus.Desc, rate domain.TrafficRate, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(rate.RxBitsPerSecond), append(labels, "rx")...)
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(rate.TxBitsPerSecond), append(labels, "tx")...)
+	}
+
+	rates(c.routerRate, snapshot.Total, router)
+	ch <- prometheus.MustNewConstMetric(c.routerSession, prometheus.GaugeValue, float64(snapshot.Sessions), router)
+	ch <- prometheus.MustNewConstMetric(c.sampledAt, prometheus.GaugeValue, float64(snapshot.Timestamp.Unix()), router)
+
+	for _, u := range snapshot.Uplinks {
+		rates(c.uplinkRate, u.TrafficRate, router, u.InterfaceName)
+	}
+	for _, p := range snapshot.Profiles {
+		rates(c.profileRate, p.TrafficRate, router, p.Profile)
+		ch <- prometheus.MustNewConstMetric(c.profileCount, prometheus.GaugeValue, float64(p.Sessions), router, p.Profile)
+	}
+
+	if !c.opts.PerCustomer || c.opts.MaxCustomers <= 0 {
+		return
+	}
+	// A duplicate label set fails the whole scrape; a user with two sessions
+	// keeps only the busier one
+	seen := make(map[string]bool)
+	for _, t := range c.talkers(c.opts.MaxCustomers) {
+		key := t.CustomerID + "\x00" + t.Username
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rates(c.customerRate, t.TrafficRate, router, t.CustomerID, t.Username)
+	}
+}
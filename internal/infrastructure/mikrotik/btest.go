@@ -0,0 +1,147 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bandwidth test limits; a test saturates the customer's link, so it is always bounded
+const (
+	BandwidthTestMaxDuration    = time.Minute
+	BandwidthTestMaxConnections = 20
+)
+
+// BandwidthTestOptions configures /tool/bandwidth-test towards a btest server (usually the CPE)
+type BandwidthTestOptions struct {
+	Address         string
+	Direction       string        // receive, transmit, both (default both)
+	Protocol        string        // tcp (default), udp
+	Duration        time.Duration // required, at most BandwidthTestMaxDuration
+	User            string
+	Password        string
+	LocalTxSpeed    uint64 // bps cap for UDP tests, 0 = unlimited
+	RemoteTxSpeed   uint64
+	ConnectionCount int // TCP connections, RouterOS default 20
+}
+
+// Validate checks the options against the allowed limits
+func (o *BandwidthTestOptions) Validate() error {
+	if net.ParseIP(o.Address) == nil {
+		return fmt.Errorf("address %q is not a valid IP address", o.Address)
+	}
+	switch o.Direction {
+	case "", "receive", "transmit", "both":
+	default:
+		return fmt.Errorf("direction must be receive, transmit or both")
+	}
+	switch o.Protocol {
+	case "", "tcp", "udp":
+	default:
+		return fmt.Errorf("protocol must be tcp or udp")
+	}
+	if o.Duration < time.Second || o.Duration > BandwidthTestMaxDuration {
+		return fmt.Errorf("duration must be between 1s and %s", BandwidthTestMaxDuration)
+	}
+	if o.ConnectionCount < 0 || o.ConnectionCount > BandwidthTestMaxConnections {
+		return fmt.Errorf("connection_count must be between 1 and %d", BandwidthTestMaxConnections)
+	}
+	if strings.ContainsAny(o.User+o.Password, "\x00\n") {
+		return fmt.Errorf("user and password must not contain control characters")
+	}
+	return nil
+}
+
+// args builds the /tool/bandwidth-test command words
+func (o *BandwidthTestOptions) args() []string {
+	args := []string{
+		"/tool/bandwidth-test",
+		"=address=" + o.Address,
+		"=duration=" + strconv.Itoa(int(o.Duration.Seconds())) + "s",
+	}
+	if o.Direction != "" {
+		args = append(args, "=direction="+o.Direction)
+	}
+	if o.Protocol != "" {
+		args = append(args, "=protocol="+o.Protocol)
+	}
+	if o.User != "" {
+		args = append(args, "=user="+o.User)
+	}
+	if o.Password != "" {
+		args = append(args, "=password="+o.Password)
+	}
+	if o.LocalTxSpeed > 0 {
+		args = append(args, "=local-tx-speed="+strconv.FormatUint(o.LocalTxSpeed, 10))
+	}
+	if o.RemoteTxSpeed > 0 {
+		args = append(args, "=remote-tx-speed="+strconv.FormatUint(o.RemoteTxSpeed, 10))
+	}
+	if o.ConnectionCount > 0 {
+		args = append(args, "=connection-count="+strconv.Itoa(o.ConnectionCount))
+	}
+	return args
+}
+
+// BandwidthTestResult is one progress update of a bandwidth test
+type BandwidthTestResult struct {
+	Status        string `json:"status"` // connecting, running, done testing, authentication failed, ...
+	Duration      string `json:"duration"`
+	Direction     string `json:"direction,omitempty"`
+	TxCurrentBps  uint64 `json:"tx_current_bps"`
+	Tx10sAvgBps   uint64 `json:"tx_10s_average_bps"`
+	TxAverageBps  uint64 `json:"tx_total_average_bps"`
+	RxCurrentBps  uint64 `json:"rx_current_bps"`
+	Rx10sAvgBps   uint64 `json:"rx_10s_average_bps"`
+	RxAverageBps  uint64 `json:"rx_total_average_bps"`
+	LostPackets   uint64 `json:"lost_packets"`
+	LocalCPULoad  string `json:"local_cpu_load,omitempty"`
+	RemoteCPULoad string `json:"remote_cpu_load,omitempty"`
+}
+
+// StreamBandwidthTest runs /tool/bandwidth-test and emits progress every
+// second until the test duration ends or ctx is cancelled
+func (c *Client) StreamBandwidthTest(ctx context.Context, opts BandwidthTestOptions) (<-chan BandwidthTestResult, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	in, err := c.listen(ctx, opts.args())
+	if err != nil {
+		return nil, fmt.Errorf("failed to start bandwidth test to %s: %w", opts.Address, err)
+	}
+
+	out := make(chan BandwidthTestResult)
+	go func() {
+		defer close(out)
+
+		for m := range in {
+			select {
+			case out <- mapToBandwidthTestResult(m):
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func mapToBandwidthTestResult(m map[string]string) BandwidthTestResult {
+	return BandwidthTestResult{
+		Status:        m["status"],
+		Duration:      m["duration"],
+		Direction:     m["direction"],
+		TxCurrentBps:  parseUint(m["tx-current"]),
+		Tx10sAvgBps:   parseUint(m["tx-10-second-average"]),
+		TxAverageBps:  parseUint(m["tx-total-average"]),
+		RxCurrentBps:  parseUint(m["rx-current"]),
+		Rx10sAvgBps:   parseUint(m["rx-10-second-average"]),
+		RxAverageBps:  parseUint(m["rx-total-average"]),
+		LostPackets:   parseUint(m["lost-packets"]),
+		LocalCPULoad:  m["local-cpu-load"],
+		RemoteCPULoad: m["remote-cpu-load"],
+	}
+}
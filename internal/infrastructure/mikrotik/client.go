@@ -4,13 +4,18 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"mikrotik-collector/internal/infrastructure/metrics"
+
 	"github.com/go-routeros/routeros/v3"
 )
 
 // Config holds MikroTik connection configuration
 type Config struct {
+	RouterID string // stable identifier used to key per-router data (plans, pools, history)
 	Host     string
 	Port     int
 	Username string
@@ -21,21 +26,35 @@ type Config struct {
 }
 
 // Client wraps *routeros.Client to make it reusable and configurable.
+// The connection is shared by concurrent callers and replaced by Reconnect,
+// so it is only read through conn.
 type Client struct {
 	*routeros.Client        // embedded → all default methods available!
 	Config           Config // Expose config for creating new instances
+
+	mu       sync.RWMutex // guards the embedded connection
+	checking atomic.Bool  // a CheckAPI is waiting for the router
 }
 
 // NewClient creates and returns a new MikroTik client.
 func NewClient(cfg Config) (*Client, error) {
 	client := &Client{Config: cfg}
-	if err := client.connect(); err != nil {
+	conn, err := client.dial()
+	if err != nil {
 		return nil, err
 	}
+	client.Client = conn
 	return client, nil
 }
 
-func (c *Client) connect() error {
+// conn returns the current connection
+func (c *Client) conn() *routeros.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Client
+}
+
+func (c *Client) dial() (*routeros.Client, error) {
 	address := fmt.Sprintf("%s:%d", c.Config.Host, c.Config.Port)
 
 	var (
@@ -62,34 +81,71 @@ func (c *Client) connect() error {
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to connect to MikroTik: %w", err)
+		return nil, fmt.Errorf("failed to connect to MikroTik: %w", err)
 	}
 
 	if c.Config.Queue > 0 {
 		conn.Queue = c.Config.Queue
 	}
 
-	c.Client = conn
-	return nil
+	// Async mode tags every command, so concurrent callers (probe workers,
+	// handlers, listens) each get their own reply. Without it the connection
+	// is only async once a listen has started.
+	conn.Async()
+	return conn, nil
 }
 
 // Reconnect attempts to re-establish the connection
 func (c *Client) Reconnect() error {
+	return c.reconnect(c.conn())
+}
+
+// reconnect replaces failed with a new connection. When several callers saw
+// the same connection fail, only the first one dials; the others find the
+// connection already replaced and use the new one.
+func (c *Client) reconnect(failed *routeros.Client) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Client != failed {
+		return nil
+	}
 	if c.Client != nil {
 		c.Client.Close()
 	}
-	return c.connect()
+	conn, err := c.dial()
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	} else {
+		c.Client = conn
+	}
+	metrics.RouterReconnects.WithLabelValues(c.Config.RouterID, result).Inc()
+	return err
+}
+
+// Close closes the current connection
+func (c *Client) Close() error {
+	return c.conn().Close()
 }
 
 // Run overrides routeros.Client.Run with auto-reconnection support
 func (c *Client) Run(sentence ...string) (*routeros.Reply, error) {
-	reply, err := c.Client.Run(sentence...)
+	return c.RunArgs(sentence)
+}
+
+// RunArgs overrides routeros.Client.RunArgs with auto-reconnection support and
+// records the command's latency and errors
+func (c *Client) RunArgs(sentence []string) (*routeros.Reply, error) {
+	conn := c.conn()
+	reply, err := c.runOn(conn, sentence)
 	if err != nil {
 		if isConnectionError(err) {
 			// Try to reconnect
-			if recErr := c.Reconnect(); recErr == nil {
+			if recErr := c.reconnect(conn); recErr == nil {
 				// Retry command
-				return c.Client.Run(sentence...)
+				return c.runArgs(sentence)
 			}
 		}
 		return nil, err
@@ -97,6 +153,75 @@ func (c *Client) Run(sentence ...string) (*routeros.Reply, error) {
 	return reply, nil
 }
 
+// ListenArgs overrides routeros.Client.ListenArgs with auto-reconnection support
+func (c *Client) ListenArgs(sentence []string) (*routeros.ListenReply, error) {
+	conn := c.conn()
+	reply, err := conn.ListenArgs(sentence)
+	if err != nil && isConnectionError(err) {
+		if recErr := c.reconnect(conn); recErr == nil {
+			return c.conn().ListenArgs(sentence)
+		}
+	}
+	return reply, err
+}
+
+// ListenArgsContext overrides routeros.Client.ListenArgsContext with auto-reconnection support
+func (c *Client) ListenArgsContext(ctx context.Context, sentence []string) (*routeros.ListenReply, error) {
+	conn := c.conn()
+	reply, err := conn.ListenArgsContext(ctx, sentence)
+	if err != nil && isConnectionError(err) {
+		if recErr := c.reconnect(conn); recErr == nil {
+			return c.conn().ListenArgsContext(ctx, sentence)
+		}
+	}
+	return reply, err
+}
+
+// runArgs runs one command on the current connection and records it
+func (c *Client) runArgs(sentence []string) (*routeros.Reply, error) {
+	return c.runOn(c.conn(), sentence)
+}
+
+// runOn runs one command on conn and records it
+func (c *Client) runOn(conn *routeros.Client, sentence []string) (*routeros.Reply, error) {
+	command := ""
+	if len(sentence) > 0 {
+		command = sentence[0]
+	}
+
+	start := time.Now()
+	reply, err := conn.RunArgs(sentence)
+	metrics.RouterRequestDuration.WithLabelValues(c.Config.RouterID, command).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.RouterRequestErrors.WithLabelValues(c.Config.RouterID, command).Inc()
+	}
+	return reply, err
+}
+
+// CheckAPI checks that the router answers API commands, without reconnecting.
+// It gives up when ctx is done; RunArgsContext is not used because cancelling
+// it cancels the reader shared by every listen on the connection. A check still
+// waiting for the router makes the next one fail instead of piling up.
+func (c *Client) CheckAPI(ctx context.Context) error {
+	if !c.checking.CompareAndSwap(false, true) {
+		return fmt.Errorf("previous check is still waiting for the router")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer c.checking.Store(false)
+		_, err := c.runArgs([]string{"/system/identity/print"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("router did not answer: %w", ctx.Err())
+	}
+}
+
 func isConnectionError(err error) bool {
 	msg := err.Error()
 	return strings.Contains(msg, "loop has ended") ||
@@ -0,0 +1,117 @@
+package mikrotik
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration parses RouterOS duration notation such as "1w2d3h4m5s",
+// "12ms", "1ms473us" or the clock form "2d04:05:06".
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total time.Duration
+
+	// Trailing clock part: [prefix]hh:mm:ss
+	if i := strings.Index(s, ":"); i >= 0 {
+		start := i
+		for start > 0 && s[start-1] >= '0' && s[start-1] <= '9' {
+			start--
+		}
+		clock := strings.Split(s[start:], ":")
+		if len(clock) != 3 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		h, err1 := strconv.Atoi(clock[0])
+		m, err2 := strconv.Atoi(clock[1])
+		sec, err3 := strconv.ParseFloat(clock[2], 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		total = time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+			time.Duration(sec*float64(time.Second))
+		s = s[:start]
+	}
+
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		value, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+
+		j := i
+		for j < len(s) && (s[j] < '0' || s[j] > '9') && s[j] != '.' {
+			j++
+		}
+
+		var unit time.Duration
+		switch s[i:j] {
+		case "w":
+			unit = 7 * 24 * time.Hour
+		case "d":
+			unit = 24 * time.Hour
+		case "h":
+			unit = time.Hour
+		case "m":
+			unit = time.Minute
+		case "s", "":
+			unit = time.Second
+		case "ms":
+			unit = time.Millisecond
+		case "us", "µs":
+			unit = time.Microsecond
+		case "ns":
+			unit = time.Nanosecond
+		default:
+			return 0, fmt.Errorf("invalid duration unit %q", s[i:j])
+		}
+
+		total += time.Duration(value * float64(unit))
+		s = s[j:]
+	}
+
+	return total, nil
+}
+
+// FormatDuration formats d in RouterOS notation (e.g. "1d2h30m")
+func FormatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+
+	var b strings.Builder
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"w", 7 * 24 * time.Hour},
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	for _, u := range units {
+		if d >= u.size {
+			n := d / u.size
+			d -= n * u.size
+			b.WriteString(strconv.FormatInt(int64(n), 10))
+			b.WriteString(u.suffix)
+		}
+	}
+	if b.Len() == 0 {
+		return "0s"
+	}
+	return b.String()
+}
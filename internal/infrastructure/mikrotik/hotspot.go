@@ -0,0 +1,171 @@
+package mikrotik
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// HotspotUser represents an /ip/hotspot/user entry
+type HotspotUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Password string `json:"password,omitempty"`
+	Profile  string `json:"profile"`
+	Server   string `json:"server"`
+
+	// MacAddress binds the user to a single device
+	MacAddress string `json:"mac_address"`
+	// Address assigns a static IP to the user
+	Address string `json:"address"`
+
+	LimitUptime     string `json:"limit_uptime"`      // e.g. "1h", "1d"
+	LimitBytesTotal uint64 `json:"limit_bytes_total"` // 0 = unlimited
+	Comment         string `json:"comment"`
+	Disabled        bool   `json:"disabled"`
+
+	// Counters (read only)
+	Uptime   string `json:"uptime"`
+	BytesIn  uint64 `json:"bytes_in"`
+	BytesOut uint64 `json:"bytes_out"`
+}
+
+func (u HotspotUser) args() []string {
+	var args []string
+
+	if u.Name != "" {
+		args = append(args, "=name="+u.Name)
+	}
+	if u.Password != "" {
+		args = append(args, "=password="+u.Password)
+	}
+	if u.Profile != "" {
+		args = append(args, "=profile="+u.Profile)
+	}
+	if u.Server != "" {
+		args = append(args, "=server="+u.Server)
+	}
+	if u.MacAddress != "" {
+		args = append(args, "=mac-address="+u.MacAddress)
+	}
+	if u.Address != "" {
+		args = append(args, "=address="+u.Address)
+	}
+	if u.LimitUptime != "" {
+		args = append(args, "=limit-uptime="+u.LimitUptime)
+	}
+	if u.LimitBytesTotal > 0 {
+		args = append(args, "=limit-bytes-total="+strconv.FormatUint(u.LimitBytesTotal, 10))
+	}
+	if u.Comment != "" {
+		args = append(args, "=comment="+u.Comment)
+	}
+
+	return args
+}
+
+// CreateHotspotUser creates a new hotspot user and returns its ID
+func (c *Client) CreateHotspotUser(u HotspotUser) (string, error) {
+	if u.Name == "" {
+		return "", fmt.Errorf("hotspot user name is required")
+	}
+
+	cmd := append([]string{"/ip/hotspot/user/add"}, u.args()...)
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to create hotspot user: %w", err)
+	}
+
+	return r.Done.Map["ret"], nil
+}
+
+// UpdateHotspotUser updates an existing hotspot user. Empty fields are left unchanged.
+func (c *Client) UpdateHotspotUser(id string, u HotspotUser) error {
+	cmd := append([]string{"/ip/hotspot/user/set", "=.id=" + id}, u.args()...)
+
+	_, err := c.RunArgs(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to update hotspot user: %w", err)
+	}
+	return nil
+}
+
+// PatchHotspotUser sets only the given properties (keyed by RouterOS name, e.g. "mac-address").
+// An empty value unsets the property on the router.
+func (c *Client) PatchHotspotUser(id string, changes map[string]string) error {
+	if err := c.setOrUnset("/ip/hotspot/user", id, changes, "password"); err != nil {
+		return fmt.Errorf("failed to patch hotspot user: %w", err)
+	}
+	return nil
+}
+
+// DeleteHotspotUser deletes a hotspot user by ID
+func (c *Client) DeleteHotspotUser(id string) error {
+	_, err := c.RunArgs([]string{
+		"/ip/hotspot/user/remove",
+		"=.id=" + id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete hotspot user: %w", err)
+	}
+	return nil
+}
+
+// FindHotspotUserID returns the ID of a hotspot user by name
+func (c *Client) FindHotspotUserID(name string) (string, error) {
+	r, err := c.RunArgs([]string{
+		"/ip/hotspot/user/print",
+		"?name=" + name,
+		"=.proplist=.id",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(r.Re) == 0 {
+		return "", nil // Not found
+	}
+
+	return r.Re[0].Map[".id"], nil
+}
+
+// ListHotspotUsers returns hotspot users, optionally filtered by exact comment
+func (c *Client) ListHotspotUsers(comment string) ([]HotspotUser, error) {
+	cmd := []string{"/ip/hotspot/user/print"}
+	if comment != "" {
+		cmd = append(cmd, "?comment="+comment)
+	}
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hotspot users: %w", err)
+	}
+
+	users := make([]HotspotUser, 0, len(r.Re))
+	for _, re := range r.Re {
+		users = append(users, mapToHotspotUser(re.Map))
+	}
+	return users, nil
+}
+
+func mapToHotspotUser(m map[string]string) HotspotUser {
+	limitBytes, _ := strconv.ParseUint(m["limit-bytes-total"], 10, 64)
+	bytesIn, _ := strconv.ParseUint(m["bytes-in"], 10, 64)
+	bytesOut, _ := strconv.ParseUint(m["bytes-out"], 10, 64)
+
+	return HotspotUser{
+		ID:              m[".id"],
+		Name:            m["name"],
+		Profile:         m["profile"],
+		Server:          m["server"],
+		MacAddress:      m["mac-address"],
+		Address:         m["address"],
+		LimitUptime:     m["limit-uptime"],
+		LimitBytesTotal: limitBytes,
+		Comment:         m["comment"],
+		Disabled:        m["disabled"] == "true",
+		Uptime:          m["uptime"],
+		BytesIn:         bytesIn,
+		BytesOut:        bytesOut,
+	}
+}
@@ -0,0 +1,294 @@
+package mikrotik
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// IPPool represents an /ip/pool entry
+type IPPool struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Ranges   string `json:"ranges"` // e.g. "10.0.0.2-10.0.0.254,10.0.1.0/24"
+	NextPool string `json:"next_pool"`
+	Comment  string `json:"comment"`
+}
+
+// IPPoolUsed represents an /ip/pool/used entry (an address handed out from a pool)
+type IPPoolUsed struct {
+	Pool    string `json:"pool"`
+	Address string `json:"address"`
+	Owner   string `json:"owner"`
+	Info    string `json:"info"`
+}
+
+// PoolUtilization summarizes used vs. free addresses of a pool
+type PoolUtilization struct {
+	Pool        string  `json:"pool"`
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Free        uint64  `json:"free"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// AddressRange is an inclusive IPv4 address range
+type AddressRange struct {
+	Start netip.Addr `json:"start"`
+	End   netip.Addr `json:"end"`
+}
+
+// Size returns the number of addresses in the range
+func (r AddressRange) Size() uint64 {
+	return uint64(addrToUint32(r.End)-addrToUint32(r.Start)) + 1
+}
+
+// Contains reports whether addr is inside the range
+func (r AddressRange) Contains(addr netip.Addr) bool {
+	return addr.Is4() && addr.Compare(r.Start) >= 0 && addr.Compare(r.End) <= 0
+}
+
+// Overlaps reports whether two ranges share at least one address
+func (r AddressRange) Overlaps(other AddressRange) bool {
+	return r.Start.Compare(other.End) <= 0 && other.Start.Compare(r.End) <= 0
+}
+
+// ParsePoolRanges parses the RouterOS ranges notation: comma-separated
+// single addresses, "start-end" ranges or CIDR prefixes.
+func ParsePoolRanges(ranges string) ([]AddressRange, error) {
+	var result []AddressRange
+
+	for _, part := range strings.Split(ranges, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		var r AddressRange
+		switch {
+		case strings.Contains(part, "/"):
+			prefix, err := netip.ParsePrefix(part)
+			if err != nil || !prefix.Addr().Is4() {
+				return nil, fmt.Errorf("invalid pool range %q", part)
+			}
+			r = PrefixRange(prefix)
+
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			start, err1 := netip.ParseAddr(strings.TrimSpace(bounds[0]))
+			end, err2 := netip.ParseAddr(strings.TrimSpace(bounds[1]))
+			if err1 != nil || err2 != nil || !start.Is4() || !end.Is4() || end.Less(start) {
+				return nil, fmt.Errorf("invalid pool range %q", part)
+			}
+			r = AddressRange{Start: start, End: end}
+
+		default:
+			addr, err := netip.ParseAddr(part)
+			if err != nil || !addr.Is4() {
+				return nil, fmt.Errorf("invalid pool address %q", part)
+			}
+			r = AddressRange{Start: addr, End: addr}
+		}
+
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// PrefixRange returns the full address range covered by an IPv4 prefix
+func PrefixRange(prefix netip.Prefix) AddressRange {
+	prefix = prefix.Masked()
+	start := addrToUint32(prefix.Addr())
+	size := uint32(1) << (32 - prefix.Bits())
+	return AddressRange{
+		Start: prefix.Addr(),
+		End:   uint32ToAddr(start + size - 1),
+	}
+}
+
+func addrToUint32(addr netip.Addr) uint32 {
+	b := addr.As4()
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func uint32ToAddr(v uint32) netip.Addr {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return netip.AddrFrom4(b)
+}
+
+// ListIPPools returns all IP pools
+func (c *Client) ListIPPools() ([]IPPool, error) {
+	r, err := c.RunArgs([]string{"/ip/pool/print"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ip pools: %w", err)
+	}
+
+	pools := make([]IPPool, 0, len(r.Re))
+	for _, re := range r.Re {
+		pools = append(pools, mapToIPPool(re.Map))
+	}
+	return pools, nil
+}
+
+// GetIPPool returns an IP pool by ID (nil if not found)
+func (c *Client) GetIPPool(id string) (*IPPool, error) {
+	r, err := c.RunArgs([]string{
+		"/ip/pool/print",
+		"?.id=" + id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ip pool: %w", err)
+	}
+
+	if len(r.Re) == 0 {
+		return nil, nil // Not found
+	}
+
+	pool := mapToIPPool(r.Re[0].Map)
+	return &pool, nil
+}
+
+// CreateIPPool creates a new IP pool and returns its ID
+func (c *Client) CreateIPPool(p IPPool) (string, error) {
+	if p.Name == "" || p.Ranges == "" {
+		return "", fmt.Errorf("ip pool name and ranges are required")
+	}
+	if _, err := ParsePoolRanges(p.Ranges); err != nil {
+		return "", err
+	}
+
+	cmd := append([]string{"/ip/pool/add"}, p.args()...)
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to create ip pool: %w", err)
+	}
+
+	return r.Done.Map["ret"], nil
+}
+
+// UpdateIPPool updates an existing IP pool. Empty fields are left unchanged.
+func (c *Client) UpdateIPPool(id string, p IPPool) error {
+	if p.Ranges != "" {
+		if _, err := ParsePoolRanges(p.Ranges); err != nil {
+			return err
+		}
+	}
+
+	cmd := append([]string{"/ip/pool/set", "=.id=" + id}, p.args()...)
+
+	_, err := c.RunArgs(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to update ip pool: %w", err)
+	}
+	return nil
+}
+
+// DeleteIPPool deletes an IP pool by ID
+func (c *Client) DeleteIPPool(id string) error {
+	_, err := c.RunArgs([]string{
+		"/ip/pool/remove",
+		"=.id=" + id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete ip pool: %w", err)
+	}
+	return nil
+}
+
+// ListIPPoolUsed returns addresses currently handed out, optionally filtered by pool name
+func (c *Client) ListIPPoolUsed(pool string) ([]IPPoolUsed, error) {
+	cmd := []string{"/ip/pool/used/print"}
+	if pool != "" {
+		cmd = append(cmd, "?pool="+pool)
+	}
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list used pool addresses: %w", err)
+	}
+
+	used := make([]IPPoolUsed, 0, len(r.Re))
+	for _, re := range r.Re {
+		used = append(used, IPPoolUsed{
+			Pool:    re.Map["pool"],
+			Address: re.Map["address"],
+			Owner:   re.Map["owner"],
+			Info:    re.Map["info"],
+		})
+	}
+	return used, nil
+}
+
+// PoolUtilizations computes used vs. free addresses for every pool
+func (c *Client) PoolUtilizations() (map[string]PoolUtilization, error) {
+	pools, err := c.ListIPPools()
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := c.ListIPPoolUsed("")
+	if err != nil {
+		return nil, err
+	}
+
+	usedByPool := make(map[string]uint64)
+	for _, u := range used {
+		usedByPool[u.Pool]++
+	}
+
+	result := make(map[string]PoolUtilization, len(pools))
+	for _, p := range pools {
+		util := PoolUtilization{Pool: p.Name, Used: usedByPool[p.Name]}
+
+		ranges, err := ParsePoolRanges(p.Ranges)
+		if err == nil {
+			for _, r := range ranges {
+				util.Total += r.Size()
+			}
+		}
+
+		if util.Total >= util.Used {
+			util.Free = util.Total - util.Used
+		}
+		if util.Total > 0 {
+			util.UsedPercent = float64(util.Used) / float64(util.Total) * 100
+		}
+
+		result[p.Name] = util
+	}
+
+	return result, nil
+}
+
+func (p IPPool) args() []string {
+	var args []string
+
+	if p.Name != "" {
+		args = append(args, "=name="+p.Name)
+	}
+	if p.Ranges != "" {
+		args = append(args, "=ranges="+p.Ranges)
+	}
+	if p.NextPool != "" {
+		args = append(args, "=next-pool="+p.NextPool)
+	}
+	if p.Comment != "" {
+		args = append(args, "=comment="+p.Comment)
+	}
+
+	return args
+}
+
+func mapToIPPool(m map[string]string) IPPool {
+	return IPPool{
+		ID:       m[".id"],
+		Name:     m["name"],
+		Ranges:   m["ranges"],
+		NextPool: m["next-pool"],
+		Comment:  m["comment"],
+	}
+}
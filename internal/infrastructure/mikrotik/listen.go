@@ -0,0 +1,99 @@
+package mikrotik
+
+import (
+	"context"
+	"log"
+	"strconv"
+)
+
+// listen runs a RouterOS listen command and forwards its reply maps until the
+// command finishes or ctx is cancelled. The listen is started without a context
+// and stopped with /cancel, because cancelling a listen context tears down the
+// whole async connection shared by every other stream.
+func (c *Client) listen(ctx context.Context, args []string) (<-chan map[string]string, error) {
+	reply, err := c.ListenArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			if _, err := reply.Cancel(); err != nil {
+				log.Printf("[MikroTik] Failed to cancel %s: %v", args[0], err)
+			}
+		case <-finished:
+		}
+	}()
+
+	out := make(chan map[string]string)
+	go func() {
+		defer close(out)
+		defer close(finished)
+
+		// Keep draining until RouterOS confirms the cancel: an unread reply
+		// blocks the async loop for every other command on the connection
+		for r := range reply.Chan() {
+			if r == nil || r.Map == nil {
+				continue
+			}
+			select {
+			case out <- r.Map:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// sections groups the rows of tools that redraw a table on every update
+// (traceroute, torch) by their ".section" number. A section is emitted when
+// the next one starts, and the last one when the command finishes.
+func sections(ctx context.Context, in <-chan map[string]string) <-chan []map[string]string {
+	out := make(chan []map[string]string)
+
+	go func() {
+		defer close(out)
+
+		emit := func(rows []map[string]string) {
+			if len(rows) == 0 || ctx.Err() != nil {
+				return
+			}
+			select {
+			case out <- rows:
+			case <-ctx.Done():
+			}
+		}
+
+		var rows []map[string]string
+		current := ""
+		for m := range in {
+			section := m[".section"]
+			if section == "" {
+				// Not a sectioned table; every row stands alone
+				emit([]map[string]string{m})
+				continue
+			}
+			if section != current && len(rows) > 0 {
+				emit(rows)
+				rows = nil
+			}
+			current = section
+			rows = append(rows, m)
+		}
+		emit(rows)
+	}()
+
+	return out
+}
+
+// sectionNumber parses a ".section" value, 0 when absent
+func sectionNumber(rows []map[string]string) int {
+	if len(rows) == 0 {
+		return 0
+	}
+	n, _ := strconv.Atoi(rows[0][".section"])
+	return n
+}
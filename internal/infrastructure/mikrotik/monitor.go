@@ -2,28 +2,32 @@ package mikrotik
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 )
 
-// InterfaceTraffic represents traffic data from MikroTik monitor-traffic command
+// InterfaceTraffic represents traffic data from MikroTik monitor-traffic command.
+// Rates are per second; fast-path (Fp*) counters are only non-zero when fasttrack/fastpath is active.
 type InterfaceTraffic struct {
 	Name string
 
-	RxPacketsPerSecond   string
-	RxBitsPerSecond      string
-	FpRxPacketsPerSecond string
-	FpRxBitsPerSecond    string
+	RxPacketsPerSecond   uint64
+	RxBitsPerSecond      uint64
+	FpRxPacketsPerSecond uint64
+	FpRxBitsPerSecond    uint64
 
-	RxDropsPerSecond  string
-	RxErrorsPerSecond string
+	RxDropsPerSecond  uint64
+	RxErrorsPerSecond uint64
 
-	TxPacketsPerSecond   string
-	TxBitsPerSecond      string
-	FpTxPacketsPerSecond string
-	FpTxBitsPerSecond    string
+	TxPacketsPerSecond   uint64
+	TxBitsPerSecond      uint64
+	FpTxPacketsPerSecond uint64
+	FpTxBitsPerSecond    uint64
 
-	TxDropsPerSecond      string
-	TxQueueDropsPerSecond string
-	TxErrorsPerSecond     string
+	TxDropsPerSecond      uint64
+	TxQueueDropsPerSecond uint64
+	TxErrorsPerSecond     uint64
 
 	Section string
 }
@@ -40,18 +44,6 @@ func MonitorTraffic(
 		"/interface/monitor-traffic",
 		"=interface=" + iface,
 	})
-	if err != nil {
-		if isConnectionError(err) {
-			// Try to reconnect
-			if recErr := client.Reconnect(); recErr == nil {
-				// Retry command
-				reply, err = client.ListenArgsContext(ctx, []string{
-					"/interface/monitor-traffic",
-					"=interface=" + iface,
-				})
-			}
-		}
-	}
 	if err != nil {
 		return nil, err
 	}
@@ -86,23 +78,84 @@ func mapToInterfaceTraffic(m map[string]string) InterfaceTraffic {
 	return InterfaceTraffic{
 		Name: m["name"],
 
-		RxPacketsPerSecond:   m["rx-packets-per-second"],
-		RxBitsPerSecond:      m["rx-bits-per-second"],
-		FpRxPacketsPerSecond: m["fp-rx-packets-per-second"],
-		FpRxBitsPerSecond:    m["fp-rx-bits-per-second"],
+		RxPacketsPerSecond:   parseUint(m["rx-packets-per-second"]),
+		RxBitsPerSecond:      parseUint(m["rx-bits-per-second"]),
+		FpRxPacketsPerSecond: parseUint(m["fp-rx-packets-per-second"]),
+		FpRxBitsPerSecond:    parseUint(m["fp-rx-bits-per-second"]),
 
-		RxDropsPerSecond:  m["rx-drops-per-second"],
-		RxErrorsPerSecond: m["rx-errors-per-second"],
+		RxDropsPerSecond:  parseUint(m["rx-drops-per-second"]),
+		RxErrorsPerSecond: parseUint(m["rx-errors-per-second"]),
 
-		TxPacketsPerSecond:   m["tx-packets-per-second"],
-		TxBitsPerSecond:      m["tx-bits-per-second"],
-		FpTxPacketsPerSecond: m["fp-tx-packets-per-second"],
-		FpTxBitsPerSecond:    m["fp-tx-bits-per-second"],
+		TxPacketsPerSecond:   parseUint(m["tx-packets-per-second"]),
+		TxBitsPerSecond:      parseUint(m["tx-bits-per-second"]),
+		FpTxPacketsPerSecond: parseUint(m["fp-tx-packets-per-second"]),
+		FpTxBitsPerSecond:    parseUint(m["fp-tx-bits-per-second"]),
 
-		TxDropsPerSecond:      m["tx-drops-per-second"],
-		TxQueueDropsPerSecond: m["tx-queue-drops-per-second"],
-		TxErrorsPerSecond:     m["tx-errors-per-second"],
+		TxDropsPerSecond:      parseUint(m["tx-drops-per-second"]),
+		TxQueueDropsPerSecond: parseUint(m["tx-queue-drops-per-second"]),
+		TxErrorsPerSecond:     parseUint(m["tx-errors-per-second"]),
 
 		Section: m[".section"],
 	}
 }
+
+// parseUint parses a RouterOS counter, treating empty or malformed values as 0
+func parseUint(s string) uint64 {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// sampleChunkSize bounds the interface list of a single monitor-traffic call
+const sampleChunkSize = 50
+
+// SampleTraffic takes one monitor-traffic sample ("once") for each interface.
+// Interfaces are queried in chunks; one sentence is returned per interface.
+func (c *Client) SampleTraffic(ifaces []string) ([]InterfaceTraffic, error) {
+	samples := make([]InterfaceTraffic, 0, len(ifaces))
+
+	for start := 0; start < len(ifaces); start += sampleChunkSize {
+		end := start + sampleChunkSize
+		if end > len(ifaces) {
+			end = len(ifaces)
+		}
+
+		r, err := c.RunArgs([]string{
+			"/interface/monitor-traffic",
+			"=interface=" + strings.Join(ifaces[start:end], ","),
+			"=once=",
+		})
+		if err != nil {
+			return samples, fmt.Errorf("failed to sample traffic: %w", err)
+		}
+
+		for _, re := range r.Re {
+			samples = append(samples, mapToInterfaceTraffic(re.Map))
+		}
+	}
+
+	return samples, nil
+}
+
+// ListRunningInterfaces returns the names of running interfaces of the given type
+// (e.g. "pppoe-in"), or of every type when ifaceType is empty
+func (c *Client) ListRunningInterfaces(ifaceType string) ([]string, error) {
+	sentence := []string{"/interface/print"}
+	if ifaceType != "" {
+		sentence = append(sentence, "?type="+ifaceType)
+	}
+	r, err := c.RunArgs(append(sentence, "?running=yes", "=.proplist=name"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interfaces: %w", err)
+	}
+
+	names := make([]string, 0, len(r.Re))
+	for _, re := range r.Re {
+		if name := re.Map["name"]; name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
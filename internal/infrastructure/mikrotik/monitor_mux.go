@@ -0,0 +1,299 @@
+package mikrotik
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// muxRebuildDelay coalesces subscribe/unsubscribe bursts into one rebuild
+const muxRebuildDelay = 200 * time.Millisecond
+
+// muxRetryDelay is the wait before re-listening after the stream failed;
+// a variable so tests do not have to wait for it
+var muxRetryDelay = 5 * time.Second
+
+// TrafficMux shares a single /interface/monitor-traffic listen across all watched
+// interfaces of a router. RouterOS accepts a comma-separated interface= list and
+// answers with one sentence per interface, which is fanned out by name.
+// The listen is rebuilt whenever the set of watched interfaces changes.
+type TrafficMux struct {
+	client *Client
+
+	mu      sync.Mutex
+	subs    map[string]map[chan InterfaceTraffic]struct{} // interface -> subscribers
+	current *muxListen
+	gen     int
+	timer   *time.Timer
+	closed  bool
+}
+
+// muxListen is one running monitor-traffic listen
+type muxListen struct {
+	gen    int
+	ifaces []string
+	stop   chan struct{}
+}
+
+// NewTrafficMux creates a traffic multiplexer for a router
+func NewTrafficMux(client *Client) *TrafficMux {
+	return &TrafficMux{
+		client: client,
+		subs:   make(map[string]map[chan InterfaceTraffic]struct{}),
+	}
+}
+
+// MonitorTraffic subscribes to traffic samples for iface. Like the standalone
+// MonitorTraffic, the channel is closed when ctx is cancelled; it is also closed
+// when the interface disappears from the router (e.g. the PPPoE session ended).
+func (m *TrafficMux) MonitorTraffic(ctx context.Context, iface string) (<-chan InterfaceTraffic, error) {
+	ch := make(chan InterfaceTraffic, 16)
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, context.Canceled
+	}
+	set, ok := m.subs[iface]
+	if !ok {
+		set = make(map[chan InterfaceTraffic]struct{})
+		m.subs[iface] = set
+		m.scheduleRebuild(muxRebuildDelay)
+	}
+	set[ch] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.unsubscribe(iface, ch)
+	}()
+
+	return ch, nil
+}
+
+// Interfaces returns the interfaces covered by the current listen
+func (m *TrafficMux) Interfaces() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.current == nil {
+		return nil
+	}
+	return append([]string(nil), m.current.ifaces...)
+}
+
+// Close stops the listen and closes every subscriber channel
+func (m *TrafficMux) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	m.stopCurrent()
+	for iface := range m.subs {
+		m.dropInterface(iface)
+	}
+}
+
+func (m *TrafficMux) unsubscribe(iface string, ch chan InterfaceTraffic) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	set, ok := m.subs[iface]
+	if !ok {
+		return
+	}
+	if _, ok := set[ch]; !ok {
+		return // already closed by dropInterface
+	}
+
+	delete(set, ch)
+	close(ch)
+
+	if len(set) == 0 {
+		delete(m.subs, iface)
+		m.scheduleRebuild(muxRebuildDelay)
+	}
+}
+
+// scheduleRebuild arranges a rebuild after delay unless one is already pending. Caller holds m.mu.
+func (m *TrafficMux) scheduleRebuild(delay time.Duration) {
+	if m.closed || m.timer != nil {
+		return
+	}
+	m.timer = time.AfterFunc(delay, m.rebuild)
+}
+
+// rebuild replaces the running listen with one covering the current interface set
+func (m *TrafficMux) rebuild() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.timer = nil
+	if m.closed {
+		return
+	}
+
+	ifaces := make([]string, 0, len(m.subs))
+	for iface := range m.subs {
+		ifaces = append(ifaces, iface)
+	}
+	sort.Strings(ifaces)
+
+	if m.current != nil && equalStrings(m.current.ifaces, ifaces) {
+		return
+	}
+
+	m.stopCurrent()
+	if len(ifaces) == 0 {
+		log.Printf("[TrafficMux] Router %s: no watched interfaces, listen stopped", m.client.Config.RouterID)
+		return
+	}
+
+	m.gen++
+	l := &muxListen{gen: m.gen, ifaces: ifaces, stop: make(chan struct{})}
+	m.current = l
+
+	log.Printf("[TrafficMux] Router %s: monitoring %d interfaces", m.client.Config.RouterID, len(ifaces))
+	go m.listen(l)
+}
+
+// stopCurrent signals the running listen to cancel. Caller holds m.mu.
+func (m *TrafficMux) stopCurrent() {
+	if m.current != nil {
+		close(m.current.stop)
+		m.current = nil
+	}
+}
+
+// dropInterface closes and removes all subscribers of iface. Caller holds m.mu.
+func (m *TrafficMux) dropInterface(iface string) {
+	for ch := range m.subs[iface] {
+		close(ch)
+	}
+	delete(m.subs, iface)
+}
+
+func (m *TrafficMux) listen(l *muxListen) {
+	// Listen without a context: cancelling a listen context tears down the
+	// whole async connection, so the listen is stopped with /cancel instead.
+	args := []string{
+		"/interface/monitor-traffic",
+		"=interface=" + strings.Join(l.ifaces, ","),
+	}
+
+	reply, err := m.client.ListenArgs(args)
+	if err != nil {
+		m.handleFailure(l, err)
+		return
+	}
+
+	// Cancel the listen when it is replaced; a listen that ended on its own
+	// needs no cancel, so the goroutine also exits once the reply is drained
+	ended := make(chan struct{})
+	go func() {
+		select {
+		case <-l.stop:
+			if _, err := reply.Cancel(); err != nil {
+				log.Printf("[TrafficMux] Failed to cancel listen: %v", err)
+			}
+		case <-ended:
+		}
+	}()
+
+	for r := range reply.Chan() {
+		if r == nil || r.Map == nil {
+			continue
+		}
+		m.dispatch(mapToInterfaceTraffic(r.Map))
+	}
+	close(ended)
+
+	select {
+	case <-l.stop:
+		return // replaced or closed
+	default:
+	}
+
+	err = reply.Err()
+	if err == nil {
+		err = context.Canceled
+	}
+	m.handleFailure(l, err)
+}
+
+func (m *TrafficMux) dispatch(t InterfaceTraffic) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for ch := range m.subs[t.Name] {
+		select {
+		case ch <- t:
+		default:
+			// Skip slow subscriber rather than stall the shared stream
+		}
+	}
+}
+
+// handleFailure drops interfaces that no longer exist on the router and retries the rest.
+// A single vanished interface makes RouterOS reject the whole list, so this keeps one
+// disconnected customer from stopping everyone else's stream.
+func (m *TrafficMux) handleFailure(l *muxListen, err error) {
+	log.Printf("[TrafficMux] Router %s: listen ended: %v", m.client.Config.RouterID, err)
+
+	running, lookupErr := m.runningInterfaces()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.current == nil || m.current.gen != l.gen {
+		return // superseded by a newer listen
+	}
+	m.current = nil
+
+	if lookupErr == nil {
+		for _, iface := range l.ifaces {
+			if !running[iface] {
+				log.Printf("[TrafficMux] Interface %s is gone, closing its subscribers", iface)
+				m.dropInterface(iface)
+			}
+		}
+	}
+
+	m.scheduleRebuild(muxRetryDelay)
+}
+
+func (m *TrafficMux) runningInterfaces() (map[string]bool, error) {
+	r, err := m.client.RunArgs([]string{
+		"/interface/print",
+		"?running=yes",
+		"=.proplist=name",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[string]bool, len(r.Re))
+	for _, re := range r.Re {
+		running[re.Map["name"]] = true
+	}
+	return running, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
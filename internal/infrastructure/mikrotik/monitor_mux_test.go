@@ -0,0 +1,302 @@
+package mikrotik
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-routeros/routeros/v3/proto"
+)
+
+// fakeRouter answers the API commands the mux uses: login, monitor-traffic
+// listens, cancel and the running interface list
+type fakeRouter struct {
+	t  *testing.T
+	ln net.Listener
+
+	mu      sync.Mutex
+	w       proto.Writer
+	running []string
+
+	listens chan fakeListen
+	cancels chan string // tags of cancelled listens
+}
+
+type fakeListen struct {
+	tag    string
+	ifaces string
+}
+
+func newFakeRouter(t *testing.T) *fakeRouter {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRouter{
+		t:       t,
+		ln:      ln,
+		listens: make(chan fakeListen, 16),
+		cancels: make(chan string, 16),
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+	return r
+}
+
+func (r *fakeRouter) client() *Client {
+	addr := r.ln.Addr().(*net.TCPAddr)
+	c, err := NewClient(Config{RouterID: "test", Host: "127.0.0.1", Port: addr.Port, Username: "u", Password: "p", Timeout: time.Second})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	r.t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func (r *fakeRouter) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	r.mu.Lock()
+	r.w = proto.NewWriter(conn)
+	r.mu.Unlock()
+
+	for {
+		sen, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		switch sen.Word {
+		case "/login":
+			r.write(sen.Tag, "!done")
+		case "/interface/monitor-traffic":
+			r.listens <- fakeListen{tag: sen.Tag, ifaces: sen.Map["interface"]}
+		case "/cancel":
+			r.cancels <- sen.Map["tag"]
+			r.write(sen.Map["tag"], "!trap", "=category=2", "=message=interrupted")
+			r.write(sen.Tag, "!done")
+		case "/interface/print":
+			r.mu.Lock()
+			running := append([]string(nil), r.running...)
+			r.mu.Unlock()
+			for _, name := range running {
+				r.write(sen.Tag, "!re", "=name="+name)
+			}
+			r.write(sen.Tag, "!done")
+		default:
+			r.write(sen.Tag, "!trap", "=message=unknown command "+sen.Word)
+		}
+	}
+}
+
+// readCommand reads a command sentence. proto.Reader only parses replies and
+// rejects the ?query words of a command.
+func readCommand(r *bufio.Reader) (*proto.Sentence, error) {
+	sen := proto.NewSentence()
+	for {
+		word, err := readWord(r)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case word == "":
+			return sen, nil
+		case sen.Word == "":
+			sen.Word = word
+		case strings.HasPrefix(word, ".tag="):
+			sen.Tag = word[5:]
+		case strings.HasPrefix(word, "="):
+			kv := strings.SplitN(word[1:], "=", 2)
+			if len(kv) == 1 {
+				kv = append(kv, "")
+			}
+			sen.Map[kv[0]] = kv[1]
+		}
+	}
+}
+
+// readWord reads one length-prefixed API word; the mux's words are short
+// enough for the one and two byte length encodings
+func readWord(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	n := int(b)
+	if b&0x80 != 0 {
+		next, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		n = int(b&0x3f)<<8 | int(next)
+	}
+
+	word := make([]byte, n)
+	if _, err := io.ReadFull(r, word); err != nil {
+		return "", err
+	}
+	return string(word), nil
+}
+
+// write sends one reply sentence for tag
+func (r *fakeRouter) write(tag, word string, args ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.w.BeginSentence()
+	r.w.WriteWord(word)
+	for _, arg := range args {
+		r.w.WriteWord(arg)
+	}
+	if tag != "" {
+		r.w.WriteWord(".tag=" + tag)
+	}
+	if err := r.w.EndSentence(); err != nil {
+		r.t.Logf("fake router write: %v", err)
+	}
+}
+
+func (r *fakeRouter) setRunning(names ...string) {
+	r.mu.Lock()
+	r.running = names
+	r.mu.Unlock()
+}
+
+func (r *fakeRouter) sample(tag, iface string) {
+	r.write(tag, "!re", "=name="+iface, "=rx-bits-per-second=1000", "=tx-bits-per-second=2000")
+}
+
+func (r *fakeRouter) expectListen(ifaces string) fakeListen {
+	r.t.Helper()
+	select {
+	case l := <-r.listens:
+		if l.ifaces != ifaces {
+			r.t.Fatalf("listen on %q, want %q", l.ifaces, ifaces)
+		}
+		return l
+	case <-time.After(3 * time.Second):
+		r.t.Fatalf("no listen on %q", ifaces)
+	}
+	return fakeListen{}
+}
+
+func expectSample(t *testing.T, ch <-chan InterfaceTraffic, iface string) {
+	t.Helper()
+	select {
+	case s, ok := <-ch:
+		if !ok {
+			t.Fatalf("%s: channel closed", iface)
+		}
+		if s.Name != iface || s.RxBitsPerSecond != 1000 || s.TxBitsPerSecond != 2000 {
+			t.Fatalf("got %+v, want a sample of %s", s, iface)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("%s: no sample", iface)
+	}
+}
+
+func expectClosed(t *testing.T, ch <-chan InterfaceTraffic, iface string) {
+	t.Helper()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatalf("%s: got a sample, want the channel closed", iface)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("%s: channel not closed", iface)
+	}
+}
+
+func TestTrafficMuxRebuild(t *testing.T) {
+	router := newFakeRouter(t)
+	mux := NewTrafficMux(router.client())
+	defer mux.Close()
+
+	ctx := context.Background()
+	ctxB, cancelB := context.WithCancel(ctx)
+
+	a, err := mux.MonitorTraffic(ctx, "<pppoe-a>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := mux.MonitorTraffic(ctxB, "<pppoe-b>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both subscriptions share one listen, fanned out by name
+	first := router.expectListen("<pppoe-a>,<pppoe-b>")
+	router.sample(first.tag, "<pppoe-a>")
+	router.sample(first.tag, "<pppoe-b>")
+	expectSample(t, a, "<pppoe-a>")
+	expectSample(t, b, "<pppoe-b>")
+
+	// Dropping a subscriber cancels the listen and starts a smaller one
+	cancelB()
+	expectClosed(t, b, "<pppoe-b>")
+	select {
+	case tag := <-router.cancels:
+		if tag != first.tag {
+			t.Fatalf("cancelled %s, want %s", tag, first.tag)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("old listen not cancelled")
+	}
+	second := router.expectListen("<pppoe-a>")
+	router.sample(second.tag, "<pppoe-a>")
+	expectSample(t, a, "<pppoe-a>")
+
+	if got := strings.Join(mux.Interfaces(), ","); got != "<pppoe-a>" {
+		t.Fatalf("Interfaces() = %q", got)
+	}
+}
+
+func TestTrafficMuxFailure(t *testing.T) {
+	defer func(delay time.Duration) { muxRetryDelay = delay }(muxRetryDelay)
+	muxRetryDelay = 50 * time.Millisecond
+
+	router := newFakeRouter(t)
+	mux := NewTrafficMux(router.client())
+	defer mux.Close()
+
+	ctx := context.Background()
+	a, err := mux.MonitorTraffic(ctx, "<pppoe-a>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := mux.MonitorTraffic(ctx, "<pppoe-b>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := router.expectListen("<pppoe-a>,<pppoe-b>")
+
+	// The session of b ends: RouterOS fails the whole listen
+	router.setRunning("<pppoe-a>", "ether1")
+	router.write(first.tag, "!trap", "=message=no such item")
+
+	expectClosed(t, b, "<pppoe-b>")
+	second := router.expectListen("<pppoe-a>")
+	router.sample(second.tag, "<pppoe-a>")
+	expectSample(t, a, "<pppoe-a>")
+
+	// The failed listen ended on its own and must not be cancelled
+	select {
+	case tag := <-router.cancels:
+		t.Fatalf("listen %s cancelled after it failed", tag)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
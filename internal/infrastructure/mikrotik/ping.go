@@ -0,0 +1,267 @@
+package mikrotik
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Ping option limits
+const (
+	PingMaxCount       = 100
+	PingMinSize        = 28
+	PingMaxSize        = 10000
+	PingMinInterval    = 10 * time.Millisecond
+	PingMaxInterval    = 5 * time.Second
+	PingMaxRunDuration = time.Minute // count × interval of a finite ping
+)
+
+var routingTablePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,64}$`)
+
+// PingOptions configures a ping run on the router. Zero values use the RouterOS defaults.
+type PingOptions struct {
+	Address      string
+	Count        int           // packets to send; Ping defaults to 3, StreamPing runs until cancelled
+	Size         int           // packet size in bytes (RouterOS default 56)
+	Interval     time.Duration // RouterOS default 1s
+	TTL          int           // 1-255
+	DSCP         int           // 0-63, only sent when HasDSCP is set
+	HasDSCP      bool          // DSCP 0 is a valid value
+	SrcAddress   string
+	RoutingTable string
+}
+
+// Validate checks the options against the allowed limits
+func (o *PingOptions) Validate() error {
+	if net.ParseIP(o.Address) == nil {
+		return fmt.Errorf("address %q is not a valid IP address", o.Address)
+	}
+	if o.Count < 0 || o.Count > PingMaxCount {
+		return fmt.Errorf("count must not exceed %d", PingMaxCount)
+	}
+	if o.Size != 0 && (o.Size < PingMinSize || o.Size > PingMaxSize) {
+		return fmt.Errorf("size must be between %d and %d", PingMinSize, PingMaxSize)
+	}
+	if o.Interval != 0 && (o.Interval < PingMinInterval || o.Interval > PingMaxInterval) {
+		return fmt.Errorf("interval must be between %s and %s", PingMinInterval, PingMaxInterval)
+	}
+	if o.Count > 0 {
+		interval := o.Interval
+		if interval == 0 {
+			interval = time.Second
+		}
+		if time.Duration(o.Count)*interval > PingMaxRunDuration {
+			return fmt.Errorf("count × interval must not exceed %s", PingMaxRunDuration)
+		}
+	}
+	if o.TTL != 0 && (o.TTL < 1 || o.TTL > 255) {
+		return fmt.Errorf("ttl must be between 1 and 255")
+	}
+	if o.HasDSCP && (o.DSCP < 0 || o.DSCP > 63) {
+		return fmt.Errorf("dscp must be between 0 and 63")
+	}
+	if o.SrcAddress != "" && net.ParseIP(o.SrcAddress) == nil {
+		return fmt.Errorf("src_address %q is not a valid IP address", o.SrcAddress)
+	}
+	if o.RoutingTable != "" && !routingTablePattern.MatchString(o.RoutingTable) {
+		return fmt.Errorf("routing_table %q is not a valid table name", o.RoutingTable)
+	}
+	return nil
+}
+
+// args builds the /ping command words
+func (o *PingOptions) args() []string {
+	args := []string{
+		"/ping",
+		"=address=" + o.Address,
+	}
+	if o.Count > 0 {
+		args = append(args, "=count="+strconv.Itoa(o.Count))
+	}
+	if o.Size > 0 {
+		args = append(args, "=size="+strconv.Itoa(o.Size))
+	}
+	if o.Interval > 0 {
+		args = append(args, fmt.Sprintf("=interval=%dms", o.Interval.Milliseconds()))
+	}
+	if o.TTL > 0 {
+		args = append(args, "=ttl="+strconv.Itoa(o.TTL))
+	}
+	if o.HasDSCP {
+		args = append(args, "=dscp="+strconv.Itoa(o.DSCP))
+	}
+	if o.SrcAddress != "" {
+		args = append(args, "=src-address="+o.SrcAddress)
+	}
+	if o.RoutingTable != "" {
+		args = append(args, "=routing-table="+o.RoutingTable)
+	}
+	return args
+}
+
+// Ping packet statuses; RouterOS reports other error statuses verbatim
+const (
+	PingStatusReplied = "replied"
+	PingStatusTimeout = "timeout"
+)
+
+// PingPacket is the outcome of one echo request
+type PingPacket struct {
+	Seq    int           `json:"seq"`
+	Status string        `json:"status"` // replied, timeout, net-unreachable, host-unreachable, ...
+	Time   time.Duration `json:"-"`
+	TimeMs float64       `json:"time_ms"`
+	TTL    int           `json:"ttl,omitempty"`
+	Size   int           `json:"size,omitempty"`
+	Host   string        `json:"host,omitempty"` // replying host, may differ from the target on errors
+}
+
+// PingStats summarizes a ping run
+type PingStats struct {
+	Address    string
+	Sent       int
+	Received   int
+	PacketLoss float64 // percent
+	MinRtt     time.Duration
+	AvgRtt     time.Duration
+	MaxRtt     time.Duration
+	Jitter     time.Duration  // mean difference between consecutive round trips
+	Breakdown  map[string]int // packets per status
+	Packets    []PingPacket
+}
+
+// Reachable reports whether at least one reply was received
+func (s *PingStats) Reachable() bool {
+	return s.Received > 0
+}
+
+// Ping runs /ping with a packet count and waits for it to finish
+func (c *Client) Ping(opts PingOptions) (*PingStats, error) {
+	if opts.Count <= 0 {
+		opts.Count = 3
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	r, err := c.RunArgs(opts.args())
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping %s: %w", opts.Address, err)
+	}
+
+	var packets []PingPacket
+	for _, re := range r.Re {
+		if p, ok := mapToPingResponse(re.Map).Packet(); ok {
+			packets = append(packets, p)
+		}
+	}
+
+	stats := SummarizePing(opts.Address, packets)
+
+	// Every reply sentence carries the running totals; trust the router's count
+	// when it saw packets we could not attribute to a sequence number
+	if len(r.Re) > 0 {
+		last := r.Re[len(r.Re)-1].Map
+		if sent := int(parseUint(last["sent"])); sent > stats.Sent {
+			stats.Sent = sent
+			stats.Received = int(parseUint(last["received"]))
+			stats.PacketLoss = float64(stats.Sent-stats.Received) * 100 / float64(stats.Sent)
+		}
+	}
+
+	return stats, nil
+}
+
+// Packet converts a per-packet reply sentence; ok is false for sentences without a sequence number
+func (r PingResponse) Packet() (PingPacket, bool) {
+	seq, err := strconv.Atoi(r.Seq)
+	if err != nil {
+		return PingPacket{}, false
+	}
+
+	p := PingPacket{
+		Seq:    seq,
+		Status: r.Status,
+		TTL:    int(parseUint(r.TTL)),
+		Size:   int(parseUint(r.Size)),
+		Host:   r.Host,
+	}
+	if r.Time != "" {
+		p.Time, _ = ParseDuration(r.Time)
+		p.TimeMs = float64(p.Time) / float64(time.Millisecond)
+	}
+	if p.Status == "" {
+		if r.Time != "" {
+			p.Status = PingStatusReplied
+		} else {
+			p.Status = PingStatusTimeout
+		}
+	}
+	return p, true
+}
+
+// SummarizePing computes loss, RTT stats, jitter and the status breakdown of
+// the packets. A sequence number reported twice (an in-progress update
+// followed by the final one) counts once, with the last report winning.
+func SummarizePing(address string, packets []PingPacket) *PingStats {
+	bySeq := make(map[int]int, len(packets))
+	var unique []PingPacket
+	for _, p := range packets {
+		if i, ok := bySeq[p.Seq]; ok {
+			unique[i] = p
+			continue
+		}
+		bySeq[p.Seq] = len(unique)
+		unique = append(unique, p)
+	}
+
+	stats := &PingStats{
+		Address:   address,
+		Sent:      len(unique),
+		Breakdown: make(map[string]int),
+		Packets:   unique,
+	}
+	if stats.Packets == nil {
+		stats.Packets = []PingPacket{}
+	}
+
+	var total, jitterSum time.Duration
+	var prev time.Duration
+	for _, p := range unique {
+		stats.Breakdown[p.Status]++
+		if p.Status != PingStatusReplied {
+			continue
+		}
+
+		if stats.Received == 0 || p.Time < stats.MinRtt {
+			stats.MinRtt = p.Time
+		}
+		if p.Time > stats.MaxRtt {
+			stats.MaxRtt = p.Time
+		}
+		if stats.Received > 0 {
+			diff := p.Time - prev
+			if diff < 0 {
+				diff = -diff
+			}
+			jitterSum += diff
+		}
+		prev = p.Time
+		total += p.Time
+		stats.Received++
+	}
+
+	if stats.Sent > 0 {
+		stats.PacketLoss = float64(stats.Sent-stats.Received) * 100 / float64(stats.Sent)
+	}
+	if stats.Received > 0 {
+		stats.AvgRtt = total / time.Duration(stats.Received)
+	}
+	if stats.Received > 1 {
+		stats.Jitter = jitterSum / time.Duration(stats.Received-1)
+	}
+
+	return stats
+}
@@ -21,38 +21,13 @@ type PingResponse struct {
 	IsSummary  bool   `json:"is_summary"` // Helper to identify summary packet if any
 }
 
-// StreamPing starts a continuous ping to the specified address
+// StreamPing starts a ping to the address described by opts (continuous unless opts.Count is set)
 // Returns a channel that receives ping data continuously until context is cancelled
-func (c *Client) StreamPing(
-	ctx context.Context,
-	address string,
-	size string,
-	interval string,
-) (<-chan PingResponse, error) {
-
-	args := []string{
-		"/ping",
-		"=address=" + address,
-	}
-
-	if size != "" {
-		args = append(args, "=size="+size)
-	}
-	if interval != "" {
-		args = append(args, "=interval="+interval)
-	}
-
-	reply, err := c.ListenArgsContext(ctx, args)
-	if err != nil {
-		if isConnectionError(err) {
-			// Try to reconnect
-			if recErr := c.Reconnect(); recErr == nil {
-				// Retry command
-				reply, err = c.ListenArgsContext(ctx, args)
-			}
-		}
+func (c *Client) StreamPing(ctx context.Context, opts PingOptions) (<-chan PingResponse, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
 	}
-
+	in, err := c.listen(ctx, opts.args())
 	if err != nil {
 		return nil, err
 	}
@@ -62,18 +37,10 @@ func (c *Client) StreamPing(
 	go func() {
 		defer close(out)
 
-		for {
+		for m := range in {
 			select {
+			case out <- mapToPingResponse(m):
 			case <-ctx.Done():
-				return
-			case r, ok := <-reply.Chan():
-				if !ok {
-					return
-				}
-				if r == nil || r.Map == nil {
-					continue
-				}
-				out <- mapToPingResponse(r.Map)
 			}
 		}
 	}()
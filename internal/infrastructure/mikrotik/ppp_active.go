@@ -0,0 +1,124 @@
+package mikrotik
+
+import (
+	"fmt"
+	"time"
+)
+
+// PPPoESession is the live state of a PPPoE user, combined from /ppp/active,
+// its <pppoe-user> interface and the dynamic simple queue of its profile
+type PPPoESession struct {
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	Service       string          `json:"service"`
+	CallerID      string          `json:"caller_id"` // CPE MAC address
+	Address       string          `json:"address"`
+	Uptime        string          `json:"uptime"`
+	UptimeSeconds int64           `json:"uptime_seconds"`
+	Encoding      string          `json:"encoding"`
+	SessionID     string          `json:"session_id"`
+	LimitBytesIn  uint64          `json:"limit_bytes_in"` // 0 = unlimited
+	LimitBytesOut uint64          `json:"limit_bytes_out"`
+	Radius        bool            `json:"radius"`
+	Interface     *PPPoEInterface `json:"interface,omitempty"`
+	Queue         *SimpleQueue    `json:"queue,omitempty"`
+}
+
+// PPPoEInterface is the dynamic pppoe-in interface of a session
+type PPPoEInterface struct {
+	Name           string `json:"name"`
+	Running        bool   `json:"running"`
+	ActualMTU      int    `json:"actual_mtu"`
+	RxBytes        uint64 `json:"rx_bytes"`
+	TxBytes        uint64 `json:"tx_bytes"`
+	LastLinkUpTime string `json:"last_link_up_time,omitempty"`
+}
+
+// SimpleQueue is a /queue/simple entry, e.g. the one created from a PPP profile rate-limit
+type SimpleQueue struct {
+	Name       string `json:"name"`
+	Target     string `json:"target"`
+	MaxLimit   string `json:"max_limit"`             // upload/download, e.g. 5M/10M
+	BurstLimit string `json:"burst_limit,omitempty"` // upload/download
+	Rate       string `json:"rate"`                  // current upload/download bps
+	Bytes      string `json:"bytes"`                 // upload/download
+	Dynamic    bool   `json:"dynamic"`
+	Disabled   bool   `json:"disabled"`
+}
+
+// GetPPPActive returns the /ppp/active entry of a user without interface and
+// queue details, or nil when the user is not connected
+func (c *Client) GetPPPActive(username string) (*PPPoESession, error) {
+	r, err := c.Run(
+		"/ppp/active/print",
+		"?name="+username,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active ppp sessions: %w", err)
+	}
+	if len(r.Re) == 0 {
+		return nil, nil
+	}
+
+	m := r.Re[0].Map
+	session := &PPPoESession{
+		ID:            m[".id"],
+		Name:          m["name"],
+		Service:       m["service"],
+		CallerID:      m["caller-id"],
+		Address:       m["address"],
+		Uptime:        m["uptime"],
+		Encoding:      m["encoding"],
+		SessionID:     m["session-id"],
+		LimitBytesIn:  parseUint(m["limit-bytes-in"]),
+		LimitBytesOut: parseUint(m["limit-bytes-out"]),
+		Radius:        m["radius"] == "true",
+	}
+	if uptime, err := ParseDuration(m["uptime"]); err == nil {
+		session.UptimeSeconds = int64(uptime / time.Second)
+	}
+	return session, nil
+}
+
+// GetPPPoESession returns the live session of a PPPoE user, or nil when the
+// user is not connected. Interface and queue details are best effort.
+func (c *Client) GetPPPoESession(username string) (*PPPoESession, error) {
+	session, err := c.GetPPPActive(username)
+	if err != nil || session == nil {
+		return session, err
+	}
+
+	// Same case-insensitive lookup as the traffic monitor; the queue shares the interface name
+	ifaceName, err := c.FindActivePPPoEInterface(username)
+	if err != nil {
+		return session, nil
+	}
+
+	if ir, err := c.Run("/interface/print", "?name="+ifaceName); err == nil && len(ir.Re) > 0 {
+		im := ir.Re[0].Map
+		session.Interface = &PPPoEInterface{
+			Name:           im["name"],
+			Running:        im["running"] == "true",
+			ActualMTU:      int(parseUint(im["actual-mtu"])),
+			RxBytes:        parseUint(im["rx-byte"]),
+			TxBytes:        parseUint(im["tx-byte"]),
+			LastLinkUpTime: im["last-link-up-time"],
+		}
+	}
+
+	if qr, err := c.Run("/queue/simple/print", "?name="+ifaceName); err == nil && len(qr.Re) > 0 {
+		qm := qr.Re[0].Map
+		session.Queue = &SimpleQueue{
+			Name:       qm["name"],
+			Target:     qm["target"],
+			MaxLimit:   qm["max-limit"],
+			BurstLimit: qm["burst-limit"],
+			Rate:       qm["rate"],
+			Bytes:      qm["bytes"],
+			Dynamic:    qm["dynamic"] == "true",
+			Disabled:   qm["disabled"] == "true",
+		}
+	}
+
+	return session, nil
+}
@@ -0,0 +1,160 @@
+package mikrotik
+
+import (
+	"fmt"
+)
+
+// PPPProfile represents a /ppp/profile entry
+type PPPProfile struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	LocalAddress  string `json:"local_address"`
+	RemoteAddress string `json:"remote_address"` // address or pool name
+	RateLimit     string `json:"rate_limit"`
+	OnlyOne       string `json:"only_one"`
+	DNSServer     string `json:"dns_server"`
+	Comment       string `json:"comment"`
+}
+
+// args builds the property list for add/set commands, skipping empty fields
+func (p PPPProfile) args() []string {
+	var args []string
+
+	if p.Name != "" {
+		args = append(args, "=name="+p.Name)
+	}
+	if p.LocalAddress != "" {
+		args = append(args, "=local-address="+p.LocalAddress)
+	}
+	if p.RemoteAddress != "" {
+		args = append(args, "=remote-address="+p.RemoteAddress)
+	}
+	if p.RateLimit != "" {
+		args = append(args, "=rate-limit="+p.RateLimit)
+	}
+	if p.OnlyOne != "" {
+		args = append(args, "=only-one="+p.OnlyOne)
+	}
+	if p.DNSServer != "" {
+		args = append(args, "=dns-server="+p.DNSServer)
+	}
+	if p.Comment != "" {
+		args = append(args, "=comment="+p.Comment)
+	}
+
+	return args
+}
+
+// CreatePPPProfile creates a new PPP profile and returns its ID
+func (c *Client) CreatePPPProfile(p PPPProfile) (string, error) {
+	if p.Name == "" {
+		return "", fmt.Errorf("ppp profile name is required")
+	}
+
+	cmd := append([]string{"/ppp/profile/add"}, p.args()...)
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to create ppp profile: %w", err)
+	}
+
+	return r.Done.Map["ret"], nil
+}
+
+// UpdatePPPProfile updates an existing PPP profile. Empty fields are left unchanged.
+func (c *Client) UpdatePPPProfile(id string, p PPPProfile) error {
+	cmd := append([]string{"/ppp/profile/set", "=.id=" + id}, p.args()...)
+
+	_, err := c.RunArgs(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to update ppp profile: %w", err)
+	}
+	return nil
+}
+
+// PatchPPPProfile sets the given profile properties (keyed by RouterOS name, e.g. "local-address").
+// An empty value unsets the property on the router.
+func (c *Client) PatchPPPProfile(id string, changes map[string]string) error {
+	if err := c.setOrUnset("/ppp/profile", id, changes); err != nil {
+		return fmt.Errorf("failed to patch ppp profile: %w", err)
+	}
+	return nil
+}
+
+// FindPPPProfileID returns the ID of a PPP profile by name
+func (c *Client) FindPPPProfileID(name string) (string, error) {
+	cmd := []string{
+		"/ppp/profile/print",
+		"?name=" + name,
+		"=.proplist=.id",
+	}
+
+	r, err := c.RunArgs(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	if len(r.Re) == 0 {
+		return "", nil // Not found
+	}
+
+	return r.Re[0].Map[".id"], nil
+}
+
+// ListPPPProfiles returns all PPP profiles
+func (c *Client) ListPPPProfiles() ([]PPPProfile, error) {
+	r, err := c.RunArgs([]string{"/ppp/profile/print"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ppp profiles: %w", err)
+	}
+
+	profiles := make([]PPPProfile, 0, len(r.Re))
+	for _, re := range r.Re {
+		profiles = append(profiles, mapToPPPProfile(re.Map))
+	}
+	return profiles, nil
+}
+
+// GetPPPProfile returns a PPP profile by ID (nil if not found)
+func (c *Client) GetPPPProfile(id string) (*PPPProfile, error) {
+	r, err := c.RunArgs([]string{
+		"/ppp/profile/print",
+		"?.id=" + id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ppp profile: %w", err)
+	}
+
+	if len(r.Re) == 0 {
+		return nil, nil // Not found
+	}
+
+	profile := mapToPPPProfile(r.Re[0].Map)
+	return &profile, nil
+}
+
+// DeletePPPProfile deletes a PPP profile by ID
+func (c *Client) DeletePPPProfile(id string) error {
+	cmd := []string{
+		"/ppp/profile/remove",
+		"=.id=" + id,
+	}
+	_, err := c.RunArgs(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to delete ppp profile: %w", err)
+	}
+	return nil
+}
+
+func mapToPPPProfile(m map[string]string) PPPProfile {
+	return PPPProfile{
+		ID:            m[".id"],
+		Name:          m["name"],
+		LocalAddress:  m["local-address"],
+		RemoteAddress: m["remote-address"],
+		RateLimit:     m["rate-limit"],
+		OnlyOne:       m["only-one"],
+		DNSServer:     m["dns-server"],
+		Comment:       m["comment"],
+	}
+}
@@ -2,6 +2,7 @@ package mikrotik
 
 import (
 	"fmt"
+	"strings"
 )
 
 // CreatePPPoESecret creates a new PPPoE secret
@@ -67,6 +68,65 @@ func (c *Client) UpdatePPPoESecret(id, username, password, profile, localAddress
 	return nil
 }
 
+// PatchPPPoESecret sets only the given secret properties (keyed by RouterOS name, e.g. "remote-address").
+// An empty value unsets the property on the router instead of writing an empty string.
+func (c *Client) PatchPPPoESecret(id string, changes map[string]string) error {
+	if err := c.setOrUnset("/ppp/secret", id, changes, "password"); err != nil {
+		return fmt.Errorf("failed to patch ppp secret: %w", err)
+	}
+	return nil
+}
+
+// setOrUnset runs <menu>/set for non-empty values and <menu>/unset for empty ones.
+// Properties listed in keepEmpty are written as empty strings instead of unset.
+func (c *Client) setOrUnset(menu, id string, changes map[string]string, keepEmpty ...string) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	cmd := []string{
+		menu + "/set",
+		"=.id=" + id,
+	}
+	var unset []string
+
+	for prop, value := range changes {
+		if value == "" && !contains(keepEmpty, prop) {
+			unset = append(unset, prop)
+			continue
+		}
+		cmd = append(cmd, "="+prop+"="+value)
+	}
+
+	if len(cmd) > 2 {
+		if _, err := c.RunArgs(cmd); err != nil {
+			return err
+		}
+	}
+
+	for _, prop := range unset {
+		_, err := c.RunArgs([]string{
+			menu + "/unset",
+			"=numbers=" + id,
+			"=value-name=" + prop,
+		})
+		if err != nil {
+			return fmt.Errorf("unset %s: %w", prop, err)
+		}
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // DeletePPPoESecret deletes a PPPoE secret by ID
 func (c *Client) DeletePPPoESecret(id string) error {
 	cmd := []string{
@@ -99,3 +159,69 @@ func (c *Client) FindPPPoESecretID(username string) (string, error) {
 
 	return r.Re[0].Map[".id"], nil
 }
+
+// PPPoEUsernameFromInterface extracts the username from a dynamic PPPoE
+// server interface name such as "<pppoe-budi>"; "" for other interfaces
+func PPPoEUsernameFromInterface(name string) string {
+	if len(name) < 9 {
+		return ""
+	}
+	if strings.HasPrefix(name, "<pppoe-") && strings.HasSuffix(name, ">") {
+		return name[7 : len(name)-1]
+	}
+	return ""
+}
+
+// FindActivePPPoEInterface returns the running pppoe-in interface of a user
+// (case-insensitive), or an error when the user has no active session
+func (c *Client) FindActivePPPoEInterface(username string) (string, error) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if username == "" {
+		return "", fmt.Errorf("no PPPoE username configured")
+	}
+
+	reply, err := c.Run(
+		"/interface/print",
+		"?type=pppoe-in",
+		"?running=yes",
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to query MikroTik interfaces: %w", err)
+	}
+
+	for _, re := range reply.Re {
+		name := re.Map["name"]
+		if strings.ToLower(strings.TrimSpace(PPPoEUsernameFromInterface(name))) == username {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("no active PPPoE session found for username '%s'", username)
+}
+
+// DisconnectPPPoESession removes the /ppp/active entries of a user, forcing the
+// client to reconnect. It returns how many sessions were removed.
+func (c *Client) DisconnectPPPoESession(username string) (int, error) {
+	reply, err := c.Run(
+		"/ppp/active/print",
+		"?name="+username,
+		"=.proplist=.id",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query active ppp sessions: %w", err)
+	}
+
+	removed := 0
+	for _, re := range reply.Re {
+		id := re.Map[".id"]
+		if id == "" {
+			continue
+		}
+		if _, err := c.Run("/ppp/active/remove", "=.id="+id); err != nil {
+			return removed, fmt.Errorf("failed to remove ppp session %s: %w", id, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
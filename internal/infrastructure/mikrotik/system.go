@@ -0,0 +1,133 @@
+package mikrotik
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SystemResource is the /system/resource state of a router
+type SystemResource struct {
+	Uptime       string
+	Version      string
+	BoardName    string
+	Architecture string
+	CPUCount     int
+	CPULoad      int // percent
+	FreeMemory   uint64
+	TotalMemory  uint64
+	FreeHDD      uint64
+	TotalHDD     uint64
+}
+
+// SystemHealth holds the /system/health sensors of a router. Boards without a
+// sensor leave it nil; Sensors keeps every reported value by name.
+type SystemHealth struct {
+	Temperature *float64 // board or CPU temperature, °C
+	Voltage     *float64 // supply voltage, V
+	Sensors     map[string]string
+}
+
+// InterfaceCounters are the cumulative error and drop counters of an interface
+type InterfaceCounters struct {
+	Name     string
+	RxErrors uint64
+	TxErrors uint64
+	RxDrops  uint64
+	TxDrops  uint64
+}
+
+// GetSystemResource reads /system/resource
+func (c *Client) GetSystemResource() (*SystemResource, error) {
+	r, err := c.Run("/system/resource/print")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system resource: %w", err)
+	}
+	if len(r.Re) == 0 {
+		return nil, fmt.Errorf("failed to read system resource: empty reply")
+	}
+
+	m := r.Re[0].Map
+	return &SystemResource{
+		Uptime:       m["uptime"],
+		Version:      m["version"],
+		BoardName:    m["board-name"],
+		Architecture: m["architecture-name"],
+		CPUCount:     int(parseUint(m["cpu-count"])),
+		CPULoad:      int(parseUint(m["cpu-load"])),
+		FreeMemory:   parseUint(m["free-memory"]),
+		TotalMemory:  parseUint(m["total-memory"]),
+		FreeHDD:      parseUint(m["free-hdd-space"]),
+		TotalHDD:     parseUint(m["total-hdd-space"]),
+	}, nil
+}
+
+// GetSystemHealth reads /system/health. RouterOS 7 returns one name/value row
+// per sensor, RouterOS 6 a single row with a property per sensor.
+func (c *Client) GetSystemHealth() (*SystemHealth, error) {
+	r, err := c.Run("/system/health/print")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read system health: %w", err)
+	}
+
+	health := &SystemHealth{Sensors: make(map[string]string)}
+	for _, re := range r.Re {
+		if name, ok := re.Map["name"]; ok {
+			health.Sensors[name] = re.Map["value"]
+			continue
+		}
+		for k, v := range re.Map {
+			if !strings.HasPrefix(k, ".") {
+				health.Sensors[k] = v
+			}
+		}
+	}
+
+	health.Temperature = firstSensor(health.Sensors, "temperature", "cpu-temperature", "board-temperature1", "sfp-temperature")
+	health.Voltage = firstSensor(health.Sensors, "voltage", "psu1-voltage", "psu-voltage")
+	return health, nil
+}
+
+// CountPPPActive returns the number of active PPP sessions
+func (c *Client) CountPPPActive() (int, error) {
+	r, err := c.Run("/ppp/active/print", "=count-only=")
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active ppp sessions: %w", err)
+	}
+	return int(parseUint(r.Done.Map["ret"])), nil
+}
+
+// ListInterfaceCounters returns the error and drop counters of every interface
+func (c *Client) ListInterfaceCounters() ([]InterfaceCounters, error) {
+	r, err := c.Run(
+		"/interface/print",
+		"=.proplist=name,rx-error,tx-error,rx-drop,tx-drop",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interface counters: %w", err)
+	}
+
+	counters := make([]InterfaceCounters, 0, len(r.Re))
+	for _, re := range r.Re {
+		counters = append(counters, InterfaceCounters{
+			Name:     re.Map["name"],
+			RxErrors: parseUint(re.Map["rx-error"]),
+			TxErrors: parseUint(re.Map["tx-error"]),
+			RxDrops:  parseUint(re.Map["rx-drop"]),
+			TxDrops:  parseUint(re.Map["tx-drop"]),
+		})
+	}
+	return counters, nil
+}
+
+// firstSensor parses the first of the named sensors the board reports
+func firstSensor(sensors map[string]string, names ...string) *float64 {
+	for _, name := range names {
+		if v, ok := sensors[name]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return &f
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+)
+
+var torchProtocolPattern = regexp.MustCompile(`^(any|[a-z0-9\-]{1,16})$`)
+
+// TorchOptions configures a torch run on an interface. Empty filters match
+// everything; torch then breaks traffic down by addresses, protocol and port.
+type TorchOptions struct {
+	Interface  string
+	SrcAddress string // prefix filter, e.g. 10.10.0.5/32 (default 0.0.0.0/0)
+	DstAddress string // prefix filter (default 0.0.0.0/0)
+	Protocol   string // ip-protocol filter, e.g. tcp, udp, icmp (default any)
+	Port       string // port filter, number or service name (default any)
+}
+
+// Validate checks the filters so they cannot inject extra command words
+func (o *TorchOptions) Validate() error {
+	if o.Interface == "" {
+		return fmt.Errorf("interface is required")
+	}
+	for _, p := range []struct{ name, value string }{
+		{"src_address", o.SrcAddress},
+		{"dst_address", o.DstAddress},
+	} {
+		if p.value == "" {
+			continue
+		}
+		if _, err := netip.ParsePrefix(p.value); err != nil {
+			if _, err := netip.ParseAddr(p.value); err != nil {
+				return fmt.Errorf("%s %q is not a valid address or prefix", p.name, p.value)
+			}
+		}
+	}
+	if o.Protocol != "" && !torchProtocolPattern.MatchString(o.Protocol) {
+		return fmt.Errorf("protocol %q is not valid", o.Protocol)
+	}
+	if o.Port != "" && !torchProtocolPattern.MatchString(o.Port) {
+		return fmt.Errorf("port %q is not valid", o.Port)
+	}
+	return nil
+}
+
+// args builds the /tool/torch command words
+func (o *TorchOptions) args() []string {
+	orDefault := func(v, def string) string {
+		if v == "" {
+			return def
+		}
+		return v
+	}
+	return []string{
+		"/tool/torch",
+		"=interface=" + o.Interface,
+		"=src-address=" + orDefault(o.SrcAddress, "0.0.0.0/0"),
+		"=dst-address=" + orDefault(o.DstAddress, "0.0.0.0/0"),
+		"=ip-protocol=" + orDefault(o.Protocol, "any"),
+		"=port=" + orDefault(o.Port, "any"),
+	}
+}
+
+// TorchFlow is one flow seen by torch during a sample
+type TorchFlow struct {
+	SrcAddress string `json:"src_address"`
+	DstAddress string `json:"dst_address"`
+	Protocol   string `json:"protocol"`
+	SrcPort    string `json:"src_port,omitempty"`
+	DstPort    string `json:"dst_port,omitempty"`
+	TxBps      uint64 `json:"tx_bps"`
+	RxBps      uint64 `json:"rx_bps"`
+	TxPackets  uint64 `json:"tx_packets"`
+	RxPackets  uint64 `json:"rx_packets"`
+}
+
+// TorchSample is the flow table of one torch refresh, busiest flows first
+type TorchSample struct {
+	Interface string      `json:"interface"`
+	TxBps     uint64      `json:"tx_bps"`
+	RxBps     uint64      `json:"rx_bps"`
+	Flows     []TorchFlow `json:"flows"`
+}
+
+// StreamTorch runs /tool/torch on an interface and emits a flow table every
+// refresh until ctx is cancelled
+func (c *Client) StreamTorch(ctx context.Context, opts TorchOptions) (<-chan TorchSample, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	in, err := c.listen(ctx, opts.args())
+	if err != nil {
+		return nil, fmt.Errorf("failed to start torch on %s: %w", opts.Interface, err)
+	}
+
+	out := make(chan TorchSample)
+	go func() {
+		defer close(out)
+
+		for rows := range sections(ctx, in) {
+			sample := TorchSample{
+				Interface: opts.Interface,
+				Flows:     make([]TorchFlow, 0, len(rows)),
+			}
+			for _, m := range rows {
+				f := mapToTorchFlow(m)
+				if f.TxBps == 0 && f.RxBps == 0 && f.SrcAddress == "" && f.DstAddress == "" {
+					continue // empty row closing a refresh
+				}
+				sample.TxBps += f.TxBps
+				sample.RxBps += f.RxBps
+				sample.Flows = append(sample.Flows, f)
+			}
+			sort.Slice(sample.Flows, func(i, j int) bool {
+				return sample.Flows[i].TxBps+sample.Flows[i].RxBps > sample.Flows[j].TxBps+sample.Flows[j].RxBps
+			})
+			select {
+			case out <- sample:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func mapToTorchFlow(m map[string]string) TorchFlow {
+	protocol := m["ip-protocol"]
+	if protocol == "" {
+		protocol = m["mac-protocol"]
+	}
+	return TorchFlow{
+		SrcAddress: m["src-address"],
+		DstAddress: m["dst-address"],
+		Protocol:   protocol,
+		SrcPort:    m["src-port"],
+		DstPort:    m["dst-port"],
+		TxBps:      parseUint(m["tx"]),
+		RxBps:      parseUint(m["rx"]),
+		TxPackets:  parseUint(m["tx-packets"]),
+		RxPackets:  parseUint(m["rx-packets"]),
+	}
+}
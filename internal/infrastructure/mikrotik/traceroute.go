@@ -0,0 +1,168 @@
+package mikrotik
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Traceroute option limits
+const (
+	TracerouteMaxCount   = 100
+	TracerouteMaxHops    = 255
+	TracerouteMinTimeout = 100 * time.Millisecond
+	TracerouteMaxTimeout = 10 * time.Second
+)
+
+// TracerouteOptions configures a traceroute run. Zero values use the RouterOS defaults.
+type TracerouteOptions struct {
+	Address      string
+	Count        int           // rounds to run; 0 runs until cancelled
+	MaxHops      int           // RouterOS default 30
+	Timeout      time.Duration // per-probe timeout, RouterOS default 1s
+	Size         int           // packet size in bytes
+	SrcAddress   string
+	RoutingTable string
+}
+
+// Validate checks the options against the allowed limits
+func (o *TracerouteOptions) Validate() error {
+	if net.ParseIP(o.Address) == nil {
+		return fmt.Errorf("address %q is not a valid IP address", o.Address)
+	}
+	if o.Count < 0 || o.Count > TracerouteMaxCount {
+		return fmt.Errorf("count must not exceed %d", TracerouteMaxCount)
+	}
+	if o.MaxHops < 0 || o.MaxHops > TracerouteMaxHops {
+		return fmt.Errorf("max_hops must be between 1 and %d", TracerouteMaxHops)
+	}
+	if o.Timeout != 0 && (o.Timeout < TracerouteMinTimeout || o.Timeout > TracerouteMaxTimeout) {
+		return fmt.Errorf("timeout must be between %s and %s", TracerouteMinTimeout, TracerouteMaxTimeout)
+	}
+	if o.Size != 0 && (o.Size < PingMinSize || o.Size > PingMaxSize) {
+		return fmt.Errorf("size must be between %d and %d", PingMinSize, PingMaxSize)
+	}
+	if o.SrcAddress != "" && net.ParseIP(o.SrcAddress) == nil {
+		return fmt.Errorf("src_address %q is not a valid IP address", o.SrcAddress)
+	}
+	if o.RoutingTable != "" && !routingTablePattern.MatchString(o.RoutingTable) {
+		return fmt.Errorf("routing_table %q is not a valid table name", o.RoutingTable)
+	}
+	return nil
+}
+
+// args builds the /tool/traceroute command words
+func (o *TracerouteOptions) args() []string {
+	args := []string{
+		"/tool/traceroute",
+		"=address=" + o.Address,
+		"=use-dns=no",
+	}
+	if o.Count > 0 {
+		args = append(args, "=count="+strconv.Itoa(o.Count))
+	}
+	if o.MaxHops > 0 {
+		args = append(args, "=max-hops="+strconv.Itoa(o.MaxHops))
+	}
+	if o.Timeout > 0 {
+		args = append(args, fmt.Sprintf("=timeout=%dms", o.Timeout.Milliseconds()))
+	}
+	if o.Size > 0 {
+		args = append(args, "=size="+strconv.Itoa(o.Size))
+	}
+	if o.SrcAddress != "" {
+		args = append(args, "=src-address="+o.SrcAddress)
+	}
+	if o.RoutingTable != "" {
+		args = append(args, "=routing-table="+o.RoutingTable)
+	}
+	return args
+}
+
+// TracerouteHop is one row of the traceroute table
+type TracerouteHop struct {
+	Hop     int     `json:"hop"`
+	Address string  `json:"address"` // empty when the hop did not answer
+	Loss    float64 `json:"loss"`    // percent
+	Sent    int     `json:"sent"`
+	LastMs  float64 `json:"last_ms"`
+	AvgMs   float64 `json:"avg_ms"`
+	BestMs  float64 `json:"best_ms"`
+	WorstMs float64 `json:"worst_ms"`
+	StdDev  float64 `json:"std_dev_ms"`
+	Status  string  `json:"status,omitempty"` // e.g. timeout, host-unreachable
+}
+
+// TracerouteRound is the full hop table after one round of probes
+type TracerouteRound struct {
+	Round int             `json:"round"`
+	Hops  []TracerouteHop `json:"hops"`
+}
+
+// StreamTraceroute runs /tool/traceroute and emits the hop table after every
+// round until the count is reached or ctx is cancelled
+func (c *Client) StreamTraceroute(ctx context.Context, opts TracerouteOptions) (<-chan TracerouteRound, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	in, err := c.listen(ctx, opts.args())
+	if err != nil {
+		return nil, fmt.Errorf("failed to start traceroute to %s: %w", opts.Address, err)
+	}
+
+	out := make(chan TracerouteRound)
+	go func() {
+		defer close(out)
+
+		for rows := range sections(ctx, in) {
+			round := TracerouteRound{
+				Round: sectionNumber(rows) + 1,
+				Hops:  make([]TracerouteHop, 0, len(rows)),
+			}
+			for i, m := range rows {
+				round.Hops = append(round.Hops, mapToTracerouteHop(i+1, m))
+			}
+			select {
+			case out <- round:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func mapToTracerouteHop(hop int, m map[string]string) TracerouteHop {
+	loss, _ := strconv.ParseFloat(strings.TrimSuffix(m["loss"], "%"), 64)
+	return TracerouteHop{
+		Hop:     hop,
+		Address: m["address"],
+		Loss:    loss,
+		Sent:    int(parseUint(m["sent"])),
+		LastMs:  parseMillis(m["last"]),
+		AvgMs:   parseMillis(m["avg"]),
+		BestMs:  parseMillis(m["best"]),
+		WorstMs: parseMillis(m["worst"]),
+		StdDev:  parseMillis(m["std-dev"]),
+		Status:  m["status"],
+	}
+}
+
+// parseMillis parses a round-trip time; tool tables print bare numbers in milliseconds
+func parseMillis(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v
+	}
+	d, err := ParseDuration(s)
+	if err != nil {
+		return 0
+	}
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,135 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+)
+
+// email sends the alert over SMTP. Port 465 uses implicit TLS, any other port
+// upgrades with STARTTLS when the server offers it.
+//
+// Config: host, from, to (required; to is comma-separated), port (default 587),
+// username and password (optional, PLAIN auth)
+type email struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func newEmail(config map[string]string) (*email, error) {
+	host, err := required(config, "host")
+	if err != nil {
+		return nil, err
+	}
+	from, err := required(config, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := required(config, "to")
+	if err != nil {
+		return nil, err
+	}
+
+	port := strings.TrimSpace(config["port"])
+	if port == "" {
+		port = "587"
+	}
+
+	e := &email{
+		host:     host,
+		port:     port,
+		username: config["username"],
+		password: config["password"],
+		from:     from,
+		to:       splitList(to),
+	}
+	if len(e.to) == 0 {
+		return nil, fmt.Errorf("%w: config.to has no recipients", domain.ErrInvalidNotifier)
+	}
+	return e, nil
+}
+
+// Send delivers a plain text email to every recipient
+func (e *email) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(e.host, e.port)
+	tlsConfig := &tls.Config{ServerName: e.host}
+
+	var conn net.Conn
+	var err error
+	if e.port == "465" {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(30 * time.Second))
+	}
+
+	c, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if e.port != "465" {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if e.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(e.from); err != nil {
+		return err
+	}
+	for _, rcpt := range e.to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", e.from)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	body.WriteString("\r\n")
+
+	if _, err := w.Write([]byte(body.String())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
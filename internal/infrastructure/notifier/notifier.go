@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+)
+
+// Message is one alert notification
+type Message struct {
+	Title string        // short one-line summary, used as email subject
+	Text  string        // plain text body
+	Alert *domain.Alert // the alert being notified
+}
+
+// Notifier delivers alert messages to one channel
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// httpClient is shared by the HTTP based notifiers
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// New builds the notifier for a configured channel, validating its config
+func New(n *domain.Notifier) (Notifier, error) {
+	switch n.Type {
+	case domain.NotifierWebhook:
+		return newWebhook(n.Config)
+	case domain.NotifierEmail:
+		return newEmail(n.Config)
+	case domain.NotifierTelegram:
+		return newTelegram(n.Config)
+	case domain.NotifierWhatsApp:
+		return newWhatsApp(n.Config)
+	default:
+		return nil, fmt.Errorf("%w: type must be webhook, email, telegram or whatsapp", domain.ErrInvalidNotifier)
+	}
+}
+
+// required returns the trimmed value of key or an error naming the missing key
+func required(config map[string]string, key string) (string, error) {
+	value := strings.TrimSpace(config[key])
+	if value == "" {
+		return "", fmt.Errorf("%w: config.%s is required", domain.ErrInvalidNotifier, key)
+	}
+	return value, nil
+}
+
+// splitList splits a comma-separated config value, skipping empty entries
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// do sends the request and turns a non-2xx response into an error
+func do(req *http.Request) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
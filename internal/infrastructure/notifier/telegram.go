@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const defaultTelegramAPI = "https://api.telegram.org"
+
+// telegram sends the alert through a Telegram bot
+//
+// Config: bot_token, chat_id (required), api_url (optional, for a local Bot API server)
+type telegram struct {
+	apiURL string
+	token  string
+	chatID string
+}
+
+func newTelegram(config map[string]string) (*telegram, error) {
+	token, err := required(config, "bot_token")
+	if err != nil {
+		return nil, err
+	}
+	chatID, err := required(config, "chat_id")
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := strings.TrimRight(config["api_url"], "/")
+	if apiURL == "" {
+		apiURL = defaultTelegramAPI
+	}
+	return &telegram{apiURL: apiURL, token: token, chatID: chatID}, nil
+}
+
+// Send calls sendMessage with the title on the first line
+func (t *telegram) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  t.chatID,
+		"text":                     msg.Title + "\n\n" + msg.Text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := t.apiURL + "/bot" + t.token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return do(req)
+}
@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// webhook POSTs the alert as JSON
+//
+// Config: url (required), authorization (optional Authorization header value)
+type webhook struct {
+	url           string
+	authorization string
+}
+
+func newWebhook(config map[string]string) (*webhook, error) {
+	url, err := required(config, "url")
+	if err != nil {
+		return nil, err
+	}
+	return &webhook{url: url, authorization: config["authorization"]}, nil
+}
+
+// Send posts {"title","text","alert"} to the webhook URL
+func (w *webhook) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"title": msg.Title,
+		"text":  msg.Text,
+		"alert": msg.Alert,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.authorization != "" {
+		req.Header.Set("Authorization", w.authorization)
+	}
+
+	return do(req)
+}
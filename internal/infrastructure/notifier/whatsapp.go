@@ -0,0 +1,51 @@
+package notifier
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// whatsapp sends the alert through an HTTP WhatsApp gateway. The request is a
+// form POST with target and message fields and the token in the Authorization
+// header, which is what Fonnte-style gateways expect.
+//
+// Config: url, token, target (required; comma-separated numbers or group IDs)
+type whatsapp struct {
+	url    string
+	token  string
+	target string
+}
+
+func newWhatsApp(config map[string]string) (*whatsapp, error) {
+	gatewayURL, err := required(config, "url")
+	if err != nil {
+		return nil, err
+	}
+	token, err := required(config, "token")
+	if err != nil {
+		return nil, err
+	}
+	target, err := required(config, "target")
+	if err != nil {
+		return nil, err
+	}
+	return &whatsapp{url: gatewayURL, token: token, target: strings.Join(splitList(target), ",")}, nil
+}
+
+// Send posts the message to every target
+func (w *whatsapp) Send(ctx context.Context, msg Message) error {
+	form := url.Values{}
+	form.Set("target", w.target)
+	form.Set("message", "*"+msg.Title+"*\n\n"+msg.Text)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", w.token)
+
+	return do(req)
+}
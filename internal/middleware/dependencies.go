@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"mikrotik-collector/internal/application/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireDependencies rejects requests with 503 while one of the dependencies
+// failed its last background check, instead of letting the handler hang on it
+func RequireDependencies(health *services.HealthService, deps ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, dep := range deps {
+			if !health.Available(dep) {
+				c.Header("Retry-After", "10")
+				c.AbortWithStatusJSON(503, gin.H{
+					"status":     "error",
+					"message":    dep + " is unavailable",
+					"dependency": dep,
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
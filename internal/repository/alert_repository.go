@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseAlertRepository implements domain.AlertRepository
+type DatabaseAlertRepository struct {
+	db *gorm.DB
+}
+
+// NewDatabaseAlertRepository creates a new database alert repository
+func NewDatabaseAlertRepository(db *gorm.DB) *DatabaseAlertRepository {
+	return &DatabaseAlertRepository{
+		db: db,
+	}
+}
+
+// CreateRule creates a new alert rule
+func (r *DatabaseAlertRepository) CreateRule(rule *domain.AlertRule) error {
+	log.Printf("[AlertRepo] CreateRule - Creating rule %s (%s %s %v)\n", rule.Name, rule.Metric, rule.Operator, rule.Threshold)
+
+	if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = time.Now()
+	}
+	rule.UpdatedAt = time.Now()
+
+	if err := r.db.Create(rule).Error; err != nil {
+		if isDuplicateKey(err) {
+			return fmt.Errorf("%w: rule %s already exists", domain.ErrInvalidAlertRule, rule.Name)
+		}
+		log.Printf("[AlertRepo] CreateRule - ERROR: %v\n", err)
+		return fmt.Errorf("failed to create alert rule: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRule saves all fields of an alert rule
+func (r *DatabaseAlertRepository) UpdateRule(rule *domain.AlertRule) error {
+	rule.UpdatedAt = time.Now()
+
+	result := r.db.Model(rule).Select("*").Omit("created_at").Updates(rule)
+	if result.Error != nil {
+		if isDuplicateKey(result.Error) {
+			return fmt.Errorf("%w: rule %s already exists", domain.ErrInvalidAlertRule, rule.Name)
+		}
+		return fmt.Errorf("failed to update alert rule: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrAlertRuleNotFound, rule.ID)
+	}
+
+	return nil
+}
+
+// DeleteRule deletes an alert rule with its alerts and silences
+func (r *DatabaseAlertRepository) DeleteRule(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&domain.AlertRule{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrAlertRuleNotFound, id)
+	}
+
+	return nil
+}
+
+// GetRule retrieves an alert rule by ID
+func (r *DatabaseAlertRepository) GetRule(id string) (*domain.AlertRule, error) {
+	var rule domain.AlertRule
+
+	err := r.db.Where("id = ?", id).First(&rule).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAlertRuleNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// ListRules returns all alert rules
+func (r *DatabaseAlertRepository) ListRules() ([]*domain.AlertRule, error) {
+	var rules []*domain.AlertRule
+
+	if err := r.db.Order("name").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to query alert rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// CreateNotifier creates a new notifier
+func (r *DatabaseAlertRepository) CreateNotifier(n *domain.Notifier) error {
+	log.Printf("[AlertRepo] CreateNotifier - Creating %s notifier %s\n", n.Type, n.Name)
+
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	n.UpdatedAt = time.Now()
+
+	if err := r.db.Create(n).Error; err != nil {
+		if isDuplicateKey(err) {
+			return fmt.Errorf("%w: notifier %s already exists", domain.ErrInvalidNotifier, n.Name)
+		}
+		log.Printf("[AlertRepo] CreateNotifier - ERROR: %v\n", err)
+		return fmt.Errorf("failed to create notifier: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateNotifier saves all fields of a notifier
+func (r *DatabaseAlertRepository) UpdateNotifier(n *domain.Notifier) error {
+	n.UpdatedAt = time.Now()
+
+	result := r.db.Model(n).Select("*").Omit("created_at").Updates(n)
+	if result.Error != nil {
+		if isDuplicateKey(result.Error) {
+			return fmt.Errorf("%w: notifier %s already exists", domain.ErrInvalidNotifier, n.Name)
+		}
+		return fmt.Errorf("failed to update notifier: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrNotifierNotFound, n.ID)
+	}
+
+	return nil
+}
+
+// DeleteNotifier deletes a notifier
+func (r *DatabaseAlertRepository) DeleteNotifier(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&domain.Notifier{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete notifier: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrNotifierNotFound, id)
+	}
+
+	return nil
+}
+
+// GetNotifier retrieves a notifier by ID
+func (r *DatabaseAlertRepository) GetNotifier(id string) (*domain.Notifier, error) {
+	var n domain.Notifier
+
+	err := r.db.Where("id = ?", id).First(&n).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrNotifierNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifier: %w", err)
+	}
+
+	return &n, nil
+}
+
+// ListNotifiers returns all notifiers
+func (r *DatabaseAlertRepository) ListNotifiers() ([]*domain.Notifier, error) {
+	var list []*domain.Notifier
+
+	if err := r.db.Order("name").Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to query notifiers: %w", err)
+	}
+
+	return list, nil
+}
+
+// CreateAlert stores a newly fired alert
+func (r *DatabaseAlertRepository) CreateAlert(a *domain.Alert) error {
+	if err := r.db.Create(a).Error; err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+	return nil
+}
+
+// UpdateAlert saves the state of an alert
+func (r *DatabaseAlertRepository) UpdateAlert(a *domain.Alert) error {
+	err := r.db.Model(&domain.Alert{}).
+		Where("id = ?", a.ID).
+		Updates(map[string]interface{}{
+			"status":           a.Status,
+			"value":            a.Value,
+			"message":          a.Message,
+			"resolved_at":      a.ResolvedAt,
+			"last_notified_at": a.LastNotifiedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+	return nil
+}
+
+// ListAlerts returns alerts newest first, optionally filtered by status
+func (r *DatabaseAlertRepository) ListAlerts(status string, limit int) ([]*domain.Alert, error) {
+	var alerts []*domain.Alert
+
+	query := r.db.Order("starts_at DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// CreateSilence creates a new silence
+func (r *DatabaseAlertRepository) CreateSilence(s *domain.Silence) error {
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+
+	if err := r.db.Create(s).Error; err != nil {
+		return fmt.Errorf("failed to create silence: %w", err)
+	}
+	return nil
+}
+
+// DeleteSilence deletes a silence
+func (r *DatabaseAlertRepository) DeleteSilence(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&domain.Silence{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete silence: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrSilenceNotFound, id)
+	}
+
+	return nil
+}
+
+// ListSilences returns silences ordered by end time, optionally including ended ones
+func (r *DatabaseAlertRepository) ListSilences(includeExpired bool) ([]*domain.Silence, error) {
+	var silences []*domain.Silence
+
+	query := r.db.Order("ends_at")
+	if !includeExpired {
+		query = query.Where("ends_at > ?", time.Now())
+	}
+
+	if err := query.Find(&silences).Error; err != nil {
+		return nil, fmt.Errorf("failed to query silences: %w", err)
+	}
+
+	return silences, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"fmt"
+
+	"mikrotik-collector/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseAuditRepository implements domain.AuditRepository
+type DatabaseAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewDatabaseAuditRepository creates a new database audit repository
+func NewDatabaseAuditRepository(db *gorm.DB) *DatabaseAuditRepository {
+	return &DatabaseAuditRepository{
+		db: db,
+	}
+}
+
+// CreateAuditLog stores an audit entry
+func (r *DatabaseAuditRepository) CreateAuditLog(entry *domain.AuditLog) error {
+	if err := r.db.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
@@ -41,6 +41,34 @@ func (r *DatabaseCustomerRepository) GetActivePPPoECustomers() ([]*domain.Custom
 	return customers, nil
 }
 
+// ListPPPoECustomers retrieves every PPPoE customer regardless of status
+func (r *DatabaseCustomerRepository) ListPPPoECustomers() ([]*domain.Customer, error) {
+	var customers []*domain.Customer
+
+	err := r.db.Where("service_type = ? AND pppoe_username IS NOT NULL", "pppoe").
+		Find(&customers).Error
+	if err != nil {
+		log.Printf("[CustomerRepo] ListPPPoECustomers - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+
+	return customers, nil
+}
+
+// ListOnlineCustomers retrieves active customers that have an address to reach
+func (r *DatabaseCustomerRepository) ListOnlineCustomers() ([]*domain.Customer, error) {
+	var customers []*domain.Customer
+
+	err := r.db.Where("status = ? AND (assigned_ip IS NOT NULL OR static_ip IS NOT NULL)", "active").
+		Find(&customers).Error
+	if err != nil {
+		log.Printf("[CustomerRepo] ListOnlineCustomers - ERROR: %v\n", err)
+		return nil, fmt.Errorf("failed to query customers: %w", err)
+	}
+
+	return customers, nil
+}
+
 // GetCustomerByID retrieves a customer by ID
 func (r *DatabaseCustomerRepository) GetCustomerByID(id string) (*domain.Customer, error) {
 	log.Printf("[CustomerRepo] GetCustomerByID - Searching for customer with ID: %s\n", id)
@@ -50,7 +78,7 @@ func (r *DatabaseCustomerRepository) GetCustomerByID(id string) (*domain.Custome
 	err := r.db.Where("id = ?", id).First(&customer).Error
 	if err == gorm.ErrRecordNotFound {
 		log.Printf("[CustomerRepo] GetCustomerByID - Customer not found: %s\n", id)
-		return nil, fmt.Errorf("customer not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, id)
 	}
 	if err != nil {
 		log.Printf("[CustomerRepo] GetCustomerByID - ERROR: %v\n", err)
@@ -70,7 +98,7 @@ func (r *DatabaseCustomerRepository) GetCustomerByPPPoEUsername(username string)
 	err := r.db.Where("pppoe_username = ?", username).First(&customer).Error
 	if err == gorm.ErrRecordNotFound {
 		log.Printf("[CustomerRepo] GetCustomerByPPPoEUsername - Customer not found with PPPoE username: %s\n", username)
-		return nil, fmt.Errorf("customer not found with pppoe_username: %s", username)
+		return nil, fmt.Errorf("%w with pppoe_username: %s", domain.ErrCustomerNotFound, username)
 	}
 	if err != nil {
 		log.Printf("[CustomerRepo] GetCustomerByPPPoEUsername - ERROR: %v\n", err)
@@ -116,7 +144,7 @@ func (r *DatabaseCustomerRepository) UpdateCustomerStatus(id string, status stri
 	
 	if result.RowsAffected == 0 {
 		log.Printf("[CustomerRepo] UpdateCustomerStatus - Customer not found: %s\n", id)
-		return fmt.Errorf("customer not found: %s", id)
+		return fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, id)
 	}
 	
 	log.Printf("[CustomerRepo] UpdateCustomerStatus - SUCCESS: Updated customer %s (rows affected: %d)\n", id, result.RowsAffected)
@@ -133,7 +161,7 @@ func (r *DatabaseCustomerRepository) CreateCustomer(c *domain.Customer) error {
 	c.UpdatedAt = time.Now()
 	
 	log.Printf("[CustomerRepo] CreateCustomer - Customer details - ServiceType: %s, Status: %s, PPPoE Username: %s\n", 
-		c.ServiceType, c.Status, c.PPPoEUsername)
+		c.ServiceType, c.Status, domain.DerefOrEmpty(c.PPPoEUsername))
 	
 	err := r.db.Create(c).Error
 	if err != nil {
@@ -152,7 +180,7 @@ func (r *DatabaseCustomerRepository) UpdateCustomer(c *domain.Customer) error {
 	c.UpdatedAt = time.Now()
 	
 	log.Printf("[CustomerRepo] UpdateCustomer - Customer details - ServiceType: %s, Status: %s, PPPoE Username: %s\n", 
-		c.ServiceType, c.Status, c.PPPoEUsername)
+		c.ServiceType, c.Status, domain.DerefOrEmpty(c.PPPoEUsername))
 	
 	result := r.db.Model(&domain.Customer{}).
 		Where("id = ?", c.ID).
@@ -165,13 +193,55 @@ func (r *DatabaseCustomerRepository) UpdateCustomer(c *domain.Customer) error {
 	
 	if result.RowsAffected == 0 {
 		log.Printf("[CustomerRepo] UpdateCustomer - Customer not found: %s\n", c.ID)
-		return fmt.Errorf("customer not found: %s", c.ID)
+		return fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, c.ID)
 	}
 	
 	log.Printf("[CustomerRepo] UpdateCustomer - SUCCESS: Updated customer %s (rows affected: %d)\n", c.ID, result.RowsAffected)
 	return nil
 }
 
+// PatchCustomer applies a partial update. Nil patch values are written as NULL.
+// If expectedUpdatedAt is set, the update only succeeds when the stored updated_at still matches.
+func (r *DatabaseCustomerRepository) PatchCustomer(id string, patch domain.CustomerPatch, expectedUpdatedAt *time.Time) error {
+	log.Printf("[CustomerRepo] PatchCustomer - Patching customer %s (%d fields)\n", id, len(patch))
+
+	updates := make(map[string]interface{}, len(patch)+1)
+	for column, value := range patch {
+		if value == nil {
+			updates[column] = nil
+		} else {
+			updates[column] = *value
+		}
+	}
+	updates["updated_at"] = time.Now()
+
+	query := r.db.Model(&domain.Customer{}).Where("id = ?", id)
+	if expectedUpdatedAt != nil {
+		query = query.Where("updated_at = ?", *expectedUpdatedAt)
+	}
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		log.Printf("[CustomerRepo] PatchCustomer - ERROR: %v\n", result.Error)
+		return fmt.Errorf("failed to patch customer: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		// Distinguish a stale precondition from a missing row
+		var count int64
+		r.db.Model(&domain.Customer{}).Where("id = ?", id).Count(&count)
+		if count > 0 && expectedUpdatedAt != nil {
+			log.Printf("[CustomerRepo] PatchCustomer - Precondition failed for customer %s\n", id)
+			return domain.ErrCustomerModified
+		}
+		log.Printf("[CustomerRepo] PatchCustomer - Customer not found: %s\n", id)
+		return fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, id)
+	}
+
+	log.Printf("[CustomerRepo] PatchCustomer - SUCCESS: Patched customer %s\n", id)
+	return nil
+}
+
 // DeleteCustomer deletes a customer
 func (r *DatabaseCustomerRepository) DeleteCustomer(id string) error {
 	result := r.db.Where("id = ?", id).Delete(&domain.Customer{})
@@ -181,7 +251,7 @@ func (r *DatabaseCustomerRepository) DeleteCustomer(id string) error {
 	}
 	
 	if result.RowsAffected == 0 {
-		return fmt.Errorf("customer not found: %s", id)
+		return fmt.Errorf("%w: %s", domain.ErrCustomerNotFound, id)
 	}
 	
 	return nil
@@ -210,4 +280,4 @@ func (r *DatabaseCustomerRepository) ListCustomers(page, limit int) ([]*domain.C
 	}
 	
 	return customers, int(total), nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseIPAMRepository implements domain.IPAMRepository
+type DatabaseIPAMRepository struct {
+	db *gorm.DB
+}
+
+// NewDatabaseIPAMRepository creates a new database IPAM repository
+func NewDatabaseIPAMRepository(db *gorm.DB) *DatabaseIPAMRepository {
+	return &DatabaseIPAMRepository{
+		db: db,
+	}
+}
+
+// CreateSubnet creates a new subnet
+func (r *DatabaseIPAMRepository) CreateSubnet(s *domain.IPSubnet) error {
+	log.Printf("[IPAMRepo] CreateSubnet - Creating subnet %s on router %s\n", s.CIDR, s.RouterID)
+
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	s.UpdatedAt = time.Now()
+
+	if err := r.db.Create(s).Error; err != nil {
+		if isDuplicateKey(err) {
+			return fmt.Errorf("%w: subnet %s already exists", domain.ErrAddressConflict, s.CIDR)
+		}
+		log.Printf("[IPAMRepo] CreateSubnet - ERROR: %v\n", err)
+		return fmt.Errorf("failed to create subnet: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSubnet deletes a subnet (fails while allocations reference it)
+func (r *DatabaseIPAMRepository) DeleteSubnet(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&domain.IPSubnet{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete subnet: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrSubnetNotFound, id)
+	}
+
+	return nil
+}
+
+// GetSubnetByID retrieves a subnet by ID
+func (r *DatabaseIPAMRepository) GetSubnetByID(id string) (*domain.IPSubnet, error) {
+	var subnet domain.IPSubnet
+
+	err := r.db.Where("id = ?", id).First(&subnet).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSubnetNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subnet: %w", err)
+	}
+
+	return &subnet, nil
+}
+
+// ListSubnets returns the subnets of a router (all routers if routerID is empty)
+func (r *DatabaseIPAMRepository) ListSubnets(routerID string) ([]*domain.IPSubnet, error) {
+	var subnets []*domain.IPSubnet
+
+	query := r.db.Order("cidr")
+	if routerID != "" {
+		query = query.Where("router_id = ?", routerID)
+	}
+
+	if err := query.Find(&subnets).Error; err != nil {
+		return nil, fmt.Errorf("failed to query subnets: %w", err)
+	}
+
+	return subnets, nil
+}
+
+// CreateAllocation records an address allocation.
+// Returns domain.ErrAddressConflict if the address or customer is already allocated.
+func (r *DatabaseIPAMRepository) CreateAllocation(a *domain.IPAllocation) error {
+	log.Printf("[IPAMRepo] CreateAllocation - Allocating %s to customer %s\n", a.Address, a.CustomerID)
+
+	if a.AllocatedAt.IsZero() {
+		a.AllocatedAt = time.Now()
+	}
+
+	if err := r.db.Create(a).Error; err != nil {
+		if isDuplicateKey(err) {
+			return fmt.Errorf("%w: %s is already allocated", domain.ErrAddressConflict, a.Address)
+		}
+		log.Printf("[IPAMRepo] CreateAllocation - ERROR: %v\n", err)
+		return fmt.Errorf("failed to create allocation: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAllocation moves an allocation to another subnet/address.
+// Returns domain.ErrAddressConflict if the address is already allocated.
+func (r *DatabaseIPAMRepository) UpdateAllocation(a *domain.IPAllocation) error {
+	log.Printf("[IPAMRepo] UpdateAllocation - Moving customer %s to %s\n", a.CustomerID, a.Address)
+
+	err := r.db.Model(&domain.IPAllocation{}).Where("id = ?", a.ID).
+		Updates(map[string]interface{}{"subnet_id": a.SubnetID, "address": a.Address}).Error
+	if err != nil {
+		if isDuplicateKey(err) {
+			return fmt.Errorf("%w: %s is already allocated", domain.ErrAddressConflict, a.Address)
+		}
+		log.Printf("[IPAMRepo] UpdateAllocation - ERROR: %v\n", err)
+		return fmt.Errorf("failed to update allocation: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAllocationByCustomer releases the customer's address (no-op if none)
+func (r *DatabaseIPAMRepository) DeleteAllocationByCustomer(customerID string) error {
+	result := r.db.Where("customer_id = ?", customerID).Delete(&domain.IPAllocation{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete allocation: %w", result.Error)
+	}
+
+	log.Printf("[IPAMRepo] DeleteAllocationByCustomer - Released %d allocation(s) for customer %s\n",
+		result.RowsAffected, customerID)
+	return nil
+}
+
+// GetAllocationByCustomer returns the customer's allocation, or nil if it has none
+func (r *DatabaseIPAMRepository) GetAllocationByCustomer(customerID string) (*domain.IPAllocation, error) {
+	var allocation domain.IPAllocation
+
+	err := r.db.Where("customer_id = ?", customerID).First(&allocation).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allocation: %w", err)
+	}
+
+	return &allocation, nil
+}
+
+// ListAllocations returns all allocations of a subnet
+func (r *DatabaseIPAMRepository) ListAllocations(subnetID string) ([]*domain.IPAllocation, error) {
+	var allocations []*domain.IPAllocation
+
+	err := r.db.Where("subnet_id = ?", subnetID).Order("address").Find(&allocations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query allocations: %w", err)
+	}
+
+	return allocations, nil
+}
+
+// isDuplicateKey reports whether err is a Postgres unique violation (SQLSTATE 23505)
+func isDuplicateKey(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "23505") || strings.Contains(msg, "duplicate key")
+}
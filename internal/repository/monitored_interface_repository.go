@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseMonitoredInterfaceRepository implements domain.MonitoredInterfaceRepository
+type DatabaseMonitoredInterfaceRepository struct {
+	db *gorm.DB
+}
+
+// NewDatabaseMonitoredInterfaceRepository creates a new database monitored interface repository
+func NewDatabaseMonitoredInterfaceRepository(db *gorm.DB) *DatabaseMonitoredInterfaceRepository {
+	return &DatabaseMonitoredInterfaceRepository{
+		db: db,
+	}
+}
+
+// CreateInterface registers an interface for monitoring
+func (r *DatabaseMonitoredInterfaceRepository) CreateInterface(m *domain.MonitoredInterface) error {
+	log.Printf("[InterfaceRepo] CreateInterface - Registering %s on router %s\n", m.Name, m.RouterID)
+
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now()
+	}
+	m.UpdatedAt = time.Now()
+
+	if err := r.db.Create(m).Error; err != nil {
+		if isDuplicateKey(err) {
+			return fmt.Errorf("%w: %s is already monitored", domain.ErrInvalidMonitoredInterface, m.Name)
+		}
+		log.Printf("[InterfaceRepo] CreateInterface - ERROR: %v\n", err)
+		return fmt.Errorf("failed to create monitored interface: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateInterface saves all fields of a monitored interface
+func (r *DatabaseMonitoredInterfaceRepository) UpdateInterface(m *domain.MonitoredInterface) error {
+	m.UpdatedAt = time.Now()
+
+	result := r.db.Model(m).Select("*").Omit("created_at").Updates(m)
+	if result.Error != nil {
+		if isDuplicateKey(result.Error) {
+			return fmt.Errorf("%w: %s is already monitored", domain.ErrInvalidMonitoredInterface, m.Name)
+		}
+		return fmt.Errorf("failed to update monitored interface: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrMonitoredInterfaceNotFound, m.ID)
+	}
+
+	return nil
+}
+
+// DeleteInterface deletes a monitored interface with its history and alerts
+func (r *DatabaseMonitoredInterfaceRepository) DeleteInterface(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&domain.MonitoredInterface{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete monitored interface: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrMonitoredInterfaceNotFound, id)
+	}
+
+	return nil
+}
+
+// GetInterface retrieves a monitored interface by ID
+func (r *DatabaseMonitoredInterfaceRepository) GetInterface(id string) (*domain.MonitoredInterface, error) {
+	var m domain.MonitoredInterface
+
+	err := r.db.Where("id = ?", id).First(&m).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrMonitoredInterfaceNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query monitored interface: %w", err)
+	}
+
+	return &m, nil
+}
+
+// ListInterfaces returns the monitored interfaces of a router (all routers if routerID is empty)
+func (r *DatabaseMonitoredInterfaceRepository) ListInterfaces(routerID string) ([]*domain.MonitoredInterface, error) {
+	var list []*domain.MonitoredInterface
+
+	query := r.db.Order("name")
+	if routerID != "" {
+		query = query.Where("router_id = ?", routerID)
+	}
+
+	if err := query.Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to query monitored interfaces: %w", err)
+	}
+
+	return list, nil
+}
+
+// SaveHistory stores a one-minute traffic bucket
+func (r *DatabaseMonitoredInterfaceRepository) SaveHistory(h *domain.InterfaceTrafficHistory) error {
+	if err := r.db.Create(h).Error; err != nil {
+		return fmt.Errorf("failed to save interface history: %w", err)
+	}
+	return nil
+}
+
+// ListHistory returns the buckets of an interface between from and to, oldest first
+func (r *DatabaseMonitoredInterfaceRepository) ListHistory(interfaceID string, from, to time.Time) ([]*domain.InterfaceTrafficHistory, error) {
+	var history []*domain.InterfaceTrafficHistory
+
+	err := r.db.Where("interface_id = ? AND sampled_at >= ? AND sampled_at < ?", interfaceID, from, to).
+		Order("sampled_at").
+		Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interface history: %w", err)
+	}
+
+	return history, nil
+}
+
+// PruneHistory deletes buckets older than before
+func (r *DatabaseMonitoredInterfaceRepository) PruneHistory(before time.Time) (int64, error) {
+	result := r.db.Where("sampled_at < ?", before).Delete(&domain.InterfaceTrafficHistory{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune interface history: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// CreateAlert stores a newly fired alert
+func (r *DatabaseMonitoredInterfaceRepository) CreateAlert(a *domain.InterfaceAlert) error {
+	if err := r.db.Create(a).Error; err != nil {
+		return fmt.Errorf("failed to create interface alert: %w", err)
+	}
+	return nil
+}
+
+// UpdateAlert saves peak and resolution of an alert
+func (r *DatabaseMonitoredInterfaceRepository) UpdateAlert(a *domain.InterfaceAlert) error {
+	err := r.db.Model(&domain.InterfaceAlert{}).
+		Where("id = ?", a.ID).
+		Updates(map[string]interface{}{
+			"peak_percent": a.PeakPercent,
+			"resolved_at":  a.ResolvedAt,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update interface alert: %w", err)
+	}
+	return nil
+}
+
+// GetOpenAlert returns the unresolved alert of an interface, or nil if there is none
+func (r *DatabaseMonitoredInterfaceRepository) GetOpenAlert(interfaceID string) (*domain.InterfaceAlert, error) {
+	var alert domain.InterfaceAlert
+
+	err := r.db.Where("interface_id = ? AND resolved_at IS NULL", interfaceID).
+		Order("fired_at DESC").
+		First(&alert).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interface alert: %w", err)
+	}
+
+	return &alert, nil
+}
+
+// ListAlerts returns alerts, newest first, optionally for one interface and/or only unresolved ones
+func (r *DatabaseMonitoredInterfaceRepository) ListAlerts(interfaceID string, openOnly bool) ([]*domain.InterfaceAlert, error) {
+	var alerts []*domain.InterfaceAlert
+
+	query := r.db.Order("fired_at DESC").Limit(500)
+	if interfaceID != "" {
+		query = query.Where("interface_id = ?", interfaceID)
+	}
+	if openOnly {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed to query interface alerts: %w", err)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DatabasePlanRepository implements domain.PlanRepository
+type DatabasePlanRepository struct {
+	db *gorm.DB
+}
+
+// NewDatabasePlanRepository creates a new database plan repository
+func NewDatabasePlanRepository(db *gorm.DB) *DatabasePlanRepository {
+	return &DatabasePlanRepository{
+		db: db,
+	}
+}
+
+// CreatePlan creates a new plan
+func (r *DatabasePlanRepository) CreatePlan(p *domain.Plan) error {
+	log.Printf("[PlanRepo] CreatePlan - Creating plan: %s (profile: %s)\n", p.Name, p.ProfileName)
+
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+	p.UpdatedAt = time.Now()
+
+	if err := r.db.Create(p).Error; err != nil {
+		log.Printf("[PlanRepo] CreatePlan - ERROR: %v\n", err)
+		return fmt.Errorf("failed to create plan: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePlan saves all plan fields (including clearing optional ones)
+func (r *DatabasePlanRepository) UpdatePlan(p *domain.Plan) error {
+	log.Printf("[PlanRepo] UpdatePlan - Updating plan: %s (ID: %s)\n", p.Name, p.ID)
+
+	p.UpdatedAt = time.Now()
+
+	result := r.db.Model(&domain.Plan{}).
+		Where("id = ?", p.ID).
+		Select("*").
+		Omit("id", "created_at").
+		Updates(p)
+
+	if result.Error != nil {
+		log.Printf("[PlanRepo] UpdatePlan - ERROR: %v\n", result.Error)
+		return fmt.Errorf("failed to update plan: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrPlanNotFound, p.ID)
+	}
+
+	return nil
+}
+
+// DeletePlan deletes a plan (router profile mappings cascade)
+func (r *DatabasePlanRepository) DeletePlan(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&domain.Plan{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete plan: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrPlanNotFound, id)
+	}
+
+	return nil
+}
+
+// GetPlanByID retrieves a plan by ID
+func (r *DatabasePlanRepository) GetPlanByID(id string) (*domain.Plan, error) {
+	var plan domain.Plan
+
+	err := r.db.Where("id = ?", id).First(&plan).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrPlanNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query plan: %w", err)
+	}
+
+	return &plan, nil
+}
+
+// ListPlans returns all plans ordered by name
+func (r *DatabasePlanRepository) ListPlans() ([]*domain.Plan, error) {
+	var plans []*domain.Plan
+
+	if err := r.db.Order("name").Find(&plans).Error; err != nil {
+		return nil, fmt.Errorf("failed to query plans: %w", err)
+	}
+
+	return plans, nil
+}
+
+// CountCustomersByPlan returns how many customers reference a plan
+func (r *DatabasePlanRepository) CountCustomersByPlan(planID string) (int, error) {
+	var count int64
+
+	err := r.db.Model(&domain.Customer{}).Where("plan_id = ?", planID).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count customers for plan: %w", err)
+	}
+
+	return int(count), nil
+}
+
+// SaveRouterProfile inserts or updates the per-router profile mapping
+func (r *DatabasePlanRepository) SaveRouterProfile(p *domain.PlanRouterProfile) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "plan_id"}, {Name: "router_id"}},
+		UpdateAll: true,
+	}).Create(p).Error
+	if err != nil {
+		return fmt.Errorf("failed to save plan router profile: %w", err)
+	}
+	return nil
+}
+
+// ListRouterProfiles returns the router mappings for a plan
+func (r *DatabasePlanRepository) ListRouterProfiles(planID string) ([]domain.PlanRouterProfile, error) {
+	var profiles []domain.PlanRouterProfile
+
+	err := r.db.Where("plan_id = ?", planID).Order("router_id").Find(&profiles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query plan router profiles: %w", err)
+	}
+
+	return profiles, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseProbeRepository implements domain.ProbeRepository
+type DatabaseProbeRepository struct {
+	db *gorm.DB
+}
+
+// NewDatabaseProbeRepository creates a new database probe repository
+func NewDatabaseProbeRepository(db *gorm.DB) *DatabaseProbeRepository {
+	return &DatabaseProbeRepository{
+		db: db,
+	}
+}
+
+// SaveProbe stores one probe result
+func (r *DatabaseProbeRepository) SaveProbe(p *domain.CustomerProbe) error {
+	if err := r.db.Create(p).Error; err != nil {
+		return fmt.Errorf("failed to save probe: %w", err)
+	}
+	return nil
+}
+
+// ListProbes returns the probes of a customer between from and to, oldest first
+func (r *DatabaseProbeRepository) ListProbes(customerID string, from, to time.Time) ([]*domain.CustomerProbe, error) {
+	var probes []*domain.CustomerProbe
+
+	err := r.db.Where("customer_id = ? AND probed_at >= ? AND probed_at < ?", customerID, from, to).
+		Order("probed_at").
+		Find(&probes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query probes: %w", err)
+	}
+
+	return probes, nil
+}
+
+// PruneProbes deletes probes older than before
+func (r *DatabaseProbeRepository) PruneProbes(before time.Time) (int64, error) {
+	result := r.db.Where("probed_at < ?", before).Delete(&domain.CustomerProbe{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune probes: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseRouterHealthRepository implements domain.RouterHealthRepository
+type DatabaseRouterHealthRepository struct {
+	db *gorm.DB
+}
+
+// NewDatabaseRouterHealthRepository creates a new database router health repository
+func NewDatabaseRouterHealthRepository(db *gorm.DB) *DatabaseRouterHealthRepository {
+	return &DatabaseRouterHealthRepository{
+		db: db,
+	}
+}
+
+// SaveHealthSample stores one health sample
+func (r *DatabaseRouterHealthRepository) SaveHealthSample(s *domain.RouterHealthSample) error {
+	if err := r.db.Create(s).Error; err != nil {
+		return fmt.Errorf("failed to save router health sample: %w", err)
+	}
+	return nil
+}
+
+// ListHealthSamples returns the samples of a router between from and to, oldest first
+func (r *DatabaseRouterHealthRepository) ListHealthSamples(routerID string, from, to time.Time) ([]*domain.RouterHealthSample, error) {
+	var samples []*domain.RouterHealthSample
+
+	err := r.db.Where("router_id = ? AND sampled_at >= ? AND sampled_at < ?", routerID, from, to).
+		Order("sampled_at").
+		Find(&samples).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query router health history: %w", err)
+	}
+
+	return samples, nil
+}
+
+// PruneHealthSamples deletes samples older than before
+func (r *DatabaseRouterHealthRepository) PruneHealthSamples(before time.Time) (int64, error) {
+	result := r.db.Where("sampled_at < ?", before).Delete(&domain.RouterHealthSample{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune router health history: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseVoucherRepository implements domain.VoucherRepository
+type DatabaseVoucherRepository struct {
+	db *gorm.DB
+}
+
+// NewDatabaseVoucherRepository creates a new database voucher repository
+func NewDatabaseVoucherRepository(db *gorm.DB) *DatabaseVoucherRepository {
+	return &DatabaseVoucherRepository{
+		db: db,
+	}
+}
+
+// CreateBatch stores a batch and its vouchers in one transaction
+func (r *DatabaseVoucherRepository) CreateBatch(batch *domain.VoucherBatch, vouchers []*domain.Voucher) error {
+	log.Printf("[VoucherRepo] CreateBatch - Creating batch %s with %d vouchers\n", batch.Name, len(vouchers))
+
+	now := time.Now()
+	if batch.CreatedAt.IsZero() {
+		batch.CreatedAt = now
+	}
+	for _, v := range vouchers {
+		if v.CreatedAt.IsZero() {
+			v.CreatedAt = now
+		}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		if len(vouchers) > 0 {
+			if err := tx.CreateInBatches(vouchers, 100).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[VoucherRepo] CreateBatch - ERROR: %v\n", err)
+		return fmt.Errorf("failed to create voucher batch: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBatch deletes a batch (vouchers cascade)
+func (r *DatabaseVoucherRepository) DeleteBatch(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&domain.VoucherBatch{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete voucher batch: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrVoucherBatchNotFound, id)
+	}
+
+	return nil
+}
+
+// GetBatch retrieves a batch by ID
+func (r *DatabaseVoucherRepository) GetBatch(id string) (*domain.VoucherBatch, error) {
+	var batch domain.VoucherBatch
+
+	err := r.db.Where("id = ?", id).First(&batch).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrVoucherBatchNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query voucher batch: %w", err)
+	}
+
+	return &batch, nil
+}
+
+// ListBatches returns all batches, newest first
+func (r *DatabaseVoucherRepository) ListBatches() ([]*domain.VoucherBatch, error) {
+	var batches []*domain.VoucherBatch
+
+	if err := r.db.Order("created_at DESC").Find(&batches).Error; err != nil {
+		return nil, fmt.Errorf("failed to query voucher batches: %w", err)
+	}
+
+	return batches, nil
+}
+
+// ListVouchers returns the vouchers of a batch
+func (r *DatabaseVoucherRepository) ListVouchers(batchID string) ([]*domain.Voucher, error) {
+	var vouchers []*domain.Voucher
+
+	if err := r.db.Where("batch_id = ?", batchID).Order("code").Find(&vouchers).Error; err != nil {
+		return nil, fmt.Errorf("failed to query vouchers: %w", err)
+	}
+
+	return vouchers, nil
+}
+
+// ListVouchersByStatus returns vouchers in any of the given statuses
+func (r *DatabaseVoucherRepository) ListVouchersByStatus(statuses ...string) ([]*domain.Voucher, error) {
+	var vouchers []*domain.Voucher
+
+	if err := r.db.Where("status IN ?", statuses).Find(&vouchers).Error; err != nil {
+		return nil, fmt.Errorf("failed to query vouchers: %w", err)
+	}
+
+	return vouchers, nil
+}
+
+// GetVoucherByCode retrieves a voucher by its code
+func (r *DatabaseVoucherRepository) GetVoucherByCode(code string) (*domain.Voucher, error) {
+	var voucher domain.Voucher
+
+	err := r.db.Where("code = ?", code).First(&voucher).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("%w: %s", domain.ErrVoucherNotFound, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query voucher: %w", err)
+	}
+
+	return &voucher, nil
+}
+
+// UpdateVoucher saves the voucher's tracking fields
+func (r *DatabaseVoucherRepository) UpdateVoucher(v *domain.Voucher) error {
+	result := r.db.Model(&domain.Voucher{}).
+		Where("id = ?", v.ID).
+		Updates(map[string]interface{}{
+			"mikrotik_id":    v.MikrotikID,
+			"status":         v.Status,
+			"first_used_at":  v.FirstUsedAt,
+			"expires_at":     v.ExpiresAt,
+			"bytes_used":     v.BytesUsed,
+			"uptime_seconds": v.UptimeSeconds,
+			"last_synced_at": v.LastSyncedAt,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update voucher: %w", result.Error)
+	}
+
+	return nil
+}
@@ -3,7 +3,10 @@ package routes
 import (
 	"log"
 
+	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
 	"mikrotik-collector/internal/handlers"
+	"mikrotik-collector/internal/infrastructure/metrics"
 	"mikrotik-collector/internal/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -16,53 +19,180 @@ func SetupRoutes(
 	trafficHandler *handlers.TrafficMonitorHandler,
 	callbackHandler *handlers.CallbackHandler,
 	customerHandler *handlers.CustomerHandler,
+	planHandler *handlers.PlanHandler,
+	routerConfigHandler *handlers.RouterConfigHandler,
+	ipamHandler *handlers.IPAMHandler,
+	voucherHandler *handlers.VoucherHandler,
+	trafficAggregateHandler *handlers.TrafficAggregateHandler,
+	monitoredInterfaceHandler *handlers.MonitoredInterfaceHandler,
+	alertingHandler *handlers.AlertingHandler,
+	probeHandler *handlers.ProbeHandler,
+	diagnosticsHandler *handlers.DiagnosticsHandler,
+	sessionHandler *handlers.SessionHandler,
+	routerHealthHandler *handlers.RouterHealthHandler,
+	healthHandler *handlers.HealthHandler,
+	health *services.HealthService,
 ) *gin.Engine {
 	// Apply global middleware
 	router.Use(middleware.CORS())
 	router.Use(gin.Recovery())
 
+	// Degradation policies: which dependencies a route needs to be served.
+	// Routes answered from memory (traffic overview, probes, latest router
+	// health, /ws) keep working while MikroTik or the database is down.
+	needsDB := middleware.RequireDependencies(health, domain.DependencyDatabase)
+	needsRouter := middleware.RequireDependencies(health, domain.DependencyMikroTik)
+
 	// WebSocket endpoint
 	router.GET("/ws", wsHandler.HandleWS)
 
-	// Health check endpoint
-	router.GET("/health", wsHandler.HandleHealthCheck)
+	// Health check endpoints
+	router.GET("/health", healthHandler.Health)
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// API routes
 	api := router.Group("/api")
 	{
 		// Callback routes (MikroTik WebHooks)
-		callbacks := api.Group("/callbacks")
+		callbacks := api.Group("/callbacks", needsDB)
 		{
 			callbacks.POST("/pppoe-up", callbackHandler.HandlePPPoEUp)
 			callbacks.POST("/pppoe-down", callbackHandler.HandlePPPoEDown)
 		}
 
 		// Customer routes (CRUD)
-		customers := api.Group("/customers")
+		customers := api.Group("/customers", needsDB)
 		{
 			// CRUD operations (handled by CustomerHandler)
 			customers.GET("", customerHandler.ListCustomers)
 			customers.POST("", customerHandler.CreateCustomer)
 			customers.GET("/:id", customerHandler.GetCustomer)
 			customers.PUT("/:id", customerHandler.UpdateCustomer)
+			customers.PATCH("/:id", customerHandler.PatchCustomer)
+			customers.POST("/:id/static-ip", customerHandler.AssignStaticIP)
+			customers.DELETE("/:id/static-ip", customerHandler.ReleaseStaticIP)
 			customers.DELETE("/:id", customerHandler.DeleteCustomer)
 
+			// Live session actions (handled by SessionHandler)
+			customers.GET("/:id/session", needsRouter, sessionHandler.GetSession)
+			customers.POST("/:id/session/sync", needsRouter, sessionHandler.SyncSession)
+			customers.POST("/:id/disconnect", needsRouter, sessionHandler.DisconnectCustomer)
+
 			// Monitoring Specifics (handled by TrafficMonitorHandler)
 			// These extend the customer resource
-			customers.GET("/:id/ping", trafficHandler.GetPingHandler().PingCustomerByID)
-			customers.GET("/:id/ping/ws", trafficHandler.GetPingHandler().PingCustomerStream)
-			customers.GET("/:id/traffic/ws", trafficHandler.StreamCustomerTraffic)
+			customers.GET("/:id/ping", needsRouter, trafficHandler.GetPingHandler().PingCustomerByID)
+			customers.GET("/:id/ping/ws", needsRouter, trafficHandler.GetPingHandler().PingCustomerStream)
+			customers.GET("/:id/traffic/ws", needsRouter, trafficHandler.StreamCustomerTraffic)
+			customers.GET("/:id/probes", probeHandler.GetCustomerProbes)
+
+			// Router diagnostics streamed over WebSocket
+			customers.GET("/:id/traceroute/ws", needsRouter, diagnosticsHandler.TracerouteStream)
+			customers.GET("/:id/torch/ws", needsRouter, diagnosticsHandler.TorchStream)
+			customers.GET("/:id/btest/ws", needsRouter, diagnosticsHandler.BandwidthTestStream)
+		}
+
+		// Background reachability probes
+		api.GET("/probes", probeHandler.ListStatus)
+
+		// Plan routes (catalog mapped to /ppp/profile)
+		plans := api.Group("/plans", needsDB)
+		{
+			plans.GET("", planHandler.ListPlans)
+			plans.POST("", planHandler.CreatePlan)
+			plans.GET("/:id", planHandler.GetPlan)
+			plans.PUT("/:id", planHandler.UpdatePlan)
+			plans.DELETE("/:id", planHandler.DeletePlan)
+			plans.POST("/:id/sync", planHandler.SyncPlan)
+		}
+
+		// Router configuration (/ppp/profile, /ip/pool)
+		setupRouterConfigRoutes(api, routerConfigHandler, needsRouter)
+
+		// IPAM routes (static address subnets)
+		ipam := api.Group("/ipam", needsDB)
+		{
+			ipam.GET("/subnets", ipamHandler.ListSubnets)
+			ipam.POST("/subnets", ipamHandler.CreateSubnet)
+			ipam.GET("/subnets/:id", ipamHandler.GetSubnet)
+			ipam.DELETE("/subnets/:id", ipamHandler.DeleteSubnet)
+		}
+
+		// Hotspot voucher routes
+		vouchers := api.Group("/vouchers", needsDB)
+		{
+			vouchers.GET("/batches", voucherHandler.ListBatches)
+			vouchers.POST("/batches", voucherHandler.CreateBatch)
+			vouchers.GET("/batches/:id", voucherHandler.GetBatch)
+			vouchers.GET("/batches/:id/print", voucherHandler.PrintBatch)
+			vouchers.DELETE("/batches/:id", voucherHandler.DeleteBatch)
+			vouchers.GET("/jobs/:id", voucherHandler.GetJob)
+			vouchers.GET("/:code", voucherHandler.GetVoucher)
+		}
+
+		// Router-wide traffic overview
+		traffic := api.Group("/traffic")
+		{
+			traffic.GET("/summary", trafficAggregateHandler.GetSummary)
+			traffic.GET("/top", trafficAggregateHandler.GetTop)
+			traffic.GET("/ws", trafficAggregateHandler.StreamAggregate)
+		}
+
+		// Uplink/infrastructure interface monitoring
+		monitoredInterfaces := api.Group("/monitored-interfaces", needsDB)
+		{
+			monitoredInterfaces.GET("", monitoredInterfaceHandler.ListInterfaces)
+			monitoredInterfaces.POST("", monitoredInterfaceHandler.CreateInterface)
+			monitoredInterfaces.GET("/alerts", monitoredInterfaceHandler.ListAlerts)
+			monitoredInterfaces.GET("/:id", monitoredInterfaceHandler.GetInterface)
+			monitoredInterfaces.PUT("/:id", monitoredInterfaceHandler.UpdateInterface)
+			monitoredInterfaces.DELETE("/:id", monitoredInterfaceHandler.DeleteInterface)
+			monitoredInterfaces.GET("/:id/history", monitoredInterfaceHandler.GetHistory)
+		}
+
+		// Router health (/system/resource, /system/health)
+		routers := api.Group("/routers")
+		{
+			routers.GET("/:id/health", routerHealthHandler.GetHealth)
+			routers.GET("/:id/health/history", needsDB, routerHealthHandler.GetHistory)
+			routers.GET("/:id/health/ws", routerHealthHandler.StreamHealth)
+		}
+
+		// Alerting routes
+		alerting := api.Group("/alerting", needsDB)
+		{
+			alerting.GET("/rules", alertingHandler.ListRules)
+			alerting.POST("/rules", alertingHandler.CreateRule)
+			alerting.GET("/rules/:id", alertingHandler.GetRule)
+			alerting.PUT("/rules/:id", alertingHandler.UpdateRule)
+			alerting.DELETE("/rules/:id", alertingHandler.DeleteRule)
+
+			alerting.GET("/notifiers", alertingHandler.ListNotifiers)
+			alerting.POST("/notifiers", alertingHandler.CreateNotifier)
+			alerting.GET("/notifiers/:id", alertingHandler.GetNotifier)
+			alerting.PUT("/notifiers/:id", alertingHandler.UpdateNotifier)
+			alerting.DELETE("/notifiers/:id", alertingHandler.DeleteNotifier)
+			alerting.POST("/notifiers/:id/test", alertingHandler.TestNotifier)
+
+			alerting.GET("/silences", alertingHandler.ListSilences)
+			alerting.POST("/silences", alertingHandler.CreateSilence)
+			alerting.DELETE("/silences/:id", alertingHandler.DeleteSilence)
+
+			alerting.GET("/alerts", alertingHandler.ListAlerts)
 		}
 
 		// Monitor routes
-		monitor := api.Group("/monitor")
+		monitor := api.Group("/monitor", needsDB)
 		{
 			monitor.GET("/status", trafficHandler.GetStatus)
 		}
 
 		// Reload customers route
 		// trafficHandler.ReloadCustomers might be deprecated, but keeping if logic exists
-		api.POST("/reload-customers", trafficHandler.ReloadCustomers)
+		api.POST("/reload-customers", needsDB, trafficHandler.ReloadCustomers)
 	}
 
 	// Log registered routes
@@ -70,3 +200,32 @@ func SetupRoutes(
 
 	return router
 }
+
+// SetupRouterConfigRoutes registers the router configuration API on an engine
+// running without a database; it only talks to the router
+func SetupRouterConfigRoutes(router *gin.Engine, routerConfigHandler *handlers.RouterConfigHandler, health *services.HealthService) {
+	router.Use(middleware.CORS())
+	needsRouter := middleware.RequireDependencies(health, domain.DependencyMikroTik)
+	setupRouterConfigRoutes(router.Group("/api"), routerConfigHandler, needsRouter)
+}
+
+func setupRouterConfigRoutes(api *gin.RouterGroup, routerConfigHandler *handlers.RouterConfigHandler, needsRouter gin.HandlerFunc) {
+	ppp := api.Group("/ppp", needsRouter)
+	{
+		ppp.GET("/profiles", routerConfigHandler.ListPPPProfiles)
+		ppp.POST("/profiles", routerConfigHandler.CreatePPPProfile)
+		ppp.GET("/profiles/:id", routerConfigHandler.GetPPPProfile)
+		ppp.PUT("/profiles/:id", routerConfigHandler.UpdatePPPProfile)
+		ppp.DELETE("/profiles/:id", routerConfigHandler.DeletePPPProfile)
+	}
+
+	ip := api.Group("/ip", needsRouter)
+	{
+		ip.GET("/pools", routerConfigHandler.ListIPPools)
+		ip.POST("/pools", routerConfigHandler.CreateIPPool)
+		ip.GET("/pools/:id", routerConfigHandler.GetIPPool)
+		ip.GET("/pools/:id/used", routerConfigHandler.ListIPPoolUsed)
+		ip.PUT("/pools/:id", routerConfigHandler.UpdateIPPool)
+		ip.DELETE("/pools/:id", routerConfigHandler.DeleteIPPool)
+	}
+}
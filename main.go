@@ -10,13 +10,16 @@ import (
 	"time"
 
 	"mikrotik-collector/internal/application/services"
+	"mikrotik-collector/internal/domain"
 	"mikrotik-collector/internal/handlers"
+	"mikrotik-collector/internal/infrastructure/metrics"
 	"mikrotik-collector/internal/infrastructure/mikrotik"
 	"mikrotik-collector/internal/repository"
 	"mikrotik-collector/internal/routes"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -36,6 +39,7 @@ func main() {
 
 	// Initialize MikroTik client
 	mtClient, err := mikrotik.NewClient(mikrotik.Config{
+		RouterID: cfg.MikroTikRouterID,
 		Host:     cfg.MikroTikHost,
 		Port:     cfg.MikroTikPortInt(),
 		Username: cfg.MikroTikUsername,
@@ -51,47 +55,177 @@ func main() {
 	log.Println("MikroTik connected successfully")
 
 	// Initialize WebSocket handler (global broadcasts)
-	wsHandler := handlers.NewWebSocketHandler()
+	wsHandler := handlers.NewWebSocketHandler(handlers.WebSocketOptions{
+		QueueSize:        cfg.WSSendQueueSize,
+		SlowClientPolicy: cfg.WSSlowClientPolicy,
+		WriteTimeout:     cfg.WSWriteTimeout,
+		PongTimeout:      cfg.WSPongTimeout,
+	})
+	metrics.RegisterGauge("websocket_clients", "Clients connected to the global /ws endpoint.",
+		func() float64 { return float64(wsHandler.GetClientCount()) })
+
+	// Router configuration handler (no DB required, served in both route setups below)
+	routerConfigHandler := handlers.NewRouterConfigHandler(mtClient)
 
 	// Initialize Redis publisher
 	publisher := NewRedisPublisher(cfg)
 	defer publisher.Close()
 
-	// Initialize DB with GORM
+	// Background workers stop when the app shuts down
+	appCtx, cancelApp := context.WithCancel(context.Background())
+	defer cancelApp()
+
+	// Dependency checks run in the background; /readyz and the route
+	// degradation policies read their last result
+	healthService := services.NewHealthService(cfg.HealthCheckInterval, cfg.HealthCheckTimeout)
+	healthService.Register(domain.DependencyMikroTik, true, mtClient.CheckAPI)
+	healthService.Register(domain.DependencyRedis, cfg.ReadinessRequireRedis, publisher.Ping)
+
+	// Initialize DB with GORM. An unreachable database does not stop startup:
+	// DB-backed routes answer 503 until the health check sees it come back.
 	var db *gorm.DB
 	if cfg.EnableTrafficMonitor {
 		dbConn, err := InitDatabaseGORM(cfg)
 		if err != nil {
-			log.Printf("WARNING: Database connection failed: %v", err)
-			cfg.EnableTrafficMonitor = false
+			log.Printf("ERROR: Database setup failed, customer, plan and alerting APIs are disabled: %v", err)
+			// Keep /readyz failing with the cause instead of looking healthy
+			setupErr := err
+			healthService.Register(domain.DependencyDatabase, true, func(context.Context) error { return setupErr })
 		} else {
 			db = dbConn
-			log.Println("Database connected successfully")
+			healthService.Register(domain.DependencyDatabase, true, func(ctx context.Context) error {
+				sqlDB, err := db.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.PingContext(ctx)
+			})
 		}
+	} else {
+		log.Println("Database disabled (ENABLE_TRAFFIC_MONITOR=false), customer, plan and alerting APIs are disabled")
 	}
+	go healthService.Run(appCtx)
+	healthHandler := handlers.NewHealthHandler(healthService, wsHandler)
 
 	// Initialize Handlers placeholders
 	var trafficHandler *handlers.TrafficMonitorHandler
 	var callbackHandler *handlers.CallbackHandler
 	var customerHandler *handlers.CustomerHandler
+	var planHandler *handlers.PlanHandler
+	var ipamHandler *handlers.IPAMHandler
+	var voucherHandler *handlers.VoucherHandler
+	var trafficAggregateHandler *handlers.TrafficAggregateHandler
+	var monitoredInterfaceHandler *handlers.MonitoredInterfaceHandler
+	var alertingHandler *handlers.AlertingHandler
+	var probeHandler *handlers.ProbeHandler
+	var diagnosticsHandler *handlers.DiagnosticsHandler
+	var sessionHandler *handlers.SessionHandler
+	var routerHealthHandler *handlers.RouterHealthHandler
 
 	// Initialize Services if DB is up
 	if db != nil {
 		// New Repositories
 		customerRepo := repository.NewDatabaseCustomerRepository(db)
+		planRepo := repository.NewDatabasePlanRepository(db)
+		ipamRepo := repository.NewDatabaseIPAMRepository(db)
+		voucherRepo := repository.NewDatabaseVoucherRepository(db)
+		monitoredInterfaceRepo := repository.NewDatabaseMonitoredInterfaceRepository(db)
+		alertRepo := repository.NewDatabaseAlertRepository(db)
+		probeRepo := repository.NewDatabaseProbeRepository(db)
+		auditRepo := repository.NewDatabaseAuditRepository(db)
+		routerHealthRepo := repository.NewDatabaseRouterHealthRepository(db)
+
+		// One monitor-traffic listen shared by customer and uplink monitoring
+		trafficMux := mikrotik.NewTrafficMux(mtClient)
+		defer trafficMux.Close()
 
 		// New Services
-		trafficService := services.NewOnDemandTrafficService(mtClient, customerRepo, publisher)
-		customerService := services.NewCustomerService(customerRepo, mtClient)
+		alertService := services.NewAlertService(alertRepo, publisher, cfg.AlertEvalInterval, cfg.AlertSeriesStale)
+		// Router reachability comes from the background API check, not from failed samples
+		healthService.Watch(domain.DependencyMikroTik, func(status domain.DependencyStatus) {
+			unreachable := 0.0
+			if status.Status == domain.DependencyDown {
+				unreachable = 1
+			}
+			alertService.Observe(domain.MetricRouterUnreachable, cfg.MikroTikRouterID, "", unreachable)
+		})
+		trafficService := services.NewOnDemandTrafficService(mtClient, trafficMux, customerRepo, publisher, services.MonitorLimits{
+			Global:       cfg.MaxConcurrentMonitors,
+			PerUser:      cfg.MaxMonitorsPerUser,
+			QueueTimeout: cfg.MonitorQueueTimeout,
+		})
+		// Replicas share customer monitors through Redis leases
+		trafficService.SetCoordinator(NewRedisMonitorCoordinator(publisher, cfg.InstanceID), cfg.MonitorLeaseTTL)
+		// /ws subscribers of customer:<id>:traffic keep the customer's monitor running
+		wsHandler.SetTrafficService(trafficService)
+		ipamService := services.NewIPAMService(ipamRepo, mtClient)
+		customerService := services.NewCustomerService(customerRepo, planRepo, ipamService, mtClient)
+		planService := services.NewPlanService(planRepo, mtClient)
+		voucherService := services.NewVoucherService(voucherRepo, mtClient)
+		go voucherService.Run(appCtx, cfg.VoucherSyncInterval)
+		trafficAggregateService := services.NewTrafficAggregateService(mtClient, customerRepo, publisher,
+			cfg.TrafficUplinkInterfaces, cfg.TrafficAggregateInterval, cfg.TrafficTopN)
+		interfaceMonitorService := services.NewInterfaceMonitorService(monitoredInterfaceRepo, mtClient, trafficMux,
+			publisher, cfg.InterfaceHistoryRetention)
+		trafficAggregateService.SetUplinkSource(interfaceMonitorService.UplinkNames)
+
+		// Alerting and uplink monitoring load their state once at start, so they
+		// wait for the database instead of starting empty
+		go func() {
+			if !healthService.WaitUntilUp(appCtx, domain.DependencyDatabase) {
+				return
+			}
+			go alertService.Run(appCtx)
+			go interfaceMonitorService.Run(appCtx)
+		}()
+		go trafficAggregateService.Run(appCtx)
+		probeService := services.NewProbeService(mtClient, customerRepo, probeRepo, publisher, alertService, services.ProbeSettings{
+			Interval:     cfg.ProbeInterval,
+			Count:        cfg.ProbeCount,
+			Concurrency:  cfg.ProbeConcurrency,
+			DegradedLoss: cfg.ProbeDegradedLoss,
+			DegradedRtt:  cfg.ProbeDegradedRtt,
+			Retention:    cfg.ProbeHistoryRetention,
+		})
+		if cfg.EnableReachabilityProbes {
+			go probeService.Run(appCtx)
+		}
+		sessionService := services.NewSessionService(mtClient, customerRepo, auditRepo, publisher)
+		routerHealthService := services.NewRouterHealthService(mtClient, routerHealthRepo, publisher, alertService,
+			cfg.RouterHealthInterval, cfg.RouterHealthRetention)
+		go routerHealthService.Run(appCtx)
+
+		// Scrape-time metrics over the running services
+		metrics.RegisterGauge("active_monitors", "Customers with a running traffic monitor.",
+			func() float64 { return float64(trafficService.Usage().Active) })
+		metrics.RegisterGauge("queued_monitors", "Clients waiting for a free monitor slot.",
+			func() float64 { return float64(trafficService.Usage().Queued) })
+		metrics.RegisterGauge("monitor_observers", "Traffic streams attached to running monitors.",
+			func() float64 { return float64(trafficService.Usage().Observers) })
+		prometheus.MustRegister(metrics.NewTrafficCollector(
+			trafficAggregateService.Latest,
+			func(n int) []domain.TalkerRate { return trafficAggregateService.TopTalkers(n, services.TopByTotal) },
+			metrics.TrafficOptions{
+				PerCustomer:  cfg.MetricsCustomerTraffic,
+				MaxCustomers: cfg.MetricsMaxCustomerSeries,
+			},
+		))
 
 		// Create Handlers
-		trafficHandler = handlers.NewTrafficMonitorHandler(trafficService, customerRepo, mtClient)
-		callbackHandler = handlers.NewCallbackHandler(customerRepo, publisher)
+		trafficHandler = handlers.NewTrafficMonitorHandler(trafficService, customerRepo, mtClient, alertService, sessionService,
+			NewRedisTrafficHistory(publisher))
+		callbackHandler = handlers.NewCallbackHandler(customerRepo, publisher, trafficService, alertService, cfg.MikroTikRouterID)
 		customerHandler = handlers.NewCustomerHandler(customerService)
-	} else {
-		// Fallback if DB connects fails, but wait, TrafficHandler needs repo...
-		// If DB fails, we probably can't run most things.
-		log.Println("Running in limited mode (No Database)")
+		planHandler = handlers.NewPlanHandler(planService)
+		ipamHandler = handlers.NewIPAMHandler(ipamService)
+		voucherHandler = handlers.NewVoucherHandler(voucherService, cfg.HotspotLoginURL)
+		trafficAggregateHandler = handlers.NewTrafficAggregateHandler(trafficAggregateService)
+		monitoredInterfaceHandler = handlers.NewMonitoredInterfaceHandler(interfaceMonitorService)
+		alertingHandler = handlers.NewAlertingHandler(alertService)
+		probeHandler = handlers.NewProbeHandler(probeService)
+		diagnosticsHandler = handlers.NewDiagnosticsHandler(mtClient, customerRepo, sessionService, cfg.BtestUsername, cfg.BtestPassword)
+		sessionHandler = handlers.NewSessionHandler(sessionService)
+		routerHealthHandler = handlers.NewRouterHealthHandler(routerHealthService)
 	}
 
 	// Setup Redis Event Subscriber (Global)
@@ -119,11 +253,15 @@ func main() {
 	// Setup routes (API only, no template rendering)
 	if customerHandler != nil {
 		log.Println("Setting up routes...")
-		routes.SetupRoutes(router, wsHandler, trafficHandler, callbackHandler, customerHandler)
+		routes.SetupRoutes(router, wsHandler, trafficHandler, callbackHandler, customerHandler, planHandler, routerConfigHandler, ipamHandler, voucherHandler, trafficAggregateHandler, monitoredInterfaceHandler, alertingHandler, probeHandler, diagnosticsHandler, sessionHandler, routerHealthHandler, healthHandler, healthService)
 	} else {
-		// Minimal setup
+		// Without a database only health, metrics and the router configuration API are served
 		router.Use(gin.Recovery())
-		router.GET("/health", wsHandler.HandleHealthCheck)
+		routes.SetupRouterConfigRoutes(router, routerConfigHandler, healthService)
+		router.GET("/health", healthHandler.Health)
+		router.GET("/healthz", healthHandler.Liveness)
+		router.GET("/readyz", healthHandler.Readiness)
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
 	}
 
 	// Create HTTP server
@@ -144,7 +282,16 @@ func main() {
 	}()
 
 	// Start WebSocket Broadcaster
-	go wsHandler.Broadcaster()
+	go wsHandler.Broadcaster(appCtx)
+
+	// Fan the traffic stream out to this replica's /ws clients
+	streamConsumer := NewRedisStreamConsumer(cfg, wsHandler.GetBroadcastChannel())
+	defer streamConsumer.Close()
+	go streamConsumer.Start(appCtx)
+
+	// Forward the topics /ws clients subscribed to
+	topicConsumer := NewRedisTopicConsumer(publisher, wsHandler.SubscribedTopics, wsHandler.PublishTopic)
+	go topicConsumer.Start(appCtx)
 
 	// Graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -152,10 +299,14 @@ func main() {
 	<-sigChan
 
 	log.Println("\nShutting down gracefully...")
+	cancelApp()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Hijacked /ws connections are not closed by server.Shutdown
+	wsHandler.Close(ctx)
+
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Server shutdown error: %v", err)
 	}
@@ -182,8 +333,11 @@ func InitDatabaseGORM(cfg *Config) (*gorm.DB, error) {
 		},
 	)
 
+	// Without the automatic ping Open only fails on a bad configuration, so
+	// the app can start while the database is still down
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger,
+		Logger:               gormLogger,
+		DisableAutomaticPing: true,
 		NowFunc: func() time.Time {
 			return time.Now().Local()
 		},
@@ -208,8 +362,8 @@ func InitDatabaseGORM(cfg *Config) (*gorm.DB, error) {
 
 	log.Println("[Database] Testing connection...")
 	if err := sqlDB.Ping(); err != nil {
-		log.Printf("[Database] ERROR: Ping failed: %v", err)
-		return nil, err
+		log.Printf("[Database] WARNING: Ping failed, DB-backed routes return 503 until it recovers: %v", err)
+		return db, nil
 	}
 
 	log.Println("[Database] SUCCESS: Connection established and tested")
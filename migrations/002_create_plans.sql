@@ -0,0 +1,51 @@
+-- Migration: Create service plans catalog
+-- Description: Plans map to a /ppp/profile on every router; customers reference a plan
+
+CREATE TABLE IF NOT EXISTS plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+
+    -- Name of the /ppp/profile created on each router (immutable)
+    profile_name VARCHAR(100) NOT NULL UNIQUE,
+
+    -- Rates in RouterOS notation (e.g. 10M, 512k)
+    download_rate VARCHAR(20) NOT NULL,
+    upload_rate VARCHAR(20) NOT NULL,
+
+    -- Burst settings (optional)
+    burst_download_rate VARCHAR(20),
+    burst_upload_rate VARCHAR(20),
+    burst_threshold_download VARCHAR(20),
+    burst_threshold_upload VARCHAR(20),
+    burst_time INTEGER, -- seconds
+
+    -- Profile addressing (optional)
+    local_address VARCHAR(64),
+    remote_address VARCHAR(64), -- address or /ip/pool name
+
+    price NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    quota_bytes BIGINT, -- NULL = unlimited
+
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Per-router mapping of a plan to its /ppp/profile entry
+CREATE TABLE IF NOT EXISTS plan_router_profiles (
+    plan_id UUID NOT NULL REFERENCES plans(id) ON DELETE CASCADE,
+    router_id VARCHAR(100) NOT NULL,
+    mikrotik_id VARCHAR(100),
+    synced_at TIMESTAMPTZ,
+    last_error TEXT,
+    PRIMARY KEY (plan_id, router_id)
+);
+
+CREATE TRIGGER update_plans_updated_at
+    BEFORE UPDATE ON plans
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Customers reference a plan instead of a raw profile string
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS plan_id UUID REFERENCES plans(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_customers_plan ON customers(plan_id);
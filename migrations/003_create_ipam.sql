@@ -0,0 +1,30 @@
+-- Migration: Create IPAM tables
+-- Description: Static address subnets per router and per-customer allocations
+
+CREATE TABLE IF NOT EXISTS ipam_subnets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    router_id VARCHAR(100) NOT NULL,
+    cidr CIDR NOT NULL,
+    gateway INET, -- pushed as local-address on the PPP secret
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (router_id, cidr)
+);
+
+CREATE TABLE IF NOT EXISTS ipam_allocations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subnet_id UUID NOT NULL REFERENCES ipam_subnets(id) ON DELETE RESTRICT,
+    customer_id UUID NOT NULL UNIQUE REFERENCES customers(id) ON DELETE CASCADE,
+    address INET NOT NULL,
+    allocated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (subnet_id, address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ipam_subnets_router ON ipam_subnets(router_id);
+CREATE INDEX IF NOT EXISTS idx_ipam_allocations_subnet ON ipam_allocations(subnet_id);
+
+CREATE TRIGGER update_ipam_subnets_updated_at
+    BEFORE UPDATE ON ipam_subnets
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
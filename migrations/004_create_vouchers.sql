@@ -0,0 +1,36 @@
+-- Migration: Create hotspot voucher tables
+-- Description: Voucher batches generated as /ip/hotspot/user entries with usage tracking
+
+CREATE TABLE IF NOT EXISTS voucher_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    router_id VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    profile VARCHAR(100) NOT NULL DEFAULT 'default', -- hotspot user profile
+    price NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    price_tag VARCHAR(50), -- label printed on the voucher, e.g. "Rp5.000"
+    validity_seconds INTEGER NOT NULL DEFAULT 0, -- counted from first use, 0 = no expiry
+    limit_uptime VARCHAR(20), -- RouterOS notation, e.g. "3h"
+    limit_bytes_total BIGINT, -- NULL = unlimited
+    count INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TYPE voucher_status AS ENUM ('unused', 'active', 'expired', 'exhausted');
+
+CREATE TABLE IF NOT EXISTS vouchers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    batch_id UUID NOT NULL REFERENCES voucher_batches(id) ON DELETE CASCADE,
+    code VARCHAR(50) NOT NULL UNIQUE, -- hotspot username
+    password VARCHAR(50) NOT NULL,
+    mikrotik_id VARCHAR(100),
+    status voucher_status NOT NULL DEFAULT 'unused',
+    first_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    bytes_used BIGINT NOT NULL DEFAULT 0,
+    uptime_seconds BIGINT NOT NULL DEFAULT 0,
+    last_synced_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vouchers_batch ON vouchers(batch_id);
+CREATE INDEX IF NOT EXISTS idx_vouchers_status ON vouchers(status);
@@ -0,0 +1,47 @@
+-- Migration: Create monitored interface tables
+-- Description: Infrastructure interfaces (WAN, bridge, VLAN) with capacity, per-minute history and utilization alerts
+
+CREATE TABLE IF NOT EXISTS monitored_interfaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    router_id VARCHAR(100) NOT NULL,
+    name VARCHAR(100) NOT NULL, -- RouterOS interface name, e.g. ether1
+    kind VARCHAR(20) NOT NULL DEFAULT 'wan', -- wan, bridge, vlan, other
+    capacity_bps BIGINT NOT NULL,
+    threshold_percent INTEGER NOT NULL DEFAULT 80,
+    threshold_minutes INTEGER NOT NULL DEFAULT 5,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (router_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS interface_traffic_history (
+    id BIGSERIAL PRIMARY KEY,
+    interface_id UUID NOT NULL REFERENCES monitored_interfaces(id) ON DELETE CASCADE,
+    rx_avg_bps BIGINT NOT NULL,
+    tx_avg_bps BIGINT NOT NULL,
+    rx_max_bps BIGINT NOT NULL,
+    tx_max_bps BIGINT NOT NULL,
+    utilization_percent NUMERIC(6, 2) NOT NULL, -- busiest direction (avg) vs capacity
+    sampled_at TIMESTAMPTZ NOT NULL -- start of the minute bucket
+);
+
+CREATE INDEX IF NOT EXISTS idx_interface_history_iface_time ON interface_traffic_history(interface_id, sampled_at);
+
+CREATE TABLE IF NOT EXISTS interface_alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    interface_id UUID NOT NULL REFERENCES monitored_interfaces(id) ON DELETE CASCADE,
+    threshold_percent INTEGER NOT NULL,
+    peak_percent NUMERIC(6, 2) NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL, -- when utilization first crossed the threshold
+    fired_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_interface_alerts_open ON interface_alerts(interface_id) WHERE resolved_at IS NULL;
+
+CREATE TRIGGER update_monitored_interfaces_updated_at
+    BEFORE UPDATE ON monitored_interfaces
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,71 @@
+-- Migration: Create alerting tables
+-- Description: Threshold rules evaluated against traffic, ping and session metrics, notifier channels, alerts and silences
+
+CREATE TABLE IF NOT EXISTS alert_notifiers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    type VARCHAR(20) NOT NULL, -- webhook, email, telegram, whatsapp
+    config JSONB NOT NULL DEFAULT '{}', -- type specific settings (url, smtp host, bot token, ...)
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    metric VARCHAR(50) NOT NULL, -- customer_offline, packet_loss_percent, latency_ms, interface_utilization_percent, router_unreachable
+    operator VARCHAR(2) NOT NULL, -- >, >=, <, <=, ==, !=
+    threshold NUMERIC(14, 2) NOT NULL,
+    for_seconds INTEGER NOT NULL DEFAULT 0, -- condition must hold this long before firing
+    subject VARCHAR(100), -- only this customer ID / interface / router, NULL = all
+    severity VARCHAR(20) NOT NULL DEFAULT 'warning', -- info, warning, critical
+    notifier_ids JSONB NOT NULL DEFAULT '[]', -- empty = every enabled notifier
+    repeat_minutes INTEGER NOT NULL DEFAULT 0, -- re-notify while firing, 0 = once
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID NOT NULL REFERENCES alert_rules(id) ON DELETE CASCADE,
+    rule_name VARCHAR(100) NOT NULL,
+    metric VARCHAR(50) NOT NULL,
+    subject VARCHAR(100) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'firing', -- firing, resolved
+    value NUMERIC(14, 2) NOT NULL,
+    threshold NUMERIC(14, 2) NOT NULL,
+    message TEXT NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    last_notified_at TIMESTAMPTZ
+);
+
+-- Deduplication: one firing alert per rule and subject
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alerts_firing ON alerts(rule_id, subject) WHERE status = 'firing';
+CREATE INDEX IF NOT EXISTS idx_alerts_starts_at ON alerts(starts_at);
+
+CREATE TABLE IF NOT EXISTS alert_silences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID REFERENCES alert_rules(id) ON DELETE CASCADE, -- NULL = every rule
+    subject VARCHAR(100), -- NULL = every subject
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    comment TEXT,
+    created_by VARCHAR(100),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_silences_ends_at ON alert_silences(ends_at);
+
+CREATE TRIGGER update_alert_notifiers_updated_at
+    BEFORE UPDATE ON alert_notifiers
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_alert_rules_updated_at
+    BEFORE UPDATE ON alert_rules
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,19 @@
+-- Migration: Create customer probe history
+-- Description: Background reachability pings of online customers (latency/loss per probe)
+
+CREATE TABLE IF NOT EXISTS customer_probe_history (
+    id BIGSERIAL PRIMARY KEY,
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    ip_address VARCHAR(45) NOT NULL,
+    sent INTEGER NOT NULL,
+    received INTEGER NOT NULL,
+    packet_loss NUMERIC(5, 2) NOT NULL, -- percent
+    min_rtt_ms NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    avg_rtt_ms NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    max_rtt_ms NUMERIC(10, 3) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL, -- reachable, degraded, unreachable
+    probed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_probe_history_customer_time ON customer_probe_history(customer_id, probed_at);
+CREATE INDEX IF NOT EXISTS idx_probe_history_probed_at ON customer_probe_history(probed_at);
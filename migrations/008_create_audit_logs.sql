@@ -0,0 +1,15 @@
+-- Migration: Create audit log
+-- Description: Record of operator actions against the router (session kicks, ...)
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(50) NOT NULL, -- e.g. customer.disconnect
+    resource_type VARCHAR(50) NOT NULL, -- e.g. customer
+    resource_id VARCHAR(100) NOT NULL,
+    actor VARCHAR(100) NOT NULL, -- X-API-User header, else client IP
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_resource ON audit_logs(resource_type, resource_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
@@ -0,0 +1,22 @@
+-- Migration: Create router health history
+-- Description: Periodic /system/resource, /system/health, PPP session and interface error samples per router
+
+CREATE TABLE IF NOT EXISTS router_health_history (
+    id BIGSERIAL PRIMARY KEY,
+    router_id VARCHAR(100) NOT NULL,
+    reachable BOOLEAN NOT NULL,
+    cpu_load INTEGER NOT NULL DEFAULT 0, -- percent
+    memory_used_percent NUMERIC(5, 2) NOT NULL DEFAULT 0,
+    free_memory BIGINT NOT NULL DEFAULT 0, -- bytes
+    total_memory BIGINT NOT NULL DEFAULT 0,
+    uptime_seconds BIGINT NOT NULL DEFAULT 0,
+    version VARCHAR(50),
+    temperature NUMERIC(5, 1), -- °C, NULL when the board has no sensor
+    voltage NUMERIC(5, 1), -- V, NULL when the board has no sensor
+    ppp_active INTEGER NOT NULL DEFAULT 0,
+    interface_errors BIGINT NOT NULL DEFAULT 0, -- errors + drops on all interfaces since the previous sample
+    sampled_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_router_health_router_time ON router_health_history(router_id, sampled_at);
+CREATE INDEX IF NOT EXISTS idx_router_health_sampled_at ON router_health_history(sampled_at);
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const monitorOwnerKeyPrefix = "mikrotik:monitor:owner:"
+
+// Lease scripts only touch the key while it still holds the caller's token,
+// so a replica whose lease expired cannot extend or delete its successor's
+var (
+	renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// RedisMonitorCoordinator shares customer monitors between collector replicas.
+// The replica holding a customer's lease watches the router and writes events to
+// the customer's traffic topic; every other replica with clients for that
+// customer reads the topic's stream.
+type RedisMonitorCoordinator struct {
+	client     *redis.Client
+	instanceID string
+}
+
+// NewRedisMonitorCoordinator creates a coordinator on the publisher's connection
+func NewRedisMonitorCoordinator(publisher *RedisPublisher, instanceID string) *RedisMonitorCoordinator {
+	return &RedisMonitorCoordinator{
+		client:     publisher.client,
+		instanceID: instanceID,
+	}
+}
+
+// InstanceID identifies this replica in lease tokens
+func (c *RedisMonitorCoordinator) InstanceID() string {
+	return c.instanceID
+}
+
+// AcquireMonitor takes the customer's lease if no replica holds it
+func (c *RedisMonitorCoordinator) AcquireMonitor(customerID, token string, ttl time.Duration) (bool, error) {
+	ok, err := c.client.SetNX(context.Background(), monitorOwnerKeyPrefix+customerID, token, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire monitor lease: %w", err)
+	}
+	return ok, nil
+}
+
+// RenewMonitor extends the lease; false means another replica holds it now
+func (c *RedisMonitorCoordinator) RenewMonitor(customerID, token string, ttl time.Duration) (bool, error) {
+	n, err := renewLeaseScript.Run(context.Background(), c.client,
+		[]string{monitorOwnerKeyPrefix + customerID}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew monitor lease: %w", err)
+	}
+	return n == 1, nil
+}
+
+// ReleaseMonitor drops the lease so a follower can take over right away
+func (c *RedisMonitorCoordinator) ReleaseMonitor(customerID, token string) error {
+	err := releaseLeaseScript.Run(context.Background(), c.client,
+		[]string{monitorOwnerKeyPrefix + customerID}, token).Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to release monitor lease: %w", err)
+	}
+	return nil
+}
+
+// SubscribeEvents reads the customer's traffic topic from now on. Redis errors are
+// retried; the channel is closed once ctx is cancelled.
+func (c *RedisMonitorCoordinator) SubscribeEvents(ctx context.Context, customerID string) <-chan domain.TrafficEvent {
+	events := make(chan domain.TrafficEvent, 16)
+	key := topicStreamKey(domain.CustomerTrafficTopic(customerID))
+
+	go func() {
+		defer close(events)
+
+		// Start from the current tail, not "$", so a handover event published
+		// between two reads is not skipped
+		lastID := lastStreamID(ctx, c.client, key)
+		for ctx.Err() == nil {
+			streams, err := c.client.XRead(ctx, &redis.XReadArgs{
+				Streams: []string{key, lastID},
+				Count:   50,
+				Block:   2 * time.Second,
+			}).Result()
+			if err != nil {
+				if err == redis.Nil || ctx.Err() != nil {
+					continue
+				}
+				log.Printf("[Coordinator] Error reading %s: %v", key, err)
+				time.Sleep(time.Second)
+				continue
+			}
+
+			for _, stream := range streams {
+				for _, message := range stream.Messages {
+					lastID = message.ID
+
+					data, ok := message.Values["data"].(string)
+					if !ok {
+						continue
+					}
+					var event domain.TrafficEvent
+					if err := json.Unmarshal([]byte(data), &event); err != nil {
+						log.Printf("[Coordinator] Invalid event in %s: %v", key, err)
+						continue
+					}
+
+					select {
+					case events <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return events
+}
@@ -4,14 +4,27 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+	"mikrotik-collector/internal/infrastructure/metrics"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// topicKeyPrefix prefixes the stream of every /ws topic
+const topicKeyPrefix = "mikrotik:topic:"
+
 // RedisPublisher handles publishing to Redis
 type RedisPublisher struct {
 	client *redis.Client
 	ctx    context.Context
+
+	trafficRetention time.Duration
+	eventsRetention  time.Duration
+	alertsRetention  time.Duration
 }
 
 // NewRedisPublisher creates a new Redis publisher
@@ -32,8 +45,11 @@ func NewRedisPublisher(cfg *Config) *RedisPublisher {
 	}
 
 	return &RedisPublisher{
-		client: client,
-		ctx:    ctx,
+		client:           client,
+		ctx:              ctx,
+		trafficRetention: cfg.TopicTrafficRetention,
+		eventsRetention:  cfg.TopicEventsRetention,
+		alertsRetention:  cfg.TopicAlertsRetention,
 	}
 }
 
@@ -41,6 +57,7 @@ func NewRedisPublisher(cfg *Config) *RedisPublisher {
 func (r *RedisPublisher) Publish(channel string, message string) error {
 	err := r.client.Publish(r.ctx, channel, message).Err()
 	if err != nil {
+		metrics.RedisPublishErrors.WithLabelValues(metrics.RedisPubSub).Inc()
 		return fmt.Errorf("failed to publish to channel %s: %w", channel, err)
 	}
 	return nil
@@ -57,17 +74,71 @@ func (r *RedisPublisher) PublishStream(streamKey string, message string) error {
 		},
 	}).Err()
 	if err != nil {
+		metrics.RedisPublishErrors.WithLabelValues(metrics.RedisStream).Inc()
 		return fmt.Errorf("failed to publish to stream %s: %w", streamKey, err)
 	}
 	return nil
 }
 
+// PublishTopic appends a message to the topic's stream. Entries older than the
+// topic's retention are trimmed, and the key expires once nothing is published.
+func (r *RedisPublisher) PublishTopic(topic string, message string) error {
+	key := topicStreamKey(topic)
+	retention := r.topicRetention(topic)
+	minID := strconv.FormatInt(time.Now().Add(-retention).UnixMilli(), 10)
+
+	pipe := r.client.Pipeline()
+	pipe.XAdd(r.ctx, &redis.XAddArgs{
+		Stream: key,
+		MinID:  minID,
+		Approx: true,
+		Values: map[string]interface{}{
+			"data": message,
+		},
+	})
+	pipe.Expire(r.ctx, key, retention)
+
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		metrics.RedisPublishErrors.WithLabelValues(metrics.RedisStream).Inc()
+		return fmt.Errorf("failed to publish to topic %s: %w", topic, err)
+	}
+	return nil
+}
+
+// PublishEvent sends an event to the mikrotik:events channel, which legacy /ws
+// clients receive, and to the streams of its topics
+func (r *RedisPublisher) PublishEvent(message string, topics ...string) error {
+	err := r.Publish("mikrotik:events", message)
+	for _, topic := range topics {
+		if topicErr := r.PublishTopic(topic, message); topicErr != nil && err == nil {
+			err = topicErr
+		}
+	}
+	return err
+}
+
+func (r *RedisPublisher) topicRetention(topic string) time.Duration {
+	switch {
+	case topic == domain.TopicAlerts:
+		return r.alertsRetention
+	case strings.HasPrefix(topic, "router:"):
+		return r.eventsRetention
+	default:
+		return r.trafficRetention
+	}
+}
+
+// topicStreamKey is the Redis stream holding a topic's messages
+func topicStreamKey(topic string) string {
+	return topicKeyPrefix + topic
+}
+
 // Close closes the Redis connection
 func (r *RedisPublisher) Close() error {
 	return r.client.Close()
 }
 
-// IsConnected checks if Redis is connected
-func (r *RedisPublisher) IsConnected() bool {
-	return r.client.Ping(r.ctx).Err() == nil
+// Ping checks the Redis connection; used by the background health check
+func (r *RedisPublisher) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
 }
\ No newline at end of file
@@ -4,17 +4,33 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-// RedisStreamConsumer consumes messages from Redis Stream and broadcasts to WebSocket
+const (
+	broadcastGroupPrefix = "websocket-broadcasters:"
+
+	// Pending entries older than this are acked without being sent; clients
+	// only care about current traffic
+	pendingMaxAge = 10 * time.Second
+
+	// Groups of replicas whose consumers have been idle this long are removed
+	staleGroupIdle = time.Hour
+)
+
+// RedisStreamConsumer consumes messages from Redis Stream and broadcasts to WebSocket.
+// Every replica reads through its own consumer group so each one sees every
+// message and can fan it out to its own /ws clients.
 type RedisStreamConsumer struct {
-	client    *redis.Client
-	ctx       context.Context
-	streamKey string
-	broadcast chan<- []byte
+	client       *redis.Client
+	streamKey    string
+	group        string
+	consumerName string
+	broadcast    chan<- []byte
 }
 
 // NewRedisStreamConsumer creates a new Redis Stream consumer
@@ -25,72 +41,163 @@ func NewRedisStreamConsumer(cfg *Config, broadcast chan<- []byte) *RedisStreamCo
 		DB:       cfg.RedisDB,
 	})
 
-	ctx := context.Background()
-
 	// Test connection
-	if err := client.Ping(ctx).Err(); err != nil {
+	if err := client.Ping(context.Background()).Err(); err != nil {
 		log.Printf("WARNING: Redis connection failed in stream consumer: %v", err)
 	} else {
 		log.Printf("Redis Stream Consumer connected to %s", cfg.RedisAddr)
 	}
 
 	return &RedisStreamConsumer{
-		client:    client,
-		ctx:       ctx,
-		streamKey: "mikrotik:traffic:customers",
-		broadcast: broadcast,
+		client:       client,
+		streamKey:    "mikrotik:traffic:customers",
+		group:        broadcastGroupPrefix + cfg.InstanceID,
+		consumerName: cfg.InstanceID,
+		broadcast:    broadcast,
 	}
 }
 
-// Start begins consuming from Redis Stream
-func (c *RedisStreamConsumer) Start() {
-	// Create consumer group if it doesn't exist
-	// Ignore error if group already exists
-	c.client.XGroupCreateMkStream(c.ctx, c.streamKey, "websocket-broadcasters", "0")
-
-	consumerName := "broadcaster-1"
-	log.Printf("Starting Redis Stream consumer for stream: %s", c.streamKey)
+// Start begins consuming from Redis Stream until ctx is cancelled
+func (c *RedisStreamConsumer) Start(ctx context.Context) {
+	log.Printf("Starting Redis Stream consumer for stream: %s (group %s)", c.streamKey, c.group)
 
+	// The group starts at the end of the stream; a new replica has no use for history
 	for {
-		// Read from stream
-		streams, err := c.client.XReadGroup(c.ctx, &redis.XReadGroupArgs{
-			Group:    "websocket-broadcasters",
-			Consumer: consumerName,
-			Streams:  []string{c.streamKey, ">"},
+		err := c.client.XGroupCreateMkStream(ctx, c.streamKey, c.group, "$").Err()
+		if err == nil || strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			break
+		}
+		log.Printf("Error creating consumer group %s: %v", c.group, err)
+		if !sleepCtx(ctx, 5*time.Second) {
+			return
+		}
+	}
+
+	c.pruneStaleGroups(ctx)
+
+	// Entries delivered before a restart but never acked come first
+	c.consume(ctx, "0")
+	c.consume(ctx, ">")
+}
+
+// consume reads the group from id: "0" drains this consumer's pending entries
+// and returns once they are acked, ">" reads new entries until ctx is cancelled
+func (c *RedisStreamConsumer) consume(ctx context.Context, id string) {
+	for ctx.Err() == nil {
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    c.group,
+			Consumer: c.consumerName,
+			Streams:  []string{c.streamKey, id},
 			Count:    10,
 			Block:    time.Second * 2,
 		}).Result()
 
 		if err != nil {
-			if err == redis.Nil {
+			if err == redis.Nil || ctx.Err() != nil {
 				// No new messages, continue
 				continue
 			}
 			log.Printf("Error reading from stream: %v", err)
-			time.Sleep(time.Second)
+			sleepCtx(ctx, time.Second)
 			continue
 		}
 
-		// Process messages
+		read := 0
 		for _, stream := range streams {
 			for _, message := range stream.Messages {
-				// Extract data field
-				if data, ok := message.Values["data"].(string); ok {
-					// Validate JSON before broadcasting
-					var js json.RawMessage
-					if err := json.Unmarshal([]byte(data), &js); err != nil {
-						log.Printf("Invalid JSON in stream: %v", err)
-						continue
-					}
-
-					// Broadcast to WebSocket clients
-					c.broadcast <- []byte(data)
+				read++
+				if id == ">" || isRecentStreamID(message.ID, pendingMaxAge) {
+					c.forward(ctx, message)
 				}
 
 				// Acknowledge the message
-				c.client.XAck(c.ctx, c.streamKey, "websocket-broadcasters", message.ID)
+				c.client.XAck(ctx, c.streamKey, c.group, message.ID)
+			}
+		}
+
+		if id != ">" && read == 0 {
+			return // pending list drained
+		}
+	}
+}
+
+// forward sends a message's data to the WebSocket broadcaster
+func (c *RedisStreamConsumer) forward(ctx context.Context, message redis.XMessage) {
+	// Extract data field
+	data, ok := message.Values["data"].(string)
+	if !ok {
+		return
+	}
+
+	// Validate JSON before broadcasting
+	var js json.RawMessage
+	if err := json.Unmarshal([]byte(data), &js); err != nil {
+		log.Printf("Invalid JSON in stream: %v", err)
+		return
+	}
+
+	// Broadcast to WebSocket clients
+	select {
+	case c.broadcast <- []byte(data):
+	case <-ctx.Done():
+	}
+}
+
+// pruneStaleGroups removes the groups of replicas that are gone, so Redis does
+// not keep tracking pending entries for them forever
+func (c *RedisStreamConsumer) pruneStaleGroups(ctx context.Context) {
+	groups, err := c.client.XInfoGroups(ctx, c.streamKey).Result()
+	if err != nil {
+		log.Printf("Warning: failed to list consumer groups of %s: %v", c.streamKey, err)
+		return
+	}
+
+	for _, group := range groups {
+		if group.Name == c.group || !strings.HasPrefix(group.Name, broadcastGroupPrefix) {
+			continue
+		}
+
+		consumers, err := c.client.XInfoConsumers(ctx, c.streamKey, group.Name).Result()
+		if err != nil {
+			continue
+		}
+		stale := true
+		for _, consumer := range consumers {
+			if consumer.Idle < staleGroupIdle {
+				stale = false
+				break
 			}
 		}
+		// A group without consumers may belong to a replica that is just starting
+		if !stale || len(consumers) == 0 {
+			continue
+		}
+
+		if err := c.client.XGroupDestroy(ctx, c.streamKey, group.Name).Err(); err != nil {
+			log.Printf("Warning: failed to remove stale consumer group %s: %v", group.Name, err)
+			continue
+		}
+		log.Printf("Removed stale consumer group %s", group.Name)
+	}
+}
+
+// isRecentStreamID reports whether a stream entry was added within maxAge,
+// using the millisecond timestamp in its ID
+func isRecentStreamID(id string, maxAge time.Duration) bool {
+	ms, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	return time.Since(time.UnixMilli(ms)) < maxAge
+}
+
+// sleepCtx waits for d and returns false if ctx is cancelled first
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
 	}
 }
 
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTopicConsumer reads the streams of the topics this replica's /ws
+// clients are subscribed to, so a replica only receives what its clients need
+type RedisTopicConsumer struct {
+	client  *redis.Client
+	topics  func() []string
+	deliver func(topic string, data []byte)
+}
+
+// NewRedisTopicConsumer creates a topic consumer on the publisher's connection.
+// topics is polled for the current subscriptions; deliver receives every message.
+func NewRedisTopicConsumer(publisher *RedisPublisher, topics func() []string, deliver func(topic string, data []byte)) *RedisTopicConsumer {
+	return &RedisTopicConsumer{
+		client:  publisher.client,
+		topics:  topics,
+		deliver: deliver,
+	}
+}
+
+// Start reads subscribed topics until ctx is cancelled. A topic is read from the
+// moment it is first subscribed; new subscriptions are picked up within a second.
+func (c *RedisTopicConsumer) Start(ctx context.Context) {
+	lastIDs := make(map[string]string) // topic -> last delivered stream ID
+
+	for ctx.Err() == nil {
+		topics := c.topics()
+		if len(topics) == 0 {
+			clear(lastIDs)
+			sleepCtx(ctx, time.Second)
+			continue
+		}
+
+		current := make(map[string]bool, len(topics))
+		streams := make([]string, 0, 2*len(topics))
+		ids := make([]string, 0, len(topics))
+		for _, topic := range topics {
+			current[topic] = true
+			if _, ok := lastIDs[topic]; !ok {
+				lastIDs[topic] = lastStreamID(ctx, c.client, topicStreamKey(topic))
+			}
+			streams = append(streams, topicStreamKey(topic))
+			ids = append(ids, lastIDs[topic])
+		}
+		for topic := range lastIDs {
+			if !current[topic] {
+				delete(lastIDs, topic)
+			}
+		}
+
+		result, err := c.client.XRead(ctx, &redis.XReadArgs{
+			Streams: append(streams, ids...),
+			Count:   100,
+			Block:   time.Second,
+		}).Result()
+		if err != nil {
+			if err == redis.Nil || ctx.Err() != nil {
+				continue
+			}
+			log.Printf("[Topics] Error reading topic streams: %v", err)
+			sleepCtx(ctx, time.Second)
+			continue
+		}
+
+		for _, stream := range result {
+			topic := stream.Stream[len(topicKeyPrefix):]
+			for _, message := range stream.Messages {
+				lastIDs[topic] = message.ID
+				if data, ok := message.Values["data"].(string); ok {
+					c.deliver(topic, []byte(data))
+				}
+			}
+		}
+	}
+}
+
+// lastStreamID is the newest entry of a stream, so reading starts from now.
+// A "$" would be re-evaluated on every read and lose entries in between.
+func lastStreamID(ctx context.Context, client *redis.Client, key string) string {
+	entries, err := client.XRevRangeN(ctx, key, "+", "-", 1).Result()
+	switch {
+	case err != nil:
+		return strconv.FormatInt(time.Now().UnixMilli(), 10) + "-0"
+	case len(entries) == 0:
+		return "0-0"
+	default:
+		return entries[0].ID
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"mikrotik-collector/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTrafficHistory reads past samples from the customer traffic topics, which
+// keep TOPIC_TRAFFIC_RETENTION_MINUTES of every monitored customer
+type RedisTrafficHistory struct {
+	client *redis.Client
+}
+
+// NewRedisTrafficHistory creates a traffic history on the publisher's connection
+func NewRedisTrafficHistory(publisher *RedisPublisher) *RedisTrafficHistory {
+	return &RedisTrafficHistory{client: publisher.client}
+}
+
+// RecentTraffic returns up to limit samples taken since since, oldest first
+func (h *RedisTrafficHistory) RecentTraffic(customerID string, since time.Time, limit int) ([]domain.CustomerTrafficData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Newest first, so a limit keeps the most recent part of the window
+	key := topicStreamKey(domain.CustomerTrafficTopic(customerID))
+	start := strconv.FormatInt(since.UnixMilli(), 10)
+	messages, err := h.client.XRevRangeN(ctx, key, "+", start, int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read traffic history: %w", err)
+	}
+
+	samples := make([]domain.CustomerTrafficData, 0, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		data, ok := messages[i].Values["data"].(string)
+		if !ok {
+			continue
+		}
+		var event domain.TrafficEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		if event.Type == domain.TrafficEventUpdate && event.Traffic != nil {
+			samples = append(samples, *event.Traffic)
+		}
+	}
+	return samples, nil
+}
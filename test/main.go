@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -111,14 +112,14 @@ func main() {
 			formatValue(traffic.RxPacketsPerSecond), 
 			formatValue(traffic.TxPacketsPerSecond))
 		
-		if traffic.RxDropsPerSecond != "0" || traffic.TxDropsPerSecond != "0" {
+		if traffic.RxDropsPerSecond != 0 || traffic.TxDropsPerSecond != 0 {
 			fmt.Printf("│ %-20s │ %-20s │ %-20s │\n", 
 				"Drops/sec", 
 				formatValue(traffic.RxDropsPerSecond), 
 				formatValue(traffic.TxDropsPerSecond))
 		}
 		
-		if traffic.RxErrorsPerSecond != "0" || traffic.TxErrorsPerSecond != "0" {
+		if traffic.RxErrorsPerSecond != 0 || traffic.TxErrorsPerSecond != 0 {
 			fmt.Printf("│ %-20s │ %-20s │ %-20s │\n", 
 				"Errors/sec", 
 				formatValue(traffic.RxErrorsPerSecond), 
@@ -135,9 +136,6 @@ func main() {
 }
 
 // formatValue memformat nilai untuk tampilan yang lebih baik
-func formatValue(val string) string {
-	if val == "" {
-		return "0"
-	}
-	return val
+func formatValue(val uint64) string {
+	return strconv.FormatUint(val, 10)
 }
\ No newline at end of file